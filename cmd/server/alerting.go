@@ -0,0 +1,17 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/alerting"
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/mailer"
+)
+
+// StartAlerting runs the offline alert evaluator in the background for the life of the process
+func StartAlerting(env Environment, store db.Store, mail mailer.Mailer) {
+	log.Printf("Evaluating alert rules every %s", env.AlertEvalInterval)
+	evaluator := alerting.NewEvaluator(store, alerting.Notifiers(mail), env.AlertEvalInterval)
+	go evaluator.Run(context.Background())
+}
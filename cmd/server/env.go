@@ -3,6 +3,9 @@ package main
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Environment struct {
@@ -21,6 +24,27 @@ type Environment struct {
 	SpacesCDNURL    string
 	SpacesAccessKey string
 	SpacesSecretKey string
+	SMTPHost        string
+	SMTPPort        int
+	SMTPUsername    string
+	SMTPPassword    string
+	MailFrom        string
+	MailLogPath     string
+	PublicURL       string
+	DisableSignup   bool
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       string
+	MQTTBrokerURL    string
+	MQTTUsername     string
+	MQTTPassword     string
+	HeartbeatStaleAfter   time.Duration
+	HeartbeatOfflineAfter time.Duration
+	AlertEvalInterval     time.Duration
+	ScreenshotKeep        int
+	ScreenshotMaxAge      time.Duration
 }
 
 // LoadEnvironment reads and validates env vars
@@ -44,6 +68,43 @@ func LoadEnvironment() Environment {
 		SpacesCDNURL:    os.Getenv("SPACES_CDN_URL"),
 		SpacesAccessKey: os.Getenv("SPACES_ACCESS_KEY"),
 		SpacesSecretKey: os.Getenv("SPACES_SECRET_KEY"),
+
+		SMTPHost:        os.Getenv("SMTP_HOST"),
+		SMTPPort:        envInt("SMTP_PORT", 587),
+		SMTPUsername:    os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:    os.Getenv("SMTP_PASSWORD"),
+		MailFrom:        os.Getenv("MAIL_FROM"),
+		MailLogPath:     os.Getenv("MAIL_LOG_PATH"),
+
+		// base URL of the dashboard, used to build links sent by email
+		PublicURL:       strings.TrimRight(os.Getenv("PUBLIC_URL"), "/"),
+
+		// when true, accounts can only be created by accepting an invitation
+		DisableSignup:   os.Getenv("DISABLE_SIGNUP") == "true",
+
+		// single sign-on is enabled when an issuer is set; the redirect URL is the
+		// dashboard page that posts the returned code to /auth/oidc/callback
+		OIDCIssuer:       os.Getenv("OIDC_ISSUER"),
+		OIDCClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		OIDCScopes:       os.Getenv("OIDC_SCOPES"), // space separated, defaults to "openid email profile"
+
+		// the server subscribes to device heartbeats over MQTT when a broker is set
+		MQTTBrokerURL:    os.Getenv("MQTT_BROKER_URL"),
+		MQTTUsername:     os.Getenv("MQTT_USERNAME"),
+		MQTTPassword:     os.Getenv("MQTT_PASSWORD"),
+
+		// seconds without a heartbeat before a screen is reported stale, then offline
+		HeartbeatStaleAfter:   time.Duration(envInt("HEARTBEAT_STALE_AFTER", 90)) * time.Second,
+		HeartbeatOfflineAfter: time.Duration(envInt("HEARTBEAT_OFFLINE_AFTER", 300)) * time.Second,
+
+		// seconds between evaluations of the offline alert rules
+		AlertEvalInterval:     time.Duration(envInt("ALERT_EVAL_INTERVAL", 60)) * time.Second,
+
+		// screenshots kept per screen, and days before any screenshot is deleted
+		ScreenshotKeep:        envInt("SCREENSHOT_RETENTION_COUNT", 20),
+		ScreenshotMaxAge:      time.Duration(envInt("SCREENSHOT_RETENTION_DAYS", 30)) * 24 * time.Hour,
 	}
 
 	// Basic validation
@@ -51,5 +112,35 @@ func LoadEnvironment() Environment {
 		log.Fatal("Missing required environment variables")
 	}
 
+	if env.SMTPHost != "" && env.MailFrom == "" {
+		log.Fatal("MAIL_FROM is required when SMTP_HOST is set")
+	}
+
+	if env.AlertEvalInterval <= 0 {
+		log.Fatal("ALERT_EVAL_INTERVAL must be positive")
+	}
+
+	if env.ScreenshotKeep <= 0 || env.ScreenshotMaxAge <= 0 {
+		log.Fatal("SCREENSHOT_RETENTION_COUNT and SCREENSHOT_RETENTION_DAYS must be positive")
+	}
+
+	if env.HeartbeatOfflineAfter < env.HeartbeatStaleAfter {
+		log.Fatal("HEARTBEAT_OFFLINE_AFTER must not be shorter than HEARTBEAT_STALE_AFTER")
+	}
+
 	return env
 }
+
+// envInt reads an integer env var, falling back to def when unset or malformed.
+func envInt(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Printf("invalid %s=%q, using %d", key, raw, def)
+		return def
+	}
+	return v
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/mailer"
+)
+
+// InitMailer selects and returns the configured mail backend
+func InitMailer(env Environment) mailer.Mailer {
+	if env.SMTPHost != "" {
+		log.Printf("Using SMTP mailer via %s:%d", env.SMTPHost, env.SMTPPort)
+		return mailer.NewSMTPMailer(env.SMTPHost, env.SMTPPort, env.SMTPUsername, env.SMTPPassword, env.MailFrom)
+	}
+
+	log.Printf("SMTP_HOST not set, emails will only be logged")
+	return mailer.NewLogMailer(env.MailLogPath)
+}
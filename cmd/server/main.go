@@ -32,6 +32,21 @@ func main() {
 	// Storage
 	storageSystem := InitStorage(env)
 
+	// Mail
+	mail := InitMailer(env)
+
+	// Single sign-on
+	sso := InitSSO(env)
+
+	// MQTT
+	InitMQTT(env)
+
+	// Offline alerting
+	StartAlerting(env, store, mail)
+
+	// Abandoned chunked uploads
+	StartUploadSweeper(store, storageSystem)
+
 	// Templates
 	tmpl := LoadTemplates()
 
@@ -39,7 +54,7 @@ func main() {
 	r := gin.Default()
 
 	// Register routes
-	RegisterRoutes(r, env, store, storageSystem, tmpl)
+	RegisterRoutes(r, env, store, storageSystem, mail, sso, tmpl)
 
 	// Start server
 	log.Printf("Listening on %s", env.ServerAddress)
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+)
+
+// InitMQTT opens the server's one connection to the MQTT broker, when one is configured,
+// and subscribes to device heartbeats. Commands and content changes are published over the
+// same connection.
+func InitMQTT(env Environment) {
+	if env.MQTTBrokerURL == "" {
+		log.Printf("MQTT_BROKER_URL not set, MQTT disabled")
+		return
+	}
+
+	// devices log in to the broker under their device IDs, so the server's name must not be one
+	if middleware.IsDeviceIDFormat(env.MQTTUsername) {
+		log.Fatalf("MQTT_USERNAME must not be a UUID; devices log in to the broker under their UUIDs")
+	}
+
+	middleware.SetBrokerURL(env.MQTTBrokerURL)
+	middleware.SetBrokerUser(env.MQTTUsername)
+	middleware.SetBrokerPass(env.MQTTPassword)
+	middleware.SubscribeHeartbeats()
+
+	// devices can still poll and send heartbeats over HTTP, so a broker that is down is not fatal
+	if _, err := middleware.CreateMQTTClient(middleware.ServerClientID); err != nil {
+		log.Printf("mqtt connect: %v", err)
+	}
+}
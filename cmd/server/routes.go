@@ -10,6 +10,9 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/mailer"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+	"github.com/Nixie-Tech-LLC/medusa/internal/oidc"
 	"github.com/Nixie-Tech-LLC/medusa/internal/storage"
 	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
 	adminapi 	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/endpoints"
@@ -19,7 +22,7 @@ import (
 
 
 // RegisterRoutes sets up all application routes
-func RegisterRoutes(r *gin.Engine, env Environment, store db.Store, storageSystem storage.Storage, tmpl *template.Template) {
+func RegisterRoutes(r *gin.Engine, env Environment, store db.Store, storageSystem storage.Storage, mail mailer.Mailer, sso *oidc.Provider, tmpl *template.Template) {
 	r.SetHTMLTemplate(tmpl)
 	// CORS
 	r.Use(cors.New(cors.Config{
@@ -40,6 +43,9 @@ func RegisterRoutes(r *gin.Engine, env Environment, store db.Store, storageSyste
 			"Accept", 
 			"If-None-Match", 
 			"X-If-None-Match",
+			"X-Organization-ID",
+			"X-Device-Token",
+			"Upload-Offset",
 		},
 		ExposeHeaders:[]string{
 			"Content-Length",
@@ -53,9 +59,14 @@ func RegisterRoutes(r *gin.Engine, env Environment, store db.Store, storageSyste
 		Prefix: "/api/admin",
 		Auth:   false,
 	}, 
-		authapi.AuthPublicModule(env.SecretKey, store),
+		authapi.AuthPublicModule(env.SecretKey, store, mail, env.PublicURL, sso, !env.DisableSignup),
 	)
 
+	presence := model.PresenceThresholds{
+		StaleAfter:   env.HeartbeatStaleAfter,
+		OfflineAfter: env.HeartbeatOfflineAfter,
+	}
+
 	api.MountGroup(r, api.GroupConfig{
 		Prefix:    "/api/admin",
 		Auth:      true,
@@ -63,19 +74,31 @@ func RegisterRoutes(r *gin.Engine, env Environment, store db.Store, storageSyste
 	}, 
 		// control modules
 		adminapi.ContentModule(store, storageSystem),
-		adminapi.ScreenModule(store),
+		adminapi.ScreenModule(store, storageSystem, presence),
 		adminapi.PlaylistModule(store),
 		// session endpoints that require auth
-		authapi.AuthSessionModule(env.SecretKey, store),
+		authapi.AuthSessionModule(env.SecretKey, store, mail, env.PublicURL),
 		adminapi.ScheduleModule(store),
-		adminapi.ScreenGroupModule(store),
+		adminapi.ScreenGroupModule(store, presence),
+		adminapi.OrganizationModule(store),
+		adminapi.AuditModule(store),
+		adminapi.InvitationModule(store, mail, env.PublicURL),
+		adminapi.AlertModule(store),
+		adminapi.ReportModule(store),
+		adminapi.CommandModule(store),
+		adminapi.TelemetryModule(store),
 	)
 
 	api.MountGroup(r, api.GroupConfig{
 		Prefix: "/api/tv",
 	}, 
-		clientapi.PairingModule(store),
+		clientapi.PairingModule(store, storageSystem, model.ScreenshotRetention{
+			Keep:   env.ScreenshotKeep,
+			MaxAge: env.ScreenshotMaxAge,
+		}),
 		clientapi.IntegrationsModule(),
+		// called by the MQTT broker to check device logins and topic access
+		clientapi.BrokerAuthModule(store),
 	)
 
 	// Static content
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/oidc"
+)
+
+// InitSSO returns the configured OIDC provider, or nil when single sign-on is disabled
+func InitSSO(env Environment) *oidc.Provider {
+	if env.OIDCIssuer == "" {
+		return nil
+	}
+	if env.OIDCClientID == "" || env.OIDCRedirectURL == "" {
+		log.Fatal("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required when OIDC_ISSUER is set")
+	}
+
+	log.Printf("Using OIDC single sign-on with issuer %s", env.OIDCIssuer)
+	return oidc.NewProvider(oidc.Config{
+		Issuer:       env.OIDCIssuer,
+		ClientID:     env.OIDCClientID,
+		ClientSecret: env.OIDCClientSecret,
+		RedirectURL:  env.OIDCRedirectURL,
+		Scopes:       strings.Fields(env.OIDCScopes),
+	})
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/storage"
+)
+
+// how often abandoned chunked uploads are looked for
+const uploadSweepInterval = time.Hour
+
+// StartUploadSweeper discards expired chunked uploads in the background for the life of the process
+func StartUploadSweeper(store db.Store, storageSystem storage.Storage) {
+	go func() {
+		ticker := time.NewTicker(uploadSweepInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			expired, err := store.DeleteExpiredContentUploads(time.Now())
+			if err != nil {
+				continue
+			}
+			for _, u := range expired {
+				if err := storageSystem.AbortUpload(storage.PendingUploadFor(u)); err != nil {
+					log.Printf("could not discard expired upload %s: %v", u.ID, err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,154 @@
+// Package alerting watches screens for lost contact and notifies the channels configured
+// on each alert rule.
+package alerting
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+// Alert is a change in a rule's state for one screen.
+type Alert struct {
+	Rule        model.AlertRule
+	Screen      model.Screen
+	State       string // model.AlertFiring or model.AlertResolved
+	LastContact *time.Time
+	At          time.Time
+}
+
+const (
+	// an undelivered notification is retried this often, up to maxNotifyAttempts times
+	notifyRetryAfter  = 5 * time.Minute
+	maxNotifyAttempts = 10
+	// notifications sent at once; each may wait on a slow webhook
+	notifyConcurrency = 8
+)
+
+// Notifier delivers alerts to one kind of channel; the destination is the rule's Target.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// Evaluator periodically checks every enabled rule and notifies on state changes. Alert
+// state is kept in the database, so restarts and additional server instances do not send
+// duplicate notifications.
+type Evaluator struct {
+	store     db.Store
+	notifiers map[string]Notifier
+	interval  time.Duration
+}
+
+// NewEvaluator returns an evaluator that runs every interval, notifying through the
+// notifier registered for each rule's channel.
+func NewEvaluator(store db.Store, notifiers map[string]Notifier, interval time.Duration) *Evaluator {
+	return &Evaluator{store: store, notifiers: notifiers, interval: interval}
+}
+
+// Run evaluates the rules every interval until ctx is cancelled.
+func (e *Evaluator) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		e.Evaluate(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Evaluate checks every enabled rule once, as of now, and sends the resulting notifications
+// along with the retries of earlier ones that failed.
+func (e *Evaluator) Evaluate(ctx context.Context, now time.Time) {
+	rules, err := e.store.ListEnabledAlertRules()
+	if err != nil {
+		return
+	}
+	var alerts []Alert
+	for _, rule := range rules {
+		alerts = append(alerts, e.evaluateRule(rule, now)...)
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, notifyConcurrency)
+	for _, alert := range alerts {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			e.notify(ctx, alert)
+		}()
+	}
+	wg.Wait()
+}
+
+// evaluateRule records the rule's state changes and returns the alerts to notify.
+func (e *Evaluator) evaluateRule(rule model.AlertRule, now time.Time) []Alert {
+	screens, err := e.store.ListAlertRuleScreens(rule.ID)
+	if err != nil {
+		return nil
+	}
+	states, err := e.store.ListAlertRuleStates(rule.ID)
+	if err != nil {
+		return nil
+	}
+	retries, err := e.store.ClaimAlertRetries(rule.ID, notifyRetryAfter, maxNotifyAttempts)
+	if err != nil {
+		return nil
+	}
+
+	var alerts []Alert
+
+	offlineAfter := time.Duration(rule.OfflineAfterSeconds) * time.Second
+	for _, screen := range screens {
+		// a screen that has never made contact has no contact to lose
+		if screen.LastSeenAt == nil {
+			continue
+		}
+
+		want := model.AlertResolved
+		if now.Sub(*screen.LastSeenAt) >= offlineAfter {
+			want = model.AlertFiring
+		}
+		current, known := states[screen.ID]
+		if current == want || (!known && want == model.AlertResolved) {
+			// the state stands; resend its notification if delivering it failed
+			if retry, ok := retries[screen.ID]; ok && retry == want {
+				alerts = append(alerts, Alert{Rule: rule, Screen: screen, State: want, LastContact: screen.LastSeenAt, At: now})
+			}
+			continue
+		}
+
+		changed, err := e.store.SetAlertState(rule.ID, screen.ID, want)
+		if err != nil || !changed {
+			continue
+		}
+		alerts = append(alerts, Alert{Rule: rule, Screen: screen, State: want, LastContact: screen.LastSeenAt, At: now})
+	}
+	return alerts
+}
+
+func (e *Evaluator) notify(ctx context.Context, alert Alert) {
+	notifier, ok := e.notifiers[alert.Rule.Channel]
+	if !ok {
+		log.Error().Int("rule_id", alert.Rule.ID).Str("channel", alert.Rule.Channel).Msg("no notifier for alert channel")
+		return
+	}
+	if err := notifier.Notify(ctx, alert); err != nil {
+		log.Error().Err(err).Int("rule_id", alert.Rule.ID).Int("screen_id", alert.Screen.ID).
+			Str("state", alert.State).Msg("failed to send alert notification")
+		return
+	}
+	_ = e.store.MarkAlertNotified(alert.Rule.ID, alert.Screen.ID, alert.State)
+	log.Info().Int("rule_id", alert.Rule.ID).Int("screen_id", alert.Screen.ID).Str("state", alert.State).
+		Msg("sent alert notification")
+}
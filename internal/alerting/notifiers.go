@@ -0,0 +1,201 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/mailer"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+// ErrPrivateTarget is returned for webhook URLs that point into private, loopback,
+// link-local (including cloud metadata) or otherwise internal address ranges.
+var ErrPrivateTarget = errors.New("webhook target is not a public address")
+
+// address ranges the standard library does not classify but that are still not public
+var reservedNetworks = []*net.IPNet{
+	mustCIDR("0.0.0.0/8"),
+	mustCIDR("100.64.0.0/10"), // carrier-grade NAT
+	mustCIDR("192.0.0.0/24"),
+	mustCIDR("198.18.0.0/15"),
+	mustCIDR("240.0.0.0/4"),
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// isPublicIP reports whether ip may be the destination of a webhook.
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, n := range reservedNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateWebhookURL rejects webhook targets that are not http(s) URLs or that name an
+// internal host outright. Hostnames are checked again on every delivery, after they resolve.
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("target must be an http(s) URL")
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		return ErrPrivateTarget
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return ErrPrivateTarget
+	}
+	return nil
+}
+
+// webhookClient only connects to public addresses. The check runs on the resolved address
+// of every connection, redirects included, so DNS cannot point a webhook inside the network.
+func webhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return ErrPrivateTarget
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// Notifiers returns a notifier for every channel an alert rule may use.
+func Notifiers(mail mailer.Mailer) map[string]Notifier {
+	client := webhookClient()
+	return map[string]Notifier{
+		model.AlertChannelWebhook: &WebhookNotifier{client: client},
+		model.AlertChannelSlack:   &SlackNotifier{client: client},
+		model.AlertChannelEmail:   &EmailNotifier{mail: mail},
+	}
+}
+
+// WebhookPayload is the JSON body posted to generic webhooks.
+type WebhookPayload struct {
+	Event         string  `json:"event"`
+	State         string  `json:"state"`
+	RuleID        int     `json:"rule_id"`
+	RuleName      string  `json:"rule_name"`
+	ScreenID      int     `json:"screen_id"`
+	ScreenName    string  `json:"screen_name"`
+	DeviceID      *string `json:"device_id"`
+	LastContactAt *string `json:"last_contact_at"`
+	At            string  `json:"at"`
+}
+
+// WebhookNotifier posts a WebhookPayload to the rule's target URL.
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	payload := WebhookPayload{
+		Event:      "screen.offline",
+		State:      alert.State,
+		RuleID:     alert.Rule.ID,
+		RuleName:   alert.Rule.Name,
+		ScreenID:   alert.Screen.ID,
+		ScreenName: alert.Screen.Name,
+		DeviceID:   alert.Screen.DeviceID,
+		At:         alert.At.UTC().Format(time.RFC3339),
+	}
+	if alert.LastContact != nil {
+		lastContact := alert.LastContact.UTC().Format(time.RFC3339)
+		payload.LastContactAt = &lastContact
+	}
+	return postJSON(ctx, n.client, alert.Rule.Target, payload)
+}
+
+// SlackNotifier posts a message to a Slack-compatible incoming webhook.
+type SlackNotifier struct {
+	client *http.Client
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, n.client, alert.Rule.Target, map[string]string{"text": summary(alert)})
+}
+
+// EmailNotifier mails the rule's target address.
+type EmailNotifier struct {
+	mail mailer.Mailer
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, alert Alert) error {
+	subject := fmt.Sprintf("[%s] Screen %q is offline", alert.State, alert.Screen.Name)
+	if alert.State == model.AlertResolved {
+		subject = fmt.Sprintf("[%s] Screen %q is back online", alert.State, alert.Screen.Name)
+	}
+	body := summary(alert) + fmt.Sprintf("\n\nAlert rule: %s\n", alert.Rule.Name)
+	return n.mail.Send(ctx, mailer.Message{To: alert.Rule.Target, Subject: subject, Body: body})
+}
+
+// summary describes the alert in one line.
+func summary(alert Alert) string {
+	if alert.State == model.AlertResolved {
+		return fmt.Sprintf("Screen %q is back online.", alert.Screen.Name)
+	}
+	lastContact := "never"
+	if alert.LastContact != nil {
+		lastContact = alert.LastContact.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprintf("Screen %q has made no contact for %s (last contact %s).",
+		alert.Screen.Name, time.Duration(alert.Rule.OfflineAfterSeconds)*time.Second, lastContact)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body any) error {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+const alertRuleColumns = `id, organization_id, name, screen_id, group_id, offline_after_seconds, channel, target, enabled, created_by, created_at, updated_at`
+
+func CreateAlertRule(r model.AlertRule) (model.AlertRule, error) {
+	var out model.AlertRule
+	err := DB.Get(&out, `
+		INSERT INTO alert_rules (organization_id, name, screen_id, group_id, offline_after_seconds, channel, target, enabled, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now(), now())
+		RETURNING `+alertRuleColumns+`;
+	`, r.OrganizationID, r.Name, r.ScreenID, r.GroupID, r.OfflineAfterSeconds, r.Channel, r.Target, r.Enabled, r.CreatedBy)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", r.OrganizationID).Msg("failed to create alert rule")
+	}
+	return out, err
+}
+
+// UpdateAlertRule replaces the rule's settings. It returns sql.ErrNoRows if the organization
+// has no such rule.
+func UpdateAlertRule(r model.AlertRule) (model.AlertRule, error) {
+	var out model.AlertRule
+	err := DB.Get(&out, `
+		UPDATE alert_rules
+		   SET name = $3,
+		       screen_id = $4,
+		       group_id = $5,
+		       offline_after_seconds = $6,
+		       channel = $7,
+		       target = $8,
+		       enabled = $9,
+		       updated_at = now()
+		 WHERE id = $1 AND organization_id = $2
+		RETURNING `+alertRuleColumns+`;
+	`, r.ID, r.OrganizationID, r.Name, r.ScreenID, r.GroupID, r.OfflineAfterSeconds, r.Channel, r.Target, r.Enabled)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Int("rule_id", r.ID).Msg("failed to update alert rule")
+	}
+	return out, err
+}
+
+// GetAlertRule returns sql.ErrNoRows if the organization has no such rule.
+func GetAlertRule(organizationID, id int) (model.AlertRule, error) {
+	var r model.AlertRule
+	err := DB.Get(&r, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE id = $1 AND organization_id = $2;`, id, organizationID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Int("rule_id", id).Msg("failed to get alert rule")
+	}
+	return r, err
+}
+
+func ListAlertRules(organizationID int) ([]model.AlertRule, error) {
+	var out []model.AlertRule
+	err := DB.Select(&out, `
+		SELECT `+alertRuleColumns+`
+		  FROM alert_rules
+		 WHERE organization_id = $1
+		 ORDER BY id;
+	`, organizationID)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Msg("failed to list alert rules")
+	}
+	return out, err
+}
+
+// ListEnabledAlertRules returns the enabled rules of every organization, for the evaluator.
+func ListEnabledAlertRules() ([]model.AlertRule, error) {
+	var out []model.AlertRule
+	err := DB.Select(&out, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE enabled ORDER BY id;`)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list enabled alert rules")
+	}
+	return out, err
+}
+
+// DeleteAlertRule returns sql.ErrNoRows if the organization has no such rule.
+func DeleteAlertRule(organizationID, id int) error {
+	res, err := DB.Exec(`DELETE FROM alert_rules WHERE id = $1 AND organization_id = $2;`, id, organizationID)
+	if err != nil {
+		log.Error().Err(err).Int("rule_id", id).Msg("failed to delete alert rule")
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListAlertRuleScreens returns the paired screens a rule watches.
+func ListAlertRuleScreens(ruleID int) ([]model.Screen, error) {
+	var screens []model.Screen
+	err := DB.Select(&screens, `
+		SELECT s.id, s.device_id, s.client_information, s.client_width, s.client_height,
+		       s.name, s.location, s.paired, s.organization_id, s.created_by, s.created_at, s.updated_at,
+		       s.last_seen_at, s.uptime_seconds, s.playlist_etag, s.app_version
+		  FROM alert_rules r
+		  JOIN screens s ON s.organization_id = r.organization_id
+		 WHERE r.id = $1
+		   AND s.paired
+		   AND (s.id = r.screen_id
+		        OR s.id IN (SELECT m.screen_id FROM screen_group_members m WHERE m.group_id = r.group_id))
+		 ORDER BY s.id;
+	`, ruleID)
+	if err != nil {
+		log.Error().Err(err).Int("rule_id", ruleID).Msg("failed to list alert rule screens")
+	}
+	return screens, err
+}
+
+// ListAlertRuleStates returns the rule's state per screen, keyed by screen ID.
+func ListAlertRuleStates(ruleID int) (map[int]string, error) {
+	var rows []struct {
+		ScreenID int    `db:"screen_id"`
+		State    string `db:"state"`
+	}
+	err := DB.Select(&rows, `SELECT screen_id, state FROM alert_states WHERE rule_id = $1;`, ruleID)
+	if err != nil {
+		log.Error().Err(err).Int("rule_id", ruleID).Msg("failed to list alert states")
+		return nil, err
+	}
+	out := make(map[int]string, len(rows))
+	for _, r := range rows {
+		out[r.ScreenID] = r.State
+	}
+	return out, nil
+}
+
+// SetAlertState moves the rule's alert for the screen to state and reports whether it
+// changed. Only the caller that makes the change sees true, so concurrent evaluators do
+// not notify twice. The change counts as the first delivery attempt; it stays undelivered
+// until MarkAlertNotified.
+func SetAlertState(ruleID, screenID int, state string) (bool, error) {
+	res, err := DB.Exec(`
+		INSERT INTO alert_states (rule_id, screen_id, state, fired_at, resolved_at, notified, notify_attempts, notify_attempted_at)
+		VALUES ($1, $2, $3, now(), CASE WHEN $3 = 'resolved' THEN now() END, false, 1, now())
+		ON CONFLICT (rule_id, screen_id) DO UPDATE
+		   SET state = EXCLUDED.state,
+		       fired_at = CASE WHEN EXCLUDED.state = 'firing' THEN now() ELSE alert_states.fired_at END,
+		       resolved_at = EXCLUDED.resolved_at,
+		       notified = false,
+		       notify_attempts = 1,
+		       notify_attempted_at = now()
+		 WHERE alert_states.state <> EXCLUDED.state;
+	`, ruleID, screenID, state)
+	if err != nil {
+		log.Error().Err(err).Int("rule_id", ruleID).Int("screen_id", screenID).Str("state", state).
+			Msg("failed to set alert state")
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// MarkAlertNotified records that the notification for the rule's alert on the screen was
+// delivered, unless the alert has since moved to another state.
+func MarkAlertNotified(ruleID, screenID int, state string) error {
+	_, err := DB.Exec(`
+		UPDATE alert_states SET notified = true
+		 WHERE rule_id = $1 AND screen_id = $2 AND state = $3;
+	`, ruleID, screenID, state)
+	if err != nil {
+		log.Error().Err(err).Int("rule_id", ruleID).Int("screen_id", screenID).Msg("failed to mark alert notified")
+	}
+	return err
+}
+
+// ClaimAlertRetries returns the rule's undelivered alerts, as screen ID to state, whose last
+// attempt is older than retryAfter and that have had fewer than maxAttempts, and counts a new
+// attempt for each. Like SetAlertState, only one evaluator claims each retry.
+func ClaimAlertRetries(ruleID int, retryAfter time.Duration, maxAttempts int) (map[int]string, error) {
+	var rows []struct {
+		ScreenID int    `db:"screen_id"`
+		State    string `db:"state"`
+	}
+	err := DB.Select(&rows, `
+		UPDATE alert_states
+		   SET notify_attempts = notify_attempts + 1, notify_attempted_at = now()
+		 WHERE rule_id = $1 AND NOT notified AND notify_attempts < $3
+		   AND notify_attempted_at < now() - make_interval(secs => $2)
+		RETURNING screen_id, state;
+	`, ruleID, retryAfter.Seconds(), maxAttempts)
+	if err != nil {
+		log.Error().Err(err).Int("rule_id", ruleID).Msg("failed to claim alert retries")
+		return nil, err
+	}
+	out := make(map[int]string, len(rows))
+	for _, r := range rows {
+		out[r.ScreenID] = r.State
+	}
+	return out, nil
+}
+
+// ListFiringAlerts returns the organization's alerts that are currently firing, leaving out
+// screens the rule no longer watches.
+func ListFiringAlerts(organizationID int) ([]model.AlertState, error) {
+	var out []model.AlertState
+	err := DB.Select(&out, `
+		SELECT a.rule_id, r.name AS rule_name, a.screen_id, s.name AS screen_name,
+		       a.state, a.fired_at, a.resolved_at
+		  FROM alert_states a
+		  JOIN alert_rules r ON r.id = a.rule_id
+		  JOIN screens s ON s.id = a.screen_id
+		 WHERE r.organization_id = $1 AND a.state = 'firing'
+		   AND r.enabled AND s.paired
+		   AND (s.id = r.screen_id
+		        OR s.id IN (SELECT m.screen_id FROM screen_group_members m WHERE m.group_id = r.group_id))
+		 ORDER BY a.fired_at DESC;
+	`, organizationID)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Msg("failed to list firing alerts")
+	}
+	return out, err
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+const apiKeyColumns = `id, organization_id, created_by, name, prefix, key_hash, expires_at, last_used_at, revoked_at, created_at`
+
+// CreateAPIKey stores a hashed key together with its scopes.
+func CreateAPIKey(organizationID, createdBy int, name, prefix, keyHash string, scopes []model.Permission, expiresAt *time.Time) (model.APIKey, error) {
+	var k model.APIKey
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return k, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = tx.Get(&k, `
+		INSERT INTO api_keys (organization_id, created_by, name, prefix, key_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now())
+		RETURNING `+apiKeyColumns+`;
+	`, organizationID, createdBy, name, prefix, keyHash, expiresAt)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Int("created_by", createdBy).
+			Msg("failed to create api key")
+		return model.APIKey{}, err
+	}
+
+	for _, scope := range scopes {
+		_, err = tx.Exec(`
+			INSERT INTO api_key_scopes (api_key_id, permission)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING;
+		`, k.ID, string(scope))
+		if err != nil {
+			log.Error().Err(err).Int("api_key_id", k.ID).Msg("failed to store api key scope")
+			return model.APIKey{}, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return model.APIKey{}, err
+	}
+	k.Scopes = scopes
+	return k, nil
+}
+
+// GetAPIKeyByHash returns sql.ErrNoRows if no key has the given hash.
+func GetAPIKeyByHash(keyHash string) (model.APIKey, error) {
+	var k model.APIKey
+	err := DB.Get(&k, `
+		SELECT `+apiKeyColumns+`
+		  FROM api_keys
+		 WHERE key_hash = $1;
+	`, keyHash)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Msg("failed to get api key")
+		}
+		return k, err
+	}
+	k.Scopes, err = listAPIKeyScopes(k.ID)
+	return k, err
+}
+
+func GetAPIKeyByID(id int) (model.APIKey, error) {
+	var k model.APIKey
+	err := DB.Get(&k, `
+		SELECT `+apiKeyColumns+`
+		  FROM api_keys
+		 WHERE id = $1;
+	`, id)
+	if err != nil {
+		log.Error().Err(err).Int("id", id).Msg("failed to get api key by id")
+		return k, err
+	}
+	k.Scopes, err = listAPIKeyScopes(k.ID)
+	return k, err
+}
+
+// ListAPIKeys returns the organization's keys, newest first, including revoked ones.
+func ListAPIKeys(organizationID int) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := DB.Select(&keys, `
+		SELECT `+apiKeyColumns+`
+		  FROM api_keys
+		 WHERE organization_id = $1
+		 ORDER BY created_at DESC, id DESC;
+	`, organizationID)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Msg("failed to list api keys")
+		return nil, err
+	}
+
+	for i := range keys {
+		keys[i].Scopes, err = listAPIKeyScopes(keys[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey returns sql.ErrNoRows if the key does not exist or is already revoked.
+func RevokeAPIKey(id int) error {
+	res, err := DB.Exec(`
+		UPDATE api_keys
+		   SET revoked_at = now()
+		 WHERE id = $1 AND revoked_at IS NULL;
+	`, id)
+	if err != nil {
+		log.Error().Err(err).Int("id", id).Msg("failed to revoke api key")
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// TouchAPIKey records that the key was used, at most once a minute to spare writes.
+func TouchAPIKey(id int) error {
+	_, err := DB.Exec(`
+		UPDATE api_keys
+		   SET last_used_at = now()
+		 WHERE id = $1
+		   AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
+	`, id)
+	if err != nil {
+		log.Error().Err(err).Int("id", id).Msg("failed to record api key use")
+	}
+	return err
+}
+
+func listAPIKeyScopes(apiKeyID int) ([]model.Permission, error) {
+	scopes := []model.Permission{}
+	err := DB.Select(&scopes, `
+		SELECT permission
+		  FROM api_key_scopes
+		 WHERE api_key_id = $1
+		 ORDER BY permission;
+	`, apiKeyID)
+	if err != nil {
+		log.Error().Err(err).Int("api_key_id", apiKeyID).Msg("failed to list api key scopes")
+	}
+	return scopes, err
+}
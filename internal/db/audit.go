@@ -0,0 +1,102 @@
+package db
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+func CreateAuditEvent(e model.AuditEvent) error {
+	_, err := DB.Exec(`
+		INSERT INTO audit_events
+		  (organization_id, actor_user_id, actor_api_key_id, action, entity_type, entity_id, before, after, metadata, ip, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, now());
+	`, e.OrganizationID, e.ActorUserID, e.ActorAPIKeyID, e.Action, e.EntityType, e.EntityID,
+		nullJSON(e.Before), nullJSON(e.After), nullJSON(e.Metadata), e.IP)
+	if err != nil {
+		log.Error().Err(err).Str("action", e.Action).Str("entity_type", e.EntityType).Msg("failed to create audit event")
+	}
+	return err
+}
+
+// AuditFilter narrows ListAuditEvents; zero-valued fields are ignored.
+type AuditFilter struct {
+	OrganizationID int
+	ActorUserID    *int
+	Action         string // a trailing '.' matches every action under that prefix, e.g. "playlist."
+	EntityType     string
+	EntityID       string
+	From           *time.Time
+	To             *time.Time
+	Limit          int
+	Offset         int
+}
+
+// ListAuditEvents returns one page of an organization's events, newest first, along with
+// the total number of events matching the filter.
+func ListAuditEvents(f AuditFilter) ([]model.AuditEvent, int, error) {
+	where := " WHERE organization_id = $1"
+	args := []interface{}{f.OrganizationID}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where += " AND " + cond + " $" + strconv.Itoa(len(args))
+	}
+
+	if f.ActorUserID != nil {
+		add("actor_user_id =", *f.ActorUserID)
+	}
+	if f.Action != "" {
+		if strings.HasSuffix(f.Action, ".") {
+			add("action LIKE", f.Action+"%")
+		} else {
+			add("action =", f.Action)
+		}
+	}
+	if f.EntityType != "" {
+		add("entity_type =", f.EntityType)
+	}
+	if f.EntityID != "" {
+		add("entity_id =", f.EntityID)
+	}
+	if f.From != nil {
+		add("created_at >=", *f.From)
+	}
+	if f.To != nil {
+		add("created_at <", *f.To)
+	}
+
+	var total int
+	if err := DB.Get(&total, `SELECT COUNT(*) FROM audit_events`+where, args...); err != nil {
+		log.Error().Err(err).Int("organization_id", f.OrganizationID).Msg("failed to count audit events")
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, organization_id, actor_user_id, actor_api_key_id, action, entity_type, entity_id,
+		       COALESCE(before, 'null') AS before, COALESCE(after, 'null') AS after,
+		       COALESCE(metadata, 'null') AS metadata, ip, created_at
+		  FROM audit_events` + where +
+		" ORDER BY created_at DESC, id DESC LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+	args = append(args, f.Limit, f.Offset)
+
+	var out []model.AuditEvent
+	if err := DB.Select(&out, query, args...); err != nil {
+		log.Error().Err(err).Int("organization_id", f.OrganizationID).Msg("failed to list audit events")
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+// nullJSON stores empty JSON as SQL NULL rather than an empty string, which JSONB rejects.
+func nullJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
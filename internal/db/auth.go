@@ -3,6 +3,7 @@ package db
 import (
 	"database/sql"
 	"errors"
+	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog/log"
 	_ "github.com/lib/pq"
 
@@ -11,13 +12,72 @@ import (
 
 // inserts new user into table, returns new user ID.
 func CreateUser(email, hashedPassword string, name *string) (int, error) {
+	return insertUser(DB, email, hashedPassword, name)
+}
+
+// CreateUserWithOrganization inserts a new user together with their personal organization,
+// so an account never exists without a membership.
+func CreateUserWithOrganization(email, hashedPassword string, name *string, organizationName string) (int, error) {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	userID, err := insertUser(tx, email, hashedPassword, name)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = insertOrganization(tx, organizationName, userID); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// CreateUserFromInvitation inserts a new user and spends the invitation they signed up
+// with in one transaction. It returns sql.ErrNoRows, creating no user, if the invitation
+// was accepted, revoked or expired in the meantime.
+func CreateUserFromInvitation(email, hashedPassword string, name *string, invitationID int) (int, error) {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	userID, err := insertUser(tx, email, hashedPassword, name)
+	if err != nil {
+		return 0, err
+	}
+	if err = acceptInvitation(tx, invitationID, userID); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+func insertUser(q sqlx.Queryer, email, hashedPassword string, name *string) (int, error) {
 	query := `
 	INSERT INTO users (email, hashed_password, name, created_at, updated_at)
 	VALUES ($1, $2, $3, now(), now())
 	RETURNING id;
 	`
 	var newID int
-	err := DB.QueryRow(query, email, hashedPassword, name).Scan(&newID)
+	err := q.QueryRowx(query, email, hashedPassword, name).Scan(&newID)
 	if err != nil {
 		log.Error().Msg("failed to create user")
 		return 0, err
@@ -29,7 +89,7 @@ func CreateUser(email, hashedPassword string, name *string) (int, error) {
 func GetUserByEmail(email string) (*model.User, error) {
 	var u model.User
 	query := `
-	SELECT id, email, hashed_password, name, created_at, updated_at
+	SELECT id, email, hashed_password, name, totp_secret, totp_enabled_at, created_at, updated_at
 	FROM users
 	WHERE email = $1;
 	`
@@ -48,7 +108,7 @@ func GetUserByEmail(email string) (*model.User, error) {
 func GetUserByID(id int) (*model.User, error) {
 	var u model.User
 	query := `
-	SELECT id, email, hashed_password, name, created_at, updated_at
+	SELECT id, email, hashed_password, name, totp_secret, totp_enabled_at, created_at, updated_at
 	FROM users
 	WHERE id = $1;
 	`
@@ -92,3 +152,57 @@ func UpdateUserProfile(id int, email string, name *string) error {
 	return nil
 }
 
+// replaces a user's password hash.
+// returns sql.ErrNoRows if the user doesn't exist.
+func UpdateUserPassword(id int, hashedPassword string) error {
+	query := `
+	UPDATE users
+	SET hashed_password = $2,
+	updated_at = now()
+	WHERE id = $1;
+	`
+	res, err := DB.Exec(query, id, hashedPassword)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", id).Msg("failed to update user password")
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// fetches the user linked to an external identity. returns nil, sql.ErrNoRows if not linked.
+func GetUserByIdentity(issuer, subject string) (*model.User, error) {
+	var u model.User
+	query := `
+	SELECT u.id, u.email, u.hashed_password, u.name, u.totp_secret, u.totp_enabled_at, u.created_at, u.updated_at
+	FROM user_identities i
+	JOIN users u ON u.id = i.user_id
+	WHERE i.issuer = $1 AND i.subject = $2;
+	`
+	err := DB.Get(&u, query, issuer, subject)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		log.Error().Err(err).Str("issuer", issuer).Msg("failed to get user by identity")
+		return nil, err
+	}
+	return &u, nil
+}
+
+// links an external identity to a user, or records a fresh login if already linked.
+func LinkUserIdentity(userID int, issuer, subject, email string) error {
+	query := `
+	INSERT INTO user_identities (user_id, issuer, subject, email, created_at, last_login_at)
+	VALUES ($1, $2, $3, NULLIF($4, ''), now(), now())
+	ON CONFLICT (issuer, subject)
+	DO UPDATE SET email = EXCLUDED.email, last_login_at = now();
+	`
+	_, err := DB.Exec(query, userID, issuer, subject, email)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", userID).Str("issuer", issuer).Msg("failed to link user identity")
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+const deviceCommandColumns = `id, organization_id, screen_id, type, COALESCE(params, 'null') AS params, status, message,
+	COALESCE(result, 'null') AS result, issued_by, created_at, expires_at, sent_at, acked_at`
+
+func CreateDeviceCommand(c model.DeviceCommand) (model.DeviceCommand, error) {
+	var out model.DeviceCommand
+	err := DB.Get(&out, `
+		INSERT INTO device_commands (id, organization_id, screen_id, type, params, status, issued_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, 'pending', $6, now(), $7)
+		RETURNING `+deviceCommandColumns+`;
+	`, c.ID, c.OrganizationID, c.ScreenID, c.Type, nullJSON(c.Params), c.IssuedBy, c.ExpiresAt)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", c.ScreenID).Str("type", c.Type).Msg("failed to create device command")
+	}
+	return out, err
+}
+
+// MarkDeviceCommandSent records that the command was published to the device.
+func MarkDeviceCommandSent(id string) error {
+	_, err := DB.Exec(`
+		UPDATE device_commands
+		   SET status = 'sent', sent_at = now()
+		 WHERE id = $1 AND status = 'pending';
+	`, id)
+	if err != nil {
+		log.Error().Err(err).Str("command_id", id).Msg("failed to mark device command sent")
+	}
+	return err
+}
+
+// AckDeviceCommand records the device's outcome for a command. It returns sql.ErrNoRows if
+// the screen has no such command awaiting acknowledgement.
+func AckDeviceCommand(screenID int, id, status string, message *string, result json.RawMessage) (model.DeviceCommand, error) {
+	var out model.DeviceCommand
+	err := DB.Get(&out, `
+		UPDATE device_commands
+		   SET status = $3, message = $4, result = $5, acked_at = now()
+		 WHERE id = $1 AND screen_id = $2 AND acked_at IS NULL
+		RETURNING `+deviceCommandColumns+`;
+	`, id, screenID, status, message, nullJSON(result))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Str("command_id", id).Msg("failed to acknowledge device command")
+	}
+	return out, err
+}
+
+// ListDeviceCommands returns the screen's most recent commands, newest first.
+func ListDeviceCommands(screenID, limit int) ([]model.DeviceCommand, error) {
+	var out []model.DeviceCommand
+	err := DB.Select(&out, `
+		SELECT `+deviceCommandColumns+`
+		  FROM device_commands
+		 WHERE screen_id = $1
+		 ORDER BY created_at DESC
+		 LIMIT $2;
+	`, screenID, limit)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to list device commands")
+	}
+	return out, err
+}
+
+// ListPendingDeviceCommands returns the screen's unacknowledged, unexpired commands, oldest first.
+func ListPendingDeviceCommands(screenID int) ([]model.DeviceCommand, error) {
+	var out []model.DeviceCommand
+	err := DB.Select(&out, `
+		SELECT `+deviceCommandColumns+`
+		  FROM device_commands
+		 WHERE screen_id = $1 AND acked_at IS NULL AND expires_at > now()
+		 ORDER BY created_at;
+	`, screenID)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to list pending device commands")
+	}
+	return out, err
+}
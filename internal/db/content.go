@@ -30,9 +30,10 @@ func normalizeContentType(in string) (string, error) {
 	}
 }
 // CreateContent inserts content. width/height of 0 => NULL (to satisfy CHECK > 0 if not null).
+// asset describes the uploaded file behind url, and is nil for content that is not one.
 func CreateContent(
 	name, typ, url string, resolutionWidth, resolutionHeight,
-	createdBy int,
+	organizationID, createdBy int, asset *model.ContentAsset,
 ) (model.Content, error) {
 	var c model.Content
 
@@ -53,11 +54,18 @@ func CreateContent(
 
 	const query = `
 	INSERT INTO content
-	(name, type, url, resolution_width, resolution_height, created_by, created_at, updated_at)
+	(name, type, url, resolution_width, resolution_height, organization_id, created_by, created_at, updated_at, sha256, size_bytes, mime_type)
 	VALUES
-	($1,   $2,   $3,  $4,               $5,                $6,        now(),     now())
+	($1,   $2,   $3,  $4,               $5,                $6,              $7,         now(),      now(),      $8,     $9,         $10)
 	RETURNING
-	id, name, type, url, resolution_width, resolution_height, created_by, created_at, updated_at;`
+	id, name, type, url, resolution_width, resolution_height, organization_id, created_by, created_at, updated_at,
+	sha256, size_bytes, mime_type;`
+
+	var sha, mime *string
+	var size *int64
+	if asset != nil {
+		sha, size, mime = &asset.SHA256, &asset.SizeBytes, &asset.MimeType
+	}
 
 	if err := DB.Get(&c, query,
 		name,
@@ -65,7 +73,11 @@ func CreateContent(
 		url,
 		wptr,
 		hptr,
+		organizationID,
 		createdBy,
+		sha,
+		size,
+		mime,
 	); err != nil {
 		log.Error().Err(err).Str("name", name).Msg("Failed to create content")
 		return model.Content{}, err
@@ -77,7 +89,8 @@ func GetContentByID(id int) (model.Content, error) {
 	var c model.Content
 	const query = `
 	SELECT
-	  id, name, type, url, resolution_width, resolution_height, created_by, created_at, updated_at
+	  id, name, type, url, resolution_width, resolution_height, organization_id, created_by, created_at, updated_at,
+	  sha256, size_bytes, mime_type
 	FROM content
 	WHERE id = $1;`
 	err := DB.Get(&c, query, id)
@@ -92,7 +105,8 @@ func ListContent() ([]model.Content, error) {
 	var all []model.Content
 	const query = `
 	SELECT
-	  id, name, type, url, resolution_width, resolution_height, created_by, created_at, updated_at
+	  id, name, type, url, resolution_width, resolution_height, organization_id, created_by, created_at, updated_at,
+	  sha256, size_bytes, mime_type
 	FROM content
 	ORDER BY id;`
 	if err := DB.Select(&all, query); err != nil {
@@ -121,6 +135,10 @@ func UpdateContent(
 		UPDATE content
 		   SET name              = COALESCE($2, name),
 		       url               = COALESCE($3, url),
+		       -- a different url is a different file, whose checksums are unknown
+		       sha256            = CASE WHEN $3 IS NULL OR $3 = url THEN sha256 END,
+		       size_bytes        = CASE WHEN $3 IS NULL OR $3 = url THEN size_bytes END,
+		       mime_type         = CASE WHEN $3 IS NULL OR $3 = url THEN mime_type END,
 		       resolution_width  = COALESCE($4, resolution_width),
 		       resolution_height = COALESCE($5, resolution_height),
 		       updated_at        = now()
@@ -141,7 +159,58 @@ func DeleteContent(id int) error {
 	return err
 }
 
-func SearchContent(name, contentType *string, createdBy *int) ([]model.Content, error) {
+// IsContentFileInUse reports whether any of the organization's content still points at the
+// stored file with the given hash and location.
+func IsContentFileInUse(organizationID int, sha256, url string) (bool, error) {
+	var inUse bool
+	err := DB.Get(&inUse, `
+		SELECT EXISTS (
+			SELECT 1 FROM content
+			 WHERE organization_id = $1 AND sha256 = $2 AND url = $3
+		);`,
+		organizationID, sha256, url,
+	)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Msg("Failed to check content file references")
+	}
+	return inUse, err
+}
+
+// FindContentFileBySHA256 returns where the organization already stores a file with the
+// given hash, or sql.ErrNoRows if it has none.
+func FindContentFileBySHA256(organizationID int, sha256 string) (string, error) {
+	var url string
+	err := DB.Get(&url, `
+		SELECT url FROM content
+		 WHERE organization_id = $1 AND sha256 = $2
+		 ORDER BY id
+		 LIMIT 1;`,
+		organizationID, sha256,
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Int("organization_id", organizationID).Msg("Failed to look up content file")
+	}
+	return url, err
+}
+
+// LockContentFile holds a lock on the organization's stored file with the given hash until
+// unlock is called, so a file that is being shared by new content is not deleted as unused
+// at the same time.
+func LockContentFile(organizationID int, sha256 string) (unlock func(), err error) {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1, hashtext($2));`, organizationID, sha256); err != nil {
+		_ = tx.Rollback()
+		log.Error().Err(err).Int("organization_id", organizationID).Msg("Failed to lock content file")
+		return nil, err
+	}
+	// ending the transaction releases the lock
+	return func() { _ = tx.Rollback() }, nil
+}
+
+func SearchContent(name, contentType *string, organizationID *int) ([]model.Content, error) {
 	var all []model.Content
 	query := `
 	SELECT
@@ -151,9 +220,13 @@ func SearchContent(name, contentType *string, createdBy *int) ([]model.Content,
 	url,
 	resolution_width,
 	resolution_height,
+	organization_id,
 	created_by,
 	created_at,
-	updated_at
+	updated_at,
+	sha256,
+	size_bytes,
+	mime_type
 	FROM content
 	WHERE 1=1`
 
@@ -172,10 +245,10 @@ func SearchContent(name, contentType *string, createdBy *int) ([]model.Content,
 		args = append(args, *contentType)
 	}
 
-	if createdBy != nil {
+	if organizationID != nil {
 		argCount++
-		query += ` AND created_by = $` + strconv.Itoa(argCount)
-		args = append(args, *createdBy)
+		query += ` AND organization_id = $` + strconv.Itoa(argCount)
+		args = append(args, *organizationID)
 	}
 
 	query += ` ORDER BY id;`
@@ -188,7 +261,7 @@ func SearchContent(name, contentType *string, createdBy *int) ([]model.Content,
 }
 
 // SearchContentMultiple supports multiple values for name and type filters
-func SearchContentMultiple(names, types []string, createdBy *int) ([]model.Content, error) {
+func SearchContentMultiple(names, types []string, organizationID *int) ([]model.Content, error) {
 	var all []model.Content
 	query := `
 	SELECT
@@ -198,9 +271,13 @@ func SearchContentMultiple(names, types []string, createdBy *int) ([]model.Conte
 	url,
 	resolution_width,
 	resolution_height,
+	organization_id,
 	created_by,
 	created_at,
-	updated_at
+	updated_at,
+	sha256,
+	size_bytes,
+	mime_type
 	FROM content
 	WHERE 1=1`
 
@@ -245,10 +322,10 @@ func SearchContentMultiple(names, types []string, createdBy *int) ([]model.Conte
 		}
 	}
 
-	if createdBy != nil {
+	if organizationID != nil {
 		argCount++
-		query += ` AND created_by = $` + strconv.Itoa(argCount)
-		args = append(args, *createdBy)
+		query += ` AND organization_id = $` + strconv.Itoa(argCount)
+		args = append(args, *organizationID)
 	}
 
 	query += ` ORDER BY id;`
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -61,8 +62,17 @@ func RunMigrations(migrationsPath string) error {
 		return nil
 	}
 
-	// sort file names so that they run in deterministic order
-	sort.Strings(files)
+	// sort file names so that they run in deterministic order.
+	// the "_init" baseline must run before the numbered migrations that alter it,
+	// but "_" sorts after digits, so it is pulled to the front explicitly.
+	sort.SliceStable(files, func(i, j int) bool {
+		iInit := strings.HasPrefix(filepath.Base(files[i]), "_init")
+		jInit := strings.HasPrefix(filepath.Base(files[j]), "_init")
+		if iInit != jInit {
+			return iInit
+		}
+		return files[i] < files[j]
+	})
 
 	// for each file, read its contents and execute as a single SQL statement
 	for _, file := range files {
@@ -9,9 +9,9 @@ import (
 
 )
 
-func userOwnsGroup(userID, groupID int) error {
+func orgOwnsGroup(organizationID, groupID int) error {
 	var exists int
-	if err := DB.Get(&exists, `SELECT 1 FROM screen_groups WHERE id=$1 AND created_by=$2`, groupID, userID); err != nil {
+	if err := DB.Get(&exists, `SELECT 1 FROM screen_groups WHERE id=$1 AND organization_id=$2`, groupID, organizationID); err != nil {
 		if err == sql.ErrNoRows {
 			return sql.ErrNoRows
 		}
@@ -20,9 +20,9 @@ func userOwnsGroup(userID, groupID int) error {
 	return nil
 }
 
-func userOwnsScreen(userID, screenID int) error {
+func orgOwnsScreen(organizationID, screenID int) error {
 	var exists int
-	if err := DB.Get(&exists, `SELECT 1 FROM screens WHERE id=$1 AND created_by=$2`, screenID, userID); err != nil {
+	if err := DB.Get(&exists, `SELECT 1 FROM screens WHERE id=$1 AND organization_id=$2`, screenID, organizationID); err != nil {
 		if err == sql.ErrNoRows {
 			return sql.ErrNoRows
 		}
@@ -31,24 +31,24 @@ func userOwnsScreen(userID, screenID int) error {
 	return nil
 }
 
-func CreateScreenGroup(userID int, name, description *string) (model.ScreenGroup, error) {
+func CreateScreenGroup(organizationID, userID int, name, description *string) (model.ScreenGroup, error) {
 	var g model.ScreenGroup
 	if name == nil || *name == "" {
 		return g, fmt.Errorf("group name is required")
 	}
 	err := DB.Get(&g, `
-		INSERT INTO screen_groups (name, description, created_by)
-		VALUES ($1, $2, $3)
-		RETURNING id, name, description, created_by, created_at, updated_at
-	`, *name, description, userID)
+		INSERT INTO screen_groups (name, description, organization_id, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, name, description, organization_id, created_by, created_at, updated_at
+	`, *name, description, organizationID, userID)
 	return g, err
 }
 
-func RenameScreenGroup(userID, groupID int, newName, newDescription *string) (model.ScreenGroup, error) {
+func RenameScreenGroup(organizationID, groupID int, newName, newDescription *string) (model.ScreenGroup, error) {
 	var g model.ScreenGroup
 
-	// Ensure the caller owns this group
-	if err := userOwnsGroup(userID, groupID); err != nil {
+	// Ensure the caller's organization owns this group
+	if err := orgOwnsGroup(organizationID, groupID); err != nil {
 		return g, err
 	}
 
@@ -57,9 +57,9 @@ func RenameScreenGroup(userID, groupID int, newName, newDescription *string) (mo
 		   SET name        = COALESCE($1, name),
 		       description = $2,
 		       updated_at  = now()
-		 WHERE id = $3 AND created_by = $4
-		RETURNING id, name, description, created_by, created_at, updated_at
-	`, newName, newDescription, groupID, userID)
+		 WHERE id = $3 AND organization_id = $4
+		RETURNING id, name, description, organization_id, created_by, created_at, updated_at
+	`, newName, newDescription, groupID, organizationID)
 	if err == sql.ErrNoRows {
 		// Shouldn’t normally happen due to ownership check, but preserve semantics
 		return g, sql.ErrNoRows
@@ -67,8 +67,8 @@ func RenameScreenGroup(userID, groupID int, newName, newDescription *string) (mo
 	return g, err
 }
 
-func DeleteScreenGroup(userID, groupID int) error {
-	res, err := DB.Exec(`DELETE FROM screen_groups WHERE id=$1 AND created_by=$2`, groupID, userID)
+func DeleteScreenGroup(organizationID, groupID int) error {
+	res, err := DB.Exec(`DELETE FROM screen_groups WHERE id=$1 AND organization_id=$2`, groupID, organizationID)
 	if err != nil {
 		return err
 	}
@@ -81,30 +81,30 @@ func DeleteScreenGroup(userID, groupID int) error {
 func GetScreenGroupByID(groupID int) (model.ScreenGroup, error) {
 	var g model.ScreenGroup
 	err := DB.Get(&g, `
-		SELECT id, name, description, created_by, created_at, updated_at
+		SELECT id, name, description, organization_id, created_by, created_at, updated_at
 		  FROM screen_groups
 		 WHERE id = $1
 	`, groupID)
 	return g, err
 }
 
-func ListScreenGroups(userID int) ([]model.ScreenGroup, error) {
+func ListScreenGroups(organizationID int) ([]model.ScreenGroup, error) {
 	var groups []model.ScreenGroup
 	err := DB.Select(&groups, `
-		SELECT id, name, description, created_by, created_at, updated_at
+		SELECT id, name, description, organization_id, created_by, created_at, updated_at
 		  FROM screen_groups
-		 WHERE created_by = $1
+		 WHERE organization_id = $1
 		 ORDER BY name ASC, id ASC
-	`, userID)
+	`, organizationID)
 	return groups, err
 }
 
-func AddScreenToGroup(userID, groupID, screenID int) error {
+func AddScreenToGroup(organizationID, groupID, screenID int) error {
 	// Ownership checks
-	if err := userOwnsGroup(userID, groupID); err != nil {
+	if err := orgOwnsGroup(organizationID, groupID); err != nil {
 		return err
 	}
-	if err := userOwnsScreen(userID, screenID); err != nil {
+	if err := orgOwnsScreen(organizationID, screenID); err != nil {
 		return err
 	}
 
@@ -117,12 +117,12 @@ func AddScreenToGroup(userID, groupID, screenID int) error {
 	return err
 }
 
-func RemoveScreenFromGroup(userID, groupID, screenID int) error {
+func RemoveScreenFromGroup(organizationID, groupID, screenID int) error {
 	// Ownership checks
-	if err := userOwnsGroup(userID, groupID); err != nil {
+	if err := orgOwnsGroup(organizationID, groupID); err != nil {
 		return err
 	}
-	if err := userOwnsScreen(userID, screenID); err != nil {
+	if err := orgOwnsScreen(organizationID, screenID); err != nil {
 		return err
 	}
 
@@ -140,40 +140,41 @@ func RemoveScreenFromGroup(userID, groupID, screenID int) error {
 	return nil
 }
 
-func ListScreensInGroup(userID, groupID int) ([]model.Screen, error) {
-	// Ensure the caller owns the group
-	if err := userOwnsGroup(userID, groupID); err != nil {
+func ListScreensInGroup(organizationID, groupID int) ([]model.Screen, error) {
+	// Ensure the caller's organization owns the group
+	if err := orgOwnsGroup(organizationID, groupID); err != nil {
 		return nil, err
 	}
 
 	var screens []model.Screen
 	err := DB.Select(&screens, `
 		SELECT s.id, s.device_id, s.client_information, s.client_width, s.client_height,
-		       s.name, s.location, s.paired, s.created_by, s.created_at, s.updated_at
+		       s.name, s.location, s.paired, s.organization_id, s.created_by, s.created_at, s.updated_at,
+		       s.last_seen_at, s.uptime_seconds, s.playlist_etag, s.app_version
 		  FROM screen_group_members m
 		  JOIN screens s ON s.id = m.screen_id
 		 WHERE m.group_id = $1
-		   AND s.created_by = $2
+		   AND s.organization_id = $2
 		 ORDER BY s.name ASC, s.id ASC
-	`, groupID, userID)
+	`, groupID, organizationID)
 	return screens, err
 }
 
-func  ListGroupsForScreen(userID, screenID int) ([]model.ScreenGroup, error) {
-	// Ensure the caller owns the screen
-	if err := userOwnsScreen(userID, screenID); err != nil {
+func  ListGroupsForScreen(organizationID, screenID int) ([]model.ScreenGroup, error) {
+	// Ensure the caller's organization owns the screen
+	if err := orgOwnsScreen(organizationID, screenID); err != nil {
 		return nil, err
 	}
 
 	var groups []model.ScreenGroup
 	err := DB.Select(&groups, `
-		SELECT g.id, g.name, g.description, g.created_by, g.created_at, g.updated_at
+		SELECT g.id, g.name, g.description, g.organization_id, g.created_by, g.created_at, g.updated_at
 		  FROM screen_group_members m
 		  JOIN screen_groups g ON g.id = m.group_id
 		 WHERE m.screen_id = $1
-		   AND g.created_by = $2
+		   AND g.organization_id = $2
 		 ORDER BY g.name ASC, g.id ASC
-	`, screenID, userID)
+	`, screenID, organizationID)
 	return groups, err
 }
 
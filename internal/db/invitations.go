@@ -0,0 +1,124 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+const invitationColumns = `id, organization_id, email, role, invited_by, expires_at, accepted_at, accepted_by, revoked_at, created_at`
+
+func CreateInvitation(organizationID, invitedBy int, email, role, tokenHash string, expiresAt time.Time) (model.Invitation, error) {
+	var inv model.Invitation
+	err := DB.Get(&inv, `
+		INSERT INTO invitations (organization_id, email, role, token_hash, invited_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now())
+		RETURNING `+invitationColumns+`;
+	`, organizationID, email, role, tokenHash, invitedBy, expiresAt)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Msg("failed to create invitation")
+	}
+	return inv, err
+}
+
+// GetInvitationByTokenHash returns sql.ErrNoRows if no invitation has that token.
+func GetInvitationByTokenHash(tokenHash string) (model.Invitation, error) {
+	var inv model.Invitation
+	err := DB.Get(&inv, `SELECT `+invitationColumns+` FROM invitations WHERE token_hash = $1;`, tokenHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Msg("failed to get invitation by token")
+	}
+	return inv, err
+}
+
+// ListPendingInvitations returns the organization's invitations that can still be accepted.
+func ListPendingInvitations(organizationID int) ([]model.Invitation, error) {
+	var out []model.Invitation
+	err := DB.Select(&out, `
+		SELECT `+invitationColumns+`
+		  FROM invitations
+		 WHERE organization_id = $1
+		   AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > now()
+		 ORDER BY created_at DESC;
+	`, organizationID)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Msg("failed to list invitations")
+	}
+	return out, err
+}
+
+// RevokeInvitation returns sql.ErrNoRows if the organization has no such pending invitation.
+func RevokeInvitation(organizationID, id int) error {
+	res, err := DB.Exec(`
+		UPDATE invitations
+		   SET revoked_at = now()
+		 WHERE id = $1 AND organization_id = $2
+		   AND accepted_at IS NULL AND revoked_at IS NULL;
+	`, id, organizationID)
+	if err != nil {
+		log.Error().Err(err).Int("invitation_id", id).Msg("failed to revoke invitation")
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AcceptInvitation spends a pending invitation and adds the user to its organization with
+// the invited role. It returns sql.ErrNoRows if the invitation was accepted, revoked or
+// expired in the meantime.
+func AcceptInvitation(id, userID int) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = acceptInvitation(tx, id, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// acceptInvitation spends the invitation and adds the member inside tx.
+func acceptInvitation(tx *sqlx.Tx, id, userID int) error {
+	var inv model.Invitation
+	err := tx.Get(&inv, `
+		UPDATE invitations
+		   SET accepted_at = now(), accepted_by = $2
+		 WHERE id = $1
+		   AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > now()
+		RETURNING `+invitationColumns+`;
+	`, id, userID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Int("invitation_id", id).Msg("failed to accept invitation")
+		}
+		return err
+	}
+
+	// an existing member keeps their current role
+	_, err = tx.Exec(`
+		INSERT INTO organization_members (organization_id, user_id, role, joined_at)
+		VALUES ($1, $2, $3, now())
+		ON CONFLICT DO NOTHING;
+	`, inv.OrganizationID, userID, inv.Role)
+	if err != nil {
+		log.Error().Err(err).Int("invitation_id", id).Int("user_id", userID).Msg("failed to add invited member")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+// CreateOrganization inserts a new organization and makes its creator the first member, as owner.
+func CreateOrganization(name string, createdBy int) (model.Organization, error) {
+	var o model.Organization
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return o, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	o, err = insertOrganization(tx, name, createdBy)
+	if err != nil {
+		return model.Organization{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return model.Organization{}, err
+	}
+	return o, nil
+}
+
+// insertOrganization creates the organization inside tx, with its creator as owner.
+func insertOrganization(tx *sqlx.Tx, name string, createdBy int) (model.Organization, error) {
+	var o model.Organization
+	err := tx.Get(&o, `
+		INSERT INTO organizations (name, created_by, created_at, updated_at)
+		VALUES ($1, $2, now(), now())
+		RETURNING id, name, created_by, require_two_factor, created_at, updated_at;
+	`, name, createdBy)
+	if err != nil {
+		log.Error().Err(err).Int("created_by", createdBy).Msg("failed to create organization")
+		return model.Organization{}, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO organization_members (organization_id, user_id, role, joined_at)
+		VALUES ($1, $2, $3, now());
+	`, o.ID, createdBy, model.RoleOwner)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", o.ID).Msg("failed to add organization creator as member")
+		return model.Organization{}, err
+	}
+
+	return o, nil
+}
+
+func GetOrganizationByID(id int) (model.Organization, error) {
+	var o model.Organization
+	err := DB.Get(&o, `
+		SELECT id, name, created_by, require_two_factor, created_at, updated_at
+		  FROM organizations
+		 WHERE id = $1;
+	`, id)
+	if err != nil {
+		log.Error().Err(err).Int("id", id).Msg("failed to get organization by id")
+	}
+	return o, err
+}
+
+// ListOrganizationsForUser returns every organization the user is a member of.
+func ListOrganizationsForUser(userID int) ([]model.Organization, error) {
+	var out []model.Organization
+	err := DB.Select(&out, `
+		SELECT o.id, o.name, o.created_by, o.require_two_factor, o.created_at, o.updated_at
+		  FROM organization_members m
+		  JOIN organizations o ON o.id = m.organization_id
+		 WHERE m.user_id = $1
+		 ORDER BY m.joined_at, o.id;
+	`, userID)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to list organizations for user")
+	}
+	return out, err
+}
+
+func AddOrganizationMember(organizationID, userID int, role string) error {
+	_, err := DB.Exec(`
+		INSERT INTO organization_members (organization_id, user_id, role, joined_at)
+		VALUES ($1, $2, $3, now())
+		ON CONFLICT DO NOTHING;
+	`, organizationID, userID, role)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Int("user_id", userID).
+			Msg("failed to add organization member")
+	}
+	return err
+}
+
+func RemoveOrganizationMember(organizationID, userID int) error {
+	res, err := DB.Exec(`
+		DELETE FROM organization_members
+		 WHERE organization_id = $1 AND user_id = $2;
+	`, organizationID, userID)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Int("user_id", userID).
+			Msg("failed to remove organization member")
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func ListOrganizationMembers(organizationID int) ([]model.OrganizationMember, error) {
+	var out []model.OrganizationMember
+	err := DB.Select(&out, `
+		SELECT m.organization_id, m.user_id, u.email, u.name, m.role, m.joined_at
+		  FROM organization_members m
+		  JOIN users u ON u.id = m.user_id
+		 WHERE m.organization_id = $1
+		 ORDER BY m.joined_at, m.user_id;
+	`, organizationID)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Msg("failed to list organization members")
+	}
+	return out, err
+}
+
+// GetOrganizationMembership returns sql.ErrNoRows if the user is not a member of the organization.
+func GetOrganizationMembership(organizationID, userID int) (model.OrganizationMember, error) {
+	var m model.OrganizationMember
+	err := DB.Get(&m, `
+		SELECT m.organization_id, m.user_id, u.email, u.name, m.role, m.joined_at, o.require_two_factor
+		  FROM organization_members m
+		  JOIN users u ON u.id = m.user_id
+		  JOIN organizations o ON o.id = m.organization_id
+		 WHERE m.organization_id = $1 AND m.user_id = $2;
+	`, organizationID, userID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Int("organization_id", organizationID).Int("user_id", userID).
+				Msg("failed to get organization membership")
+		}
+		return m, err
+	}
+	m.Permissions, err = ListRolePermissions(m.Role)
+	return m, err
+}
+
+// GetDefaultOrganizationMembership returns the user's oldest membership, used when
+// a request does not name an organization explicitly.
+func GetDefaultOrganizationMembership(userID int) (model.OrganizationMember, error) {
+	var m model.OrganizationMember
+	err := DB.Get(&m, `
+		SELECT m.organization_id, m.user_id, u.email, u.name, m.role, m.joined_at, o.require_two_factor
+		  FROM organization_members m
+		  JOIN users u ON u.id = m.user_id
+		  JOIN organizations o ON o.id = m.organization_id
+		 WHERE m.user_id = $1
+		 ORDER BY m.joined_at, m.organization_id
+		 LIMIT 1;
+	`, userID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Int("user_id", userID).Msg("failed to get default organization membership")
+		}
+		return m, err
+	}
+	m.Permissions, err = ListRolePermissions(m.Role)
+	return m, err
+}
+
+// UpdateOrganizationMemberRole returns sql.ErrNoRows if the user is not a member of the organization.
+func UpdateOrganizationMemberRole(organizationID, userID int, role string) error {
+	res, err := DB.Exec(`
+		UPDATE organization_members
+		   SET role = $3
+		 WHERE organization_id = $1 AND user_id = $2;
+	`, organizationID, userID, role)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Int("user_id", userID).
+			Str("role", role).Msg("failed to update organization member role")
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func CountOrganizationMembersWithRole(organizationID int, role string) (int, error) {
+	var n int
+	err := DB.Get(&n, `
+		SELECT COUNT(*)
+		  FROM organization_members
+		 WHERE organization_id = $1 AND role = $2;
+	`, organizationID, role)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Str("role", role).
+			Msg("failed to count organization members with role")
+	}
+	return n, err
+}
+
+func SetOrganizationRequireTwoFactor(organizationID int, required bool) error {
+	res, err := DB.Exec(`
+		UPDATE organizations
+		   SET require_two_factor = $2, updated_at = now()
+		 WHERE id = $1;
+	`, organizationID, required)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Msg("failed to update organization two-factor requirement")
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+func CreatePasswordResetToken(userID int, tokenHash string, expiresAt time.Time) error {
+	_, err := DB.Exec(`
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, now());
+	`, userID, tokenHash, expiresAt)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to create password reset token")
+	}
+	return err
+}
+
+// ConsumePasswordResetToken marks an unused, unexpired token as used and returns its user.
+// Every other outstanding token for that user is spent as well. It returns sql.ErrNoRows
+// if the token is unknown, expired or already used.
+func ConsumePasswordResetToken(tokenHash string) (int, error) {
+	var userID int
+	err := DB.Get(&userID, `
+		UPDATE password_reset_tokens
+		   SET used_at = now()
+		 WHERE token_hash = $1
+		   AND used_at IS NULL
+		   AND expires_at > now()
+		RETURNING user_id;
+	`, tokenHash)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Msg("failed to consume password reset token")
+		}
+		return 0, err
+	}
+
+	_, err = DB.Exec(`
+		UPDATE password_reset_tokens
+		   SET used_at = now()
+		 WHERE user_id = $1 AND used_at IS NULL;
+	`, userID)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to spend remaining password reset tokens")
+	}
+	return userID, nil
+}
@@ -12,14 +12,14 @@ import (
 )
 
 // @ PLAYLIST
-func CreatePlaylist(name, description string, createdBy int) (model.Playlist, error) {
+func CreatePlaylist(name, description string, organizationID, createdBy int) (model.Playlist, error) {
 	var p model.Playlist
 	const q = `
-    INSERT INTO playlists (name, description, created_by, created_at, updated_at)
-    VALUES ($1, $2, $3, now(), now())
-    RETURNING id, name, description, created_by, created_at, updated_at;
+    INSERT INTO playlists (name, description, organization_id, created_by, created_at, updated_at)
+    VALUES ($1, $2, $3, $4, now(), now())
+    RETURNING id, name, description, organization_id, created_by, created_at, updated_at;
     `
-	if err := DB.Get(&p, q, name, description, createdBy); err != nil {
+	if err := DB.Get(&p, q, name, description, organizationID, createdBy); err != nil {
 		log.Error().Err(err).Msg("[db] CreatePlaylist: failed to insert playlist")
 		return model.Playlist{}, err
 	}
@@ -35,6 +35,7 @@ func GetPlaylistByID(id int) (model.Playlist, error) {
 		id,
 		name,
 		description,
+		organization_id,
 		created_by,
 		created_at,
 		updated_at
@@ -59,10 +60,10 @@ func GetPlaylistByID(id int) (model.Playlist, error) {
 	return p, nil
 }
 
-func ListPlaylists() ([]model.Playlist, error) {
+func ListPlaylists(organizationID int) ([]model.Playlist, error) {
 	var out []model.Playlist
-	const q = `SELECT id, name, description, created_by, created_at, updated_at FROM playlists ORDER BY id;`
-	if err := DB.Select(&out, q); err != nil {
+	const q = `SELECT id, name, description, organization_id, created_by, created_at, updated_at FROM playlists WHERE organization_id = $1 ORDER BY id;`
+	if err := DB.Select(&out, q, organizationID); err != nil {
 		log.Error().Err(err).Msg("[db] ListPlaylists: failed to select playlists")
 		return nil, err
 	}
@@ -124,29 +125,41 @@ func AddItemToPlaylist(
 	return it, nil
 }
 
-// UpdatePlaylistItem updates position/duration of an item.
+// UpdatePlaylistItem updates position/duration of an item. It returns sql.ErrNoRows if the
+// playlist has no such item.
 func UpdatePlaylistItem(
-	itemID int,
+	playlistID, itemID int,
 	position, duration *int,
 ) error {
-	_, err := DB.Exec(`
+	res, err := DB.Exec(`
 		UPDATE playlist_items
 		SET
-		position = COALESCE($2, position),
-		duration = COALESCE($3, duration)
-		WHERE id = $1;`,
-		itemID, position, duration,
+		position = COALESCE($3, position),
+		duration = COALESCE($4, duration)
+		WHERE id = $1 AND playlist_id = $2;`,
+		itemID, playlistID, position, duration,
 	)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to update playlistItem")
+		return err
 	}
-	return err
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
 
-func RemovePlaylistItem(itemID int) error {
-	_, err := DB.Exec(`DELETE FROM playlist_items WHERE id = $1;`, itemID)
-	log.Error().Err(err).Msg("Failed to remove playlistItem")
-	return err
+// RemovePlaylistItem returns sql.ErrNoRows if the playlist has no such item.
+func RemovePlaylistItem(playlistID, itemID int) error {
+	res, err := DB.Exec(`DELETE FROM playlist_items WHERE id = $1 AND playlist_id = $2;`, itemID, playlistID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to remove playlistItem")
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
 
 func ListPlaylistItems(playlistID int) ([]model.PlaylistItem, error) {
@@ -270,9 +283,13 @@ func GetPlaylistContentForScreen(screenID int) (string, []ContentItem, error) {
 	var items []ContentItem
 	if err := DB.Select(&items, `
         SELECT
+          c.id AS content_id,
           c.url,
           pi.duration,
-          COALESCE(NULLIF(c.type, ''), 'html') AS type
+          COALESCE(NULLIF(c.type, ''), 'html') AS type,
+          c.sha256,
+          c.size_bytes,
+          c.mime_type
         FROM screen_playlists sp
         JOIN playlist_items   pi ON sp.playlist_id = pi.playlist_id
         JOIN content          c  ON pi.content_id    = c.id
@@ -291,7 +308,7 @@ func GetPlaylistContentForScreen(screenID int) (string, []ContentItem, error) {
 func GetScreensUsingPlaylist(playlistID int) ([]model.Screen, error) {
 	var screens []model.Screen
 	err := DB.Select(&screens, `
-		SELECT s.id, s.device_id, s.name, s.location, s.paired, s.created_by, s.created_at, s.updated_at
+		SELECT s.id, s.device_id, s.name, s.location, s.paired, s.organization_id, s.created_by, s.created_at, s.updated_at
 		  FROM screens s
 		  JOIN screen_playlists sp ON s.id = sp.screen_id
 		 WHERE sp.playlist_id = $1
@@ -305,6 +322,29 @@ func GetScreensUsingPlaylist(playlistID int) ([]model.Screen, error) {
 	return screens, nil
 }
 
+// ListDeviceIDsForPlaylist returns the device IDs of paired screens that may be showing the
+// playlist, whether it is assigned to them directly or through one of their schedules.
+func ListDeviceIDsForPlaylist(playlistID int) ([]string, error) {
+	var out []string
+	err := DB.Select(&out, `
+		SELECT DISTINCT s.device_id
+		  FROM screens s
+		 WHERE s.paired
+		   AND (
+		     EXISTS (SELECT 1 FROM screen_playlists sp
+		              WHERE sp.screen_id = s.id AND sp.playlist_id = $1 AND sp.active)
+		     OR EXISTS (SELECT 1 FROM schedule_screens ss
+		                  JOIN schedule_windows w ON w.schedule_id = ss.schedule_id
+		                 WHERE ss.screen_id = s.id AND w.playlist_id = $1)
+		   );`,
+		playlistID,
+	)
+	if err != nil {
+		log.Error().Err(err).Int("playlist_id", playlistID).Msg("failed to list devices for playlist")
+	}
+	return out, err
+}
+
 // a direct get for content items in a playlist by its ID
 func GetPlaylistContentByPlaylistID(playlistID int) (string, []ContentItem, error) {
 	var playlistName string
@@ -320,9 +360,13 @@ func GetPlaylistContentByPlaylistID(playlistID int) (string, []ContentItem, erro
 	var items []ContentItem
 	if err := DB.Select(&items, `
         SELECT
+          c.id AS content_id,
           c.url,
           pi.duration,
-          COALESCE(NULLIF(c.type, ''), 'html') AS type
+          COALESCE(NULLIF(c.type, ''), 'html') AS type,
+          c.sha256,
+          c.size_bytes,
+          c.mime_type
         FROM playlist_items   pi
         JOIN content          c  ON pi.content_id = c.id
        WHERE pi.playlist_id = $1
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+// RecordPlayEvents stores the screen's play events and returns how many were accepted.
+// Events for content or playlists outside the organization are dropped, as are events
+// already recorded.
+func RecordPlayEvents(organizationID, screenID int, events []model.PlayEvent) (int, error) {
+	contentIDs := make([]int64, len(events))
+	playlistIDs := make([]int64, len(events))
+	starts := make([]string, len(events))
+	ends := make([]string, len(events))
+	for i, e := range events {
+		contentIDs[i] = int64(e.ContentID)
+		playlistIDs[i] = int64(e.PlaylistID)
+		starts[i] = e.StartedAt.UTC().Format(time.RFC3339Nano)
+		ends[i] = e.EndedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	res, err := DB.Exec(`
+		INSERT INTO play_events (organization_id, screen_id, content_id, playlist_id, started_at, ended_at)
+		SELECT $1, $2, e.content_id, p.id, e.started_at, e.ended_at
+		  FROM unnest($3::bigint[], $4::bigint[], $5::timestamptz[], $6::timestamptz[])
+		       AS e(content_id, playlist_id, started_at, ended_at)
+		  JOIN content c ON c.id = e.content_id AND c.organization_id = $1
+		  LEFT JOIN playlists p ON p.id = e.playlist_id AND p.organization_id = $1
+		ON CONFLICT DO NOTHING;
+	`, organizationID, screenID, pq.Array(contentIDs), pq.Array(playlistIDs), pq.Array(starts), pq.Array(ends))
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Int("events", len(events)).Msg("failed to record play events")
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// PlayReportFilter selects and groups the play events of one organization. Events are
+// matched on started_at in [From, To).
+type PlayReportFilter struct {
+	OrganizationID int
+	From, To       time.Time
+	ScreenID       *int
+	ContentID      *int
+	PlaylistID     *int
+	GroupBy        []string // model.PlayBy* dimensions, in column order
+}
+
+// columns selected and grouped on for each dimension
+var playDimensions = map[string]struct{ selects, groups string }{
+	model.PlayByContent:  {"e.content_id, c.name AS content_name", "e.content_id, c.name"},
+	model.PlayByPlaylist: {"e.playlist_id, p.name AS playlist_name", "e.playlist_id, p.name"},
+	model.PlayByScreen:   {"e.screen_id, s.name AS screen_name", "e.screen_id, s.name"},
+	model.PlayByDay:      {"to_char(e.started_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day", "day"},
+}
+
+// PlayReport aggregates play counts and durations over the filter's dimensions.
+func PlayReport(f PlayReportFilter) ([]model.PlayStat, error) {
+	selects := []string{}
+	groups := []string{}
+	for _, dim := range f.GroupBy {
+		d, ok := playDimensions[dim]
+		if !ok {
+			return nil, fmt.Errorf("unknown play report dimension %q", dim)
+		}
+		selects = append(selects, d.selects)
+		groups = append(groups, d.groups)
+	}
+
+	where := []string{"e.organization_id = $1", "e.started_at >= $2", "e.started_at < $3"}
+	args := []any{f.OrganizationID, f.From, f.To}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.ScreenID != nil {
+		add("e.screen_id = $%d", *f.ScreenID)
+	}
+	if f.ContentID != nil {
+		add("e.content_id = $%d", *f.ContentID)
+	}
+	if f.PlaylistID != nil {
+		add("e.playlist_id = $%d", *f.PlaylistID)
+	}
+
+	selects = append(selects,
+		"COUNT(*) AS plays",
+		"COALESCE(SUM(EXTRACT(EPOCH FROM e.ended_at - e.started_at)), 0)::bigint AS total_seconds",
+		"COUNT(DISTINCT e.screen_id) AS screens")
+	query := `
+		SELECT ` + strings.Join(selects, ", ") + `
+		  FROM play_events e
+		  LEFT JOIN content c ON c.id = e.content_id
+		  LEFT JOIN playlists p ON p.id = e.playlist_id
+		  LEFT JOIN screens s ON s.id = e.screen_id
+		 WHERE ` + strings.Join(where, " AND ")
+	if len(groups) > 0 {
+		query += `
+		 GROUP BY ` + strings.Join(groups, ", ") + `
+		 ORDER BY ` + strings.Join(groups, ", ")
+	}
+
+	var out []model.PlayStat
+	if err := DB.Select(&out, query, args...); err != nil {
+		log.Error().Err(err).Int("organization_id", f.OrganizationID).Msg("failed to build play report")
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+func CreateRefreshToken(userID int, familyID, tokenHash string, expiresAt time.Time, userAgent, ip *string) error {
+	_, err := DB.Exec(`
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, user_agent, ip, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now());
+	`, userID, familyID, tokenHash, userAgent, ip, expiresAt)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", userID).Str("family_id", familyID).Msg("failed to create refresh token")
+	}
+	return err
+}
+
+// GetRefreshTokenByHash returns sql.ErrNoRows if no token has the given hash.
+func GetRefreshTokenByHash(tokenHash string) (model.RefreshToken, error) {
+	var t model.RefreshToken
+	err := DB.Get(&t, `
+		SELECT id, user_id, family_id, token_hash, user_agent, ip, expires_at, revoked_at, created_at
+		  FROM refresh_tokens
+		 WHERE token_hash = $1;
+	`, tokenHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Msg("failed to get refresh token")
+	}
+	return t, err
+}
+
+// RotateRefreshToken revokes the token with oldID and stores its successor in the same family.
+// It returns sql.ErrNoRows if oldID was already revoked, e.g. by a concurrent refresh.
+func RotateRefreshToken(oldID int, newHash string, expiresAt time.Time, userAgent, ip *string) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var old model.RefreshToken
+	err = tx.Get(&old, `
+		UPDATE refresh_tokens
+		   SET revoked_at = now()
+		 WHERE id = $1 AND revoked_at IS NULL
+		RETURNING id, user_id, family_id, token_hash, user_agent, ip, expires_at, revoked_at, created_at;
+	`, oldID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Int("refresh_token_id", oldID).Msg("failed to revoke rotated refresh token")
+		}
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, user_agent, ip, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now());
+	`, old.UserID, old.FamilyID, newHash, userAgent, ip, expiresAt)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", old.UserID).Str("family_id", old.FamilyID).Msg("failed to store rotated refresh token")
+		return err
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+// RevokeRefreshTokenFamily ends a single login session.
+func RevokeRefreshTokenFamily(familyID string) error {
+	_, err := DB.Exec(`
+		UPDATE refresh_tokens
+		   SET revoked_at = now()
+		 WHERE family_id = $1 AND revoked_at IS NULL;
+	`, familyID)
+	if err != nil {
+		log.Error().Err(err).Str("family_id", familyID).Msg("failed to revoke refresh token family")
+	}
+	return err
+}
+
+// RevokeAllRefreshTokensForUser ends every login session the user has.
+func RevokeAllRefreshTokensForUser(userID int) error {
+	_, err := DB.Exec(`
+		UPDATE refresh_tokens
+		   SET revoked_at = now()
+		 WHERE user_id = $1 AND revoked_at IS NULL;
+	`, userID)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to revoke refresh tokens for user")
+	}
+	return err
+}
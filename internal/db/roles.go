@@ -0,0 +1,65 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+// ListRoles returns every role together with the permissions it grants.
+func ListRoles() ([]model.Role, error) {
+	var roles []model.Role
+	err := DB.Select(&roles, `
+		SELECT name, description
+		  FROM roles
+		 ORDER BY name;
+	`)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list roles")
+		return nil, err
+	}
+
+	for i := range roles {
+		roles[i].Permissions, err = ListRolePermissions(roles[i].Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+// GetRole returns sql.ErrNoRows if no role has the given name.
+func GetRole(name string) (model.Role, error) {
+	var r model.Role
+	err := DB.Get(&r, `
+		SELECT name, description
+		  FROM roles
+		 WHERE name = $1;
+	`, name)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Str("role", name).Msg("failed to get role")
+		}
+		return r, err
+	}
+	r.Permissions, err = ListRolePermissions(name)
+	return r, err
+}
+
+func ListRolePermissions(role string) ([]model.Permission, error) {
+	perms := []model.Permission{}
+	err := DB.Select(&perms, `
+		SELECT permission
+		  FROM role_permissions
+		 WHERE role = $1
+		 ORDER BY permission;
+	`, role)
+	if err != nil {
+		log.Error().Err(err).Str("role", role).Msg("failed to list role permissions")
+	}
+	return perms, err
+}
@@ -10,13 +10,13 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func CreateSchedule(name string, createdBy int) (model.Schedule, error) {
+func CreateSchedule(name string, organizationID, createdBy int) (model.Schedule, error) {
 	var s model.Schedule
 	const q = `
-	INSERT INTO schedules (name, created_by, created_at, updated_at)
-	VALUES ($1, $2, now(), now())
-	RETURNING id, name, created_by, created_at, updated_at;`
-	if err := DB.Get(&s, q, name, createdBy); err != nil {
+	INSERT INTO schedules (name, organization_id, created_by, created_at, updated_at)
+	VALUES ($1, $2, $3, now(), now())
+	RETURNING id, name, organization_id, created_by, created_at, updated_at;`
+	if err := DB.Get(&s, q, name, organizationID, createdBy); err != nil {
 		log.Error().Err(err).Msg("CreateSchedule failed")
 		return model.Schedule{}, err
 	}
@@ -31,14 +31,14 @@ func DeleteSchedule(scheduleID int) error {
 	return err
 }
 
-func ListSchedules(ownerID int) ([]model.Schedule, error) {
+func ListSchedules(organizationID int) ([]model.Schedule, error) {
 	var out []model.Schedule
 	const q = `
-	SELECT id, name, created_by, created_at, updated_at
+	SELECT id, name, organization_id, created_by, created_at, updated_at
 	  FROM schedules
-	 WHERE created_by = $1
+	 WHERE organization_id = $1
 	 ORDER BY id;`
-	if err := DB.Select(&out, q, ownerID); err != nil {
+	if err := DB.Select(&out, q, organizationID); err != nil {
 		log.Error().Err(err).Msg("ListSchedules failed")
 		return nil, err
 	}
@@ -47,7 +47,7 @@ func ListSchedules(ownerID int) ([]model.Schedule, error) {
 
 func GetSchedule(scheduleID int) (model.Schedule, error) {
 	var s model.Schedule
-	err := DB.Get(&s, `SELECT id, name, created_by, created_at, updated_at FROM schedules WHERE id = $1;`, scheduleID)
+	err := DB.Get(&s, `SELECT id, name, organization_id, created_by, created_at, updated_at FROM schedules WHERE id = $1;`, scheduleID)
 	if err != nil {
 		log.Error().Err(err).Int("schedule_id", scheduleID).Msg("GetSchedule failed")
 	}
@@ -180,7 +180,7 @@ func ListScheduleOccurrences(scheduleID int, from, to time.Time) ([]model.Schedu
 func GetScheduleByWindowID(windowID int) (model.Schedule, error) {
 	var s model.Schedule
 	const q = `
-		SELECT sc.id, sc.name, sc.created_by, sc.created_at, sc.updated_at
+		SELECT sc.id, sc.name, sc.organization_id, sc.created_by, sc.created_at, sc.updated_at
 		  FROM schedule_windows w
 		  JOIN schedules sc ON sc.id = w.schedule_id
 		 WHERE w.id = $1;
@@ -192,6 +192,39 @@ func GetScheduleByWindowID(windowID int) (model.Schedule, error) {
 	return s, nil
 }
 
+// ListDeviceIDsForSchedule returns the device IDs of paired screens the schedule is assigned to.
+func ListDeviceIDsForSchedule(scheduleID int) ([]string, error) {
+	var out []string
+	const q = `
+		SELECT s.device_id
+		  FROM schedule_screens ss
+		  JOIN screens s ON s.id = ss.screen_id
+		 WHERE ss.schedule_id = $1
+		   AND s.paired;
+	`
+	if err := DB.Select(&out, q, scheduleID); err != nil {
+		log.Error().Err(err).Int("schedule_id", scheduleID).Msg("ListDeviceIDsForSchedule failed")
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListScheduleIDsForScreen returns the IDs of the schedules assigned to the screen.
+func ListScheduleIDsForScreen(screenID int) ([]int, error) {
+	var out []int
+	const q = `
+		SELECT schedule_id
+		  FROM schedule_screens
+		 WHERE screen_id = $1
+		 ORDER BY schedule_id;
+	`
+	if err := DB.Select(&out, q, screenID); err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("ListScheduleIDsForScreen failed")
+		return nil, err
+	}
+	return out, nil
+}
+
 func ResolvePlaylistForScreenAt(screenID int, at time.Time) (int, error) {
 	const q = `
 	  WITH w AS (
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+// BindDeviceToScreen pairs the screen with deviceID and stores the hash of the device's
+// token. A device already bound to the screen is unbound as replaced, and its last
+// heartbeat is forgotten.
+func BindDeviceToScreen(screenID int, deviceID, tokenHash string, boundBy int) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	_, err = tx.Exec(`
+		UPDATE screen_devices
+		   SET unbound_at = now(), unbound_by = $2, unbind_reason = $3
+		 WHERE screen_id = $1 AND unbound_at IS NULL;
+	`, screenID, boundBy, model.UnbindReasonReplaced)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to close previous screen device")
+		return err
+	}
+
+	res, err := tx.Exec(`
+		UPDATE screens
+		   SET device_id = $2,
+		       paired = TRUE,
+		       device_token_hash = $3,
+		       device_token_issued_at = now(),
+		       last_seen_at = NULL,
+		       uptime_seconds = NULL,
+		       playlist_etag = NULL,
+		       app_version = NULL,
+		       updated_at = now()
+		 WHERE id = $1;
+	`, screenID, deviceID, tokenHash)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Str("device_id", deviceID).Msg("failed to bind device to screen")
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		err = sql.ErrNoRows
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO screen_devices (screen_id, device_id, bound_by, bound_at)
+		VALUES ($1, $2, $3, now());
+	`, screenID, deviceID, boundBy)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to record screen device")
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UnbindScreenDevice unpairs the screen, revoking its device token. The screen keeps its
+// name, groups, schedules and playlist. It returns sql.ErrNoRows if the screen is not paired.
+func UnbindScreenDevice(screenID, unboundBy int, reason string) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// device_id is NOT NULL UNIQUE, so an unpaired screen gets a fresh placeholder as on creation
+	res, err := tx.Exec(`
+		UPDATE screens
+		   SET device_id = $2,
+		       paired = FALSE,
+		       device_token_hash = NULL,
+		       device_token_issued_at = NULL,
+		       last_seen_at = NULL,
+		       uptime_seconds = NULL,
+		       playlist_etag = NULL,
+		       app_version = NULL,
+		       updated_at = now()
+		 WHERE id = $1 AND paired;
+	`, screenID, uuid.NewString())
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to unpair screen")
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		err = sql.ErrNoRows
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE screen_devices
+		   SET unbound_at = now(), unbound_by = $2, unbind_reason = $3
+		 WHERE screen_id = $1 AND unbound_at IS NULL;
+	`, screenID, unboundBy, reason)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to close screen device")
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListScreenDevices returns every device bound to the screen, most recent first.
+func ListScreenDevices(screenID int) ([]model.ScreenDevice, error) {
+	var out []model.ScreenDevice
+	err := DB.Select(&out, `
+		SELECT id, screen_id, device_id, bound_by, bound_at, unbound_by, unbound_at, unbind_reason
+		  FROM screen_devices
+		 WHERE screen_id = $1
+		 ORDER BY bound_at DESC, id DESC;
+	`, screenID)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to list screen devices")
+	}
+	return out, err
+}
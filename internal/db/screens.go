@@ -14,7 +14,8 @@ import (
 func GetScreenByID(id int) (model.Screen, error) {
 	var screen model.Screen
 	err := DB.Get(&screen, `
-		SELECT id, device_id, client_information, client_width, client_height, name, location, paired, created_by, created_at, updated_at
+		SELECT id, device_id, client_information, client_width, client_height, name, location, paired, organization_id, created_by, created_at, updated_at,
+		       last_seen_at, uptime_seconds, playlist_etag, app_version, storage_size, ip_address
 		FROM screens
 		WHERE id = $1
 		`, id)
@@ -27,7 +28,8 @@ func GetScreenByID(id int) (model.Screen, error) {
 func GetScreenByDeviceID(deviceID *string) (model.Screen, error) {
 	var screen model.Screen
 	err := DB.Get(&screen, `
-		SELECT id, device_id, client_information, client_width, client_height, name, location, paired, created_by, created_at, updated_at
+		SELECT id, device_id, client_information, client_width, client_height, name, location, paired, organization_id, created_by, created_at, updated_at,
+		       last_seen_at, uptime_seconds, playlist_etag, app_version, storage_size, ip_address
 		FROM screens
 		WHERE device_id = $1
 		`, deviceID)
@@ -37,6 +39,69 @@ func GetScreenByDeviceID(deviceID *string) (model.Screen, error) {
 	return screen, err
 }
 
+// GetScreenByDeviceTokenHash returns sql.ErrNoRows if no screen holds the token.
+func GetScreenByDeviceTokenHash(tokenHash string) (model.Screen, error) {
+	var screen model.Screen
+	err := DB.Get(&screen, `
+		SELECT id, device_id, client_information, client_width, client_height, name, location, paired, organization_id, created_by, created_at, updated_at,
+		       last_seen_at, uptime_seconds, playlist_etag, app_version, storage_size, ip_address
+		FROM screens
+		WHERE device_token_hash = $1
+		`, tokenHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Msg("failed to get screen by device token")
+	}
+	return screen, err
+}
+
+// SetScreenDeviceToken replaces the screen's device token hash; a nil hash revokes it.
+func SetScreenDeviceToken(screenID int, tokenHash *string) error {
+	res, err := DB.Exec(`
+		UPDATE screens
+		   SET device_token_hash = $2,
+		       device_token_issued_at = CASE WHEN $2::text IS NULL THEN NULL ELSE now() END,
+		       updated_at = now()
+		 WHERE id = $1
+	`, screenID, tokenHash)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to set screen device token")
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RecordScreenHeartbeat stores the device's latest heartbeat and marks the screen as seen now.
+func RecordScreenHeartbeat(screenID int, hb model.Heartbeat) error {
+	res, err := DB.Exec(`
+		UPDATE screens
+		   SET last_seen_at = now(),
+		       uptime_seconds = $2,
+		       playlist_etag = NULLIF($3, ''),
+		       app_version = COALESCE(NULLIF($4, ''), app_version)
+		 WHERE id = $1
+	`, screenID, hb.UptimeSeconds, hb.PlaylistETag, hb.AppVersion)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to record screen heartbeat")
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// MarkScreenSeen records contact from the screen's device without a full heartbeat.
+func MarkScreenSeen(screenID int) error {
+	_, err := DB.Exec(`UPDATE screens SET last_seen_at = now() WHERE id = $1;`, screenID)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to mark screen seen")
+	}
+	return err
+}
+
 func IsScreenPairedByDeviceID(deviceID *string) (bool, error) {
 	var isPaired bool
 	err := DB.Get(&isPaired, `
@@ -51,31 +116,34 @@ func IsScreenPairedByDeviceID(deviceID *string) (bool, error) {
 	return isPaired, err
 }
 
-func ListScreens() ([]model.Screen, error) {
+func ListScreens(organizationID int) ([]model.Screen, error) {
 	var screens []model.Screen
 	err := DB.Select(&screens, `
-		SELECT id, device_id, client_information, client_width, client_height, name, location, paired, created_by, created_at, updated_at
+		SELECT id, device_id, client_information, client_width, client_height, name, location, paired, organization_id, created_by, created_at, updated_at,
+		       last_seen_at, uptime_seconds, playlist_etag, app_version, storage_size, ip_address
 		FROM screens
+		WHERE organization_id = $1
 		ORDER BY id
-		`)
+		`, organizationID)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to list screens")
+		log.Error().Err(err).Int("organization_id", organizationID).Msg("failed to list screens")
 	}
 	return screens, err
 }
 
 // CreateScreen now generates a UUID for device_id to satisfy NOT NULL + UNIQUE.
-func CreateScreen(name string, location *string, createdBy int) (model.Screen, error) {
+func CreateScreen(name string, location *string, organizationID, createdBy int) (model.Screen, error) {
 	var s model.Screen
 	deviceID := uuid.NewString()
 
 	q := `
-    INSERT INTO screens (device_id, name, location, paired, created_by, created_at, updated_at)
-    VALUES ($1, $2, $3, false, $4, now(), now())
+    INSERT INTO screens (device_id, name, location, paired, organization_id, created_by, created_at, updated_at)
+    VALUES ($1, $2, $3, false, $4, $5, now(), now())
     RETURNING id, device_id, client_information, client_width, client_height,
-              name, location, paired, created_by, created_at, updated_at;
+              name, location, paired, organization_id, created_by, created_at, updated_at,
+              last_seen_at, uptime_seconds, playlist_etag, app_version, storage_size, ip_address;
     `
-	if err := DB.Get(&s, q, deviceID, name, location, createdBy); err != nil {
+	if err := DB.Get(&s, q, deviceID, name, location, organizationID, createdBy); err != nil {
 		log.Error().Err(err).Str("device_id", deviceID).Msg("failed to create screen")
 		return model.Screen{}, err
 	}
@@ -183,18 +251,81 @@ func DeleteScreen(id int) error {
 	return err
 }
 
-func AssignScreenToUser(screenID, userID int) error {
+func AssignScreenToUser(screenID, userID, assignedBy int) error {
 	_, err := DB.Exec(`
-		INSERT INTO screen_assignments (screen_id, user_id)
-		VALUES ($1, $2)
+		INSERT INTO screen_assignments (screen_id, user_id, assigned_by, assigned_at)
+		VALUES ($1, $2, $3, now())
 		ON CONFLICT DO NOTHING
-	`, screenID, userID)
+	`, screenID, userID, assignedBy)
 	if err != nil {
 		log.Error().Err(err).Int("screen_id", screenID).Int("user_id", userID).Msg("failed to assign screen to user")
 	}
 	return err
 }
 
+// UnassignScreenFromUser returns sql.ErrNoRows if the screen was not assigned to the user.
+func UnassignScreenFromUser(screenID, userID int) error {
+	res, err := DB.Exec(`
+		DELETE FROM screen_assignments
+		 WHERE screen_id = $1 AND user_id = $2
+	`, screenID, userID)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Int("user_id", userID).Msg("failed to unassign screen from user")
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func IsScreenAssignedToUser(screenID, userID int) (bool, error) {
+	var assigned bool
+	err := DB.Get(&assigned, `
+		SELECT EXISTS (
+			SELECT 1 FROM screen_assignments
+			 WHERE screen_id = $1 AND user_id = $2
+		)
+	`, screenID, userID)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Int("user_id", userID).Msg("failed to check screen assignment")
+	}
+	return assigned, err
+}
+
+func ListScreenAssignments(screenID int) ([]model.ScreenAssignment, error) {
+	var out []model.ScreenAssignment
+	err := DB.Select(&out, `
+		SELECT a.screen_id, a.user_id, u.email, u.name, a.assigned_by, a.assigned_at
+		  FROM screen_assignments a
+		  JOIN users u ON u.id = a.user_id
+		 WHERE a.screen_id = $1
+		 ORDER BY a.assigned_at, a.user_id
+	`, screenID)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to list screen assignments")
+	}
+	return out, err
+}
+
+// ListScreensAssignedToUser returns the organization's screens delegated to the user.
+func ListScreensAssignedToUser(organizationID, userID int) ([]model.Screen, error) {
+	var screens []model.Screen
+	err := DB.Select(&screens, `
+		SELECT s.id, s.device_id, s.client_information, s.client_width, s.client_height, s.name, s.location, s.paired, s.organization_id, s.created_by, s.created_at, s.updated_at,
+		       s.last_seen_at, s.uptime_seconds, s.playlist_etag, s.app_version
+		  FROM screens s
+		  JOIN screen_assignments a ON a.screen_id = s.id
+		 WHERE s.organization_id = $1 AND a.user_id = $2
+		 ORDER BY s.id
+	`, organizationID, userID)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Int("user_id", userID).
+			Msg("failed to list screens assigned to user")
+	}
+	return screens, err
+}
+
 func deref(s *string) string {
 	if s == nil {
 		return ""
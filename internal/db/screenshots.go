@@ -0,0 +1,97 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+const screenshotColumns = `id, organization_id, screen_id, command_id, url, content_type, size_bytes, captured_at`
+
+// CreateScreenshot records an uploaded screenshot. The command ID is kept only if it names
+// one of the screen's commands, so a device cannot attach its upload to another screen's.
+func CreateScreenshot(s model.Screenshot) (model.Screenshot, error) {
+	var out model.Screenshot
+	err := DB.Get(&out, `
+		INSERT INTO screen_screenshots (organization_id, screen_id, command_id, url, content_type, size_bytes, captured_at)
+		VALUES ($1, $2, (SELECT id FROM device_commands WHERE id = $3 AND screen_id = $2), $4, $5, $6, now())
+		RETURNING `+screenshotColumns+`;
+	`, s.OrganizationID, s.ScreenID, s.CommandID, s.URL, s.ContentType, s.SizeBytes)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", s.ScreenID).Msg("failed to create screenshot")
+	}
+	return out, err
+}
+
+// ListScreenshots returns the screen's screenshots, newest first.
+func ListScreenshots(screenID int) ([]model.Screenshot, error) {
+	var out []model.Screenshot
+	err := DB.Select(&out, `
+		SELECT `+screenshotColumns+`
+		  FROM screen_screenshots
+		 WHERE screen_id = $1
+		 ORDER BY captured_at DESC, id DESC;
+	`, screenID)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to list screenshots")
+	}
+	return out, err
+}
+
+// GetScreenshot returns one of the screen's screenshots, or sql.ErrNoRows if it has no such
+// screenshot.
+func GetScreenshot(screenID, id int) (model.Screenshot, error) {
+	var out model.Screenshot
+	err := DB.Get(&out, `
+		SELECT `+screenshotColumns+`
+		  FROM screen_screenshots
+		 WHERE id = $1 AND screen_id = $2;
+	`, id, screenID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Int("screenshot_id", id).Msg("failed to get screenshot")
+	}
+	return out, err
+}
+
+// DeleteScreenshot removes one of the screen's screenshots and returns it, so its file can
+// be deleted too. It returns sql.ErrNoRows if the screen has no such screenshot.
+func DeleteScreenshot(screenID, id int) (model.Screenshot, error) {
+	var out model.Screenshot
+	err := DB.Get(&out, `
+		DELETE FROM screen_screenshots
+		 WHERE id = $1 AND screen_id = $2
+		RETURNING `+screenshotColumns+`;
+	`, id, screenID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Int("screenshot_id", id).Msg("failed to delete screenshot")
+	}
+	return out, err
+}
+
+// PruneScreenshots deletes the screen's screenshots beyond the most recent keep, and every
+// screenshot captured before olderThan, returning the deleted rows so their files can be
+// deleted too.
+func PruneScreenshots(screenID, keep int, olderThan time.Time) ([]model.Screenshot, error) {
+	var out []model.Screenshot
+	err := DB.Select(&out, `
+		DELETE FROM screen_screenshots
+		 WHERE id IN (
+		         SELECT id
+		           FROM (SELECT id, row_number() OVER (ORDER BY captured_at DESC, id DESC) AS n
+		                   FROM screen_screenshots
+		                  WHERE screen_id = $1) ranked
+		          WHERE n > $2
+		       )
+		    OR captured_at < $3
+		RETURNING `+screenshotColumns+`;
+	`, screenID, keep, olderThan)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to prune screenshots")
+	}
+	return out, err
+}
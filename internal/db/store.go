@@ -1,6 +1,7 @@
 package db
 
 import (
+	"encoding/json"
 	"github.com/Nixie-Tech-LLC/medusa/internal/model"
 	"github.com/jmoiron/sqlx"
 	"time"
@@ -8,70 +9,160 @@ import (
 
 // ContentItem represents a content item with URL and duration
 type ContentItem struct {
-	URL      string `db:"url"`
-	Duration int    `db:"duration"`
-	Type     string `db:"type"`
+	ContentID int     `db:"content_id"`
+	URL       string  `db:"url"`
+	Duration  int     `db:"duration"`
+	Type      string  `db:"type"`
+	SHA256    *string `db:"sha256"`
+	SizeBytes *int64  `db:"size_bytes"`
+	MimeType  *string `db:"mime_type"`
 }
 
 // Store defines all operations against the database.
 type Store interface {
 	// user functions
 	CreateUser(email, hashedPassword string, name *string) (int, error)
+	CreateUserWithOrganization(email, hashedPassword string, name *string, organizationName string) (int, error)
+	CreateUserFromInvitation(email, hashedPassword string, name *string, invitationID int) (int, error)
 	GetUserByEmail(email string) (*model.User, error)
 	GetUserByID(id int) (*model.User, error)
 	UpdateUserProfile(id int, email string, name *string) error
+	UpdateUserPassword(id int, hashedPassword string) error
+	GetUserByIdentity(issuer, subject string) (*model.User, error)
+	LinkUserIdentity(userID int, issuer, subject, email string) error
+
+	// password resets
+	CreatePasswordResetToken(userID int, tokenHash string, expiresAt time.Time) error
+	ConsumePasswordResetToken(tokenHash string) (int, error)
+
+	// two-factor
+	SetPendingTOTPSecret(userID int, secret string) error
+	EnableTOTP(userID int) error
+	DisableTOTP(userID int) error
+	MarkTOTPStepUsed(userID int, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID int, codeHashes []string) error
+	ConsumeRecoveryCode(userID int, codeHash string) (bool, error)
+	CountUnusedRecoveryCodes(userID int) (int, error)
+	UserRequiresTwoFactor(userID int) (bool, error)
+
+	// refresh tokens
+	CreateRefreshToken(userID int, familyID, tokenHash string, expiresAt time.Time, userAgent, ip *string) error
+	GetRefreshTokenByHash(tokenHash string) (model.RefreshToken, error)
+	RotateRefreshToken(oldID int, newHash string, expiresAt time.Time, userAgent, ip *string) error
+	RevokeRefreshTokenFamily(familyID string) error
+	RevokeAllRefreshTokensForUser(userID int) error
+
+	// api keys
+	CreateAPIKey(organizationID, createdBy int, name, prefix, keyHash string, scopes []model.Permission, expiresAt *time.Time) (model.APIKey, error)
+	GetAPIKeyByHash(keyHash string) (model.APIKey, error)
+	GetAPIKeyByID(id int) (model.APIKey, error)
+	ListAPIKeys(organizationID int) ([]model.APIKey, error)
+	RevokeAPIKey(id int) error
+	TouchAPIKey(id int) error
+
+	// audit
+	CreateAuditEvent(e model.AuditEvent) error
+	ListAuditEvents(f AuditFilter) ([]model.AuditEvent, int, error)
+
+	// invitations
+	CreateInvitation(organizationID, invitedBy int, email, role, tokenHash string, expiresAt time.Time) (model.Invitation, error)
+	GetInvitationByTokenHash(tokenHash string) (model.Invitation, error)
+	ListPendingInvitations(organizationID int) ([]model.Invitation, error)
+	RevokeInvitation(organizationID, id int) error
+	AcceptInvitation(id, userID int) error
+
+	// organizations
+	CreateOrganization(name string, createdBy int) (model.Organization, error)
+	GetOrganizationByID(id int) (model.Organization, error)
+	ListOrganizationsForUser(userID int) ([]model.Organization, error)
+	AddOrganizationMember(organizationID, userID int, role string) error
+	RemoveOrganizationMember(organizationID, userID int) error
+	ListOrganizationMembers(organizationID int) ([]model.OrganizationMember, error)
+	GetOrganizationMembership(organizationID, userID int) (model.OrganizationMember, error)
+	GetDefaultOrganizationMembership(userID int) (model.OrganizationMember, error)
+	UpdateOrganizationMemberRole(organizationID, userID int, role string) error
+	CountOrganizationMembersWithRole(organizationID int, role string) (int, error)
+	SetOrganizationRequireTwoFactor(organizationID int, required bool) error
+
+	// roles
+	ListRoles() ([]model.Role, error)
+	GetRole(name string) (model.Role, error)
 
 	// screen functions
-	CreateScreen(name string, location *string, createdBy int) (model.Screen, error)
+	CreateScreen(name string, location *string, organizationID, createdBy int) (model.Screen, error)
 	UpdateScreen(id int, name, location *string) error
 	UpdateScreenIP(id int, ip *string) error
 	GetScreenByID(id int) (model.Screen, error)
 	DeleteScreen(id int) error
 
-	ListScreens() ([]model.Screen, error)
-	AssignScreenToUser(screenID, userID int) error
+	ListScreens(organizationID int) ([]model.Screen, error)
+	AssignScreenToUser(screenID, userID, assignedBy int) error
+	UnassignScreenFromUser(screenID, userID int) error
+	IsScreenAssignedToUser(screenID, userID int) (bool, error)
+	ListScreenAssignments(screenID int) ([]model.ScreenAssignment, error)
+	ListScreensAssignedToUser(organizationID, userID int) ([]model.Screen, error)
 	AssignDeviceIDToScreen(screenID int, deviceID *string) error
 	UpdateClientInformation(screenID int, clientInformation *string) error
 	UpdateClientDimensions(screenID int, width, height int) error
 	UpdateClientStorageSize(screenID int, storageSize int64) error
 	PairScreen(screenID int) error
 	// groups
-	CreateScreenGroup(userID int, name, description *string) (model.ScreenGroup, error)
-	RenameScreenGroup(userID, groupID int, newName, newDescription *string) (model.ScreenGroup, error)
-	DeleteScreenGroup(userID, groupID int) error
+	CreateScreenGroup(organizationID, userID int, name, description *string) (model.ScreenGroup, error)
+	RenameScreenGroup(organizationID, groupID int, newName, newDescription *string) (model.ScreenGroup, error)
+	DeleteScreenGroup(organizationID, groupID int) error
 	GetScreenGroupByID(groupID int) (model.ScreenGroup, error)
-	ListScreenGroups(userID int) ([]model.ScreenGroup, error)
+	ListScreenGroups(organizationID int) ([]model.ScreenGroup, error)
 
 	// membership
-	AddScreenToGroup(userID, groupID, screenID int) error
-	RemoveScreenFromGroup(userID, groupID, screenID int) error
-	ListScreensInGroup(userID, groupID int) ([]model.Screen, error)
-	ListGroupsForScreen(userID, screenID int) ([]model.ScreenGroup, error)
+	AddScreenToGroup(organizationID, groupID, screenID int) error
+	RemoveScreenFromGroup(organizationID, groupID, screenID int) error
+	ListScreensInGroup(organizationID, groupID int) ([]model.Screen, error)
+	ListGroupsForScreen(organizationID, screenID int) ([]model.ScreenGroup, error)
 	IsScreenPairedByDeviceID(deviceID *string) (bool, error)
 	GetScreenByDeviceID(deviceID *string) (model.Screen, error)
+	GetScreenByDeviceTokenHash(tokenHash string) (model.Screen, error)
+	SetScreenDeviceToken(screenID int, tokenHash *string) error
+	BindDeviceToScreen(screenID int, deviceID, tokenHash string, boundBy int) error
+	UnbindScreenDevice(screenID, unboundBy int, reason string) error
+	ListScreenDevices(screenID int) ([]model.ScreenDevice, error)
+	RecordScreenHeartbeat(screenID int, hb model.Heartbeat) error
+	MarkScreenSeen(screenID int) error
 
 	// content functions
-	CreateContent(name, typ, url string, resWidth int, resHeight int, createdBy int) (model.Content, error)
+	CreateContent(name, typ, url string, resWidth int, resHeight int, organizationID, createdBy int, asset *model.ContentAsset) (model.Content, error)
 	GetContentByID(id int) (model.Content, error)
 	UpdateContent(id int, name, url *string, width int, height int) error
 	DeleteContent(id int) error
+	IsContentFileInUse(organizationID int, sha256, url string) (bool, error)
+	FindContentFileBySHA256(organizationID int, sha256 string) (string, error)
+	LockContentFile(organizationID int, sha256 string) (unlock func(), err error)
+
+	// content upload functions
+	CreateContentUpload(u model.ContentUpload) (model.ContentUpload, error)
+	GetContentUpload(id string) (model.ContentUpload, error)
+	ClaimUploadChunk(id string, offset int64, lease string, staleAfter time.Duration) (model.ContentUpload, error)
+	ReleaseUploadChunk(id, lease string) error
+	RecordUploadChunk(id, lease string, received int64, hashState []byte, mimeType *string, expiresAt time.Time) (model.ContentUpload, error)
+	SetContentUploadStatus(id, from, to string) (model.ContentUpload, error)
+	DeleteContentUpload(id string) (model.ContentUpload, error)
+	DeleteExpiredContentUploads(before time.Time) ([]model.ContentUpload, error)
 
 	ListContent() ([]model.Content, error)
-	SearchContent(name, contentType *string, createdBy *int) ([]model.Content, error)
-	SearchContentMultiple(names, types []string, createdBy *int) ([]model.Content, error)
+	SearchContent(name, contentType *string, organizationID *int) ([]model.Content, error)
+	SearchContentMultiple(names, types []string, organizationID *int) ([]model.Content, error)
 
 	// playlists
-	CreatePlaylist(name, description string, createdBy int) (model.Playlist, error)
+	CreatePlaylist(name, description string, organizationID, createdBy int) (model.Playlist, error)
 	GetPlaylistByID(id int) (model.Playlist, error)
 	UpdatePlaylist(id int, name, description *string) error
 	DeletePlaylist(id int) error
 
-	ListPlaylists() ([]model.Playlist, error)
+	ListPlaylists(organizationID int) ([]model.Playlist, error)
 
 	// playlist items
 	AddItemToPlaylist(playlistID, contentID, position, duration int) (model.PlaylistItem, error)
-	UpdatePlaylistItem(itemID int, position, duration *int) error
-	RemovePlaylistItem(itemID int) error
+	UpdatePlaylistItem(playlistID, itemID int, position, duration *int) error
+	RemovePlaylistItem(playlistID, itemID int) error
 
 	ListPlaylistItems(playlistID int) ([]model.PlaylistItem, error)
 	ReorderPlaylistItems(playlistID int, itemIDs []int) error
@@ -80,25 +171,66 @@ type Store interface {
 	AssignPlaylistToScreen(screenID, playlistID int) error
 	GetPlaylistForScreen(screenID int) (model.Playlist, error)
 	GetScreensUsingPlaylist(playlistID int) ([]model.Screen, error)
+	ListDeviceIDsForPlaylist(playlistID int) ([]string, error)
 	GetPlaylistContentForScreen(screenID int) (string, []ContentItem, error)
 
-	CreateSchedule(name string, createdBy int) (model.Schedule, error)
+	CreateSchedule(name string, organizationID, createdBy int) (model.Schedule, error)
 	DeleteSchedule(scheduleID int) error
 	GetScheduleByID(scheduleID int) (model.Schedule, error)
 
 	AssignScheduleToScreen(scheduleID, screenID int) error
 	UnassignScheduleFromScreen(scheduleID, screenID int) error
-	ListSchedules(ownerID int) ([]model.Schedule, error)
+	ListSchedules(organizationID int) ([]model.Schedule, error)
 
 	CreateScheduleWindow(scheduleID, playlistID int, start, end time.Time, recurrence string, recurUntil *time.Time, priority int) (model.ScheduleWindow, error)
 	DeleteScheduleWindowAll(windowID int) error
 	DeleteScheduleWindowOneOccurrence(windowID int, occurStart time.Time) error
 	ListScheduleOccurrences(scheduleID int, from, to time.Time) ([]model.ScheduleOccurrence, error)
 	GetScheduleByWindowID(windowID int) (model.Schedule, error)
+	ListDeviceIDsForSchedule(scheduleID int) ([]string, error)
+	ListScheduleIDsForScreen(screenID int) ([]int, error)
 
 	ResolvePlaylistForScreenAt(screenID int, at time.Time) (int, error)
 	GetEffectivePlaylistForScreen(screenID int, now time.Time) (model.Playlist, []ContentItem, string, error)
 	GetPlaylistContentByPlaylistID(playlistID int) (string, []ContentItem, error)
+
+	// alerting
+	CreateAlertRule(r model.AlertRule) (model.AlertRule, error)
+	UpdateAlertRule(r model.AlertRule) (model.AlertRule, error)
+	GetAlertRule(organizationID, id int) (model.AlertRule, error)
+	ListAlertRules(organizationID int) ([]model.AlertRule, error)
+	ListEnabledAlertRules() ([]model.AlertRule, error)
+	DeleteAlertRule(organizationID, id int) error
+	ListAlertRuleScreens(ruleID int) ([]model.Screen, error)
+	ListAlertRuleStates(ruleID int) (map[int]string, error)
+	SetAlertState(ruleID, screenID int, state string) (bool, error)
+	MarkAlertNotified(ruleID, screenID int, state string) error
+	ClaimAlertRetries(ruleID int, retryAfter time.Duration, maxAttempts int) (map[int]string, error)
+	ListFiringAlerts(organizationID int) ([]model.AlertState, error)
+
+	// proof-of-play
+	RecordPlayEvents(organizationID, screenID int, events []model.PlayEvent) (int, error)
+	PlayReport(f PlayReportFilter) ([]model.PlayStat, error)
+
+	// device commands
+	CreateDeviceCommand(c model.DeviceCommand) (model.DeviceCommand, error)
+	MarkDeviceCommandSent(id string) error
+	AckDeviceCommand(screenID int, id, status string, message *string, result json.RawMessage) (model.DeviceCommand, error)
+	ListDeviceCommands(screenID, limit int) ([]model.DeviceCommand, error)
+	ListPendingDeviceCommands(screenID int) ([]model.DeviceCommand, error)
+
+	// screenshots
+	CreateScreenshot(shot model.Screenshot) (model.Screenshot, error)
+	ListScreenshots(screenID int) ([]model.Screenshot, error)
+	GetScreenshot(screenID, id int) (model.Screenshot, error)
+	DeleteScreenshot(screenID, id int) (model.Screenshot, error)
+	PruneScreenshots(screenID, keep int, olderThan time.Time) ([]model.Screenshot, error)
+
+	// telemetry
+	RecordScreenTelemetry(screenID int, ip *string, t model.Telemetry) error
+	GetLatestScreenTelemetry(screenID int) (model.TelemetrySample, error)
+	ListLatestTelemetry(organizationID int, since time.Time) ([]model.TelemetrySample, error)
+	ScreenTelemetryHistory(screenID int, from, to time.Time, bucket time.Duration) ([]model.TelemetryBucket, error)
 }
 
 // pgStore is the SQL-backed implementation of Store.
@@ -118,6 +250,14 @@ func NewStore(db *sqlx.DB) Store {
 func (s *pgStore) CreateUser(email, hashedPassword string, name *string) (int, error) {
 	return CreateUser(email, hashedPassword, name)
 }
+
+func (s *pgStore) CreateUserWithOrganization(email, hashedPassword string, name *string, organizationName string) (int, error) {
+	return CreateUserWithOrganization(email, hashedPassword, name, organizationName)
+}
+
+func (s *pgStore) CreateUserFromInvitation(email, hashedPassword string, name *string, invitationID int) (int, error) {
+	return CreateUserFromInvitation(email, hashedPassword, name, invitationID)
+}
 func (s *pgStore) GetUserByEmail(email string) (*model.User, error) {
 	return GetUserByEmail(email)
 }
@@ -127,16 +267,165 @@ func (s *pgStore) GetUserByID(id int) (*model.User, error) {
 func (s *pgStore) UpdateUserProfile(id int, email string, name *string) error {
 	return UpdateUserProfile(id, email, name)
 }
+func (s *pgStore) UpdateUserPassword(id int, hashedPassword string) error {
+	return UpdateUserPassword(id, hashedPassword)
+}
+func (s *pgStore) GetUserByIdentity(issuer, subject string) (*model.User, error) {
+	return GetUserByIdentity(issuer, subject)
+}
+func (s *pgStore) LinkUserIdentity(userID int, issuer, subject, email string) error {
+	return LinkUserIdentity(userID, issuer, subject, email)
+}
+
+// @ Password reset
+func (s *pgStore) CreatePasswordResetToken(userID int, tokenHash string, expiresAt time.Time) error {
+	return CreatePasswordResetToken(userID, tokenHash, expiresAt)
+}
+func (s *pgStore) ConsumePasswordResetToken(tokenHash string) (int, error) {
+	return ConsumePasswordResetToken(tokenHash)
+}
+
+// @ Two-factor
+func (s *pgStore) SetPendingTOTPSecret(userID int, secret string) error {
+	return SetPendingTOTPSecret(userID, secret)
+}
+func (s *pgStore) EnableTOTP(userID int) error {
+	return EnableTOTP(userID)
+}
+func (s *pgStore) DisableTOTP(userID int) error {
+	return DisableTOTP(userID)
+}
+func (s *pgStore) MarkTOTPStepUsed(userID int, step int64) (bool, error) {
+	return MarkTOTPStepUsed(userID, step)
+}
+func (s *pgStore) ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	return ReplaceRecoveryCodes(userID, codeHashes)
+}
+func (s *pgStore) ConsumeRecoveryCode(userID int, codeHash string) (bool, error) {
+	return ConsumeRecoveryCode(userID, codeHash)
+}
+func (s *pgStore) CountUnusedRecoveryCodes(userID int) (int, error) {
+	return CountUnusedRecoveryCodes(userID)
+}
+func (s *pgStore) UserRequiresTwoFactor(userID int) (bool, error) {
+	return UserRequiresTwoFactor(userID)
+}
+
+// @ Refresh token
+func (s *pgStore) CreateRefreshToken(userID int, familyID, tokenHash string, expiresAt time.Time, userAgent, ip *string) error {
+	return CreateRefreshToken(userID, familyID, tokenHash, expiresAt, userAgent, ip)
+}
+func (s *pgStore) GetRefreshTokenByHash(tokenHash string) (model.RefreshToken, error) {
+	return GetRefreshTokenByHash(tokenHash)
+}
+func (s *pgStore) RotateRefreshToken(oldID int, newHash string, expiresAt time.Time, userAgent, ip *string) error {
+	return RotateRefreshToken(oldID, newHash, expiresAt, userAgent, ip)
+}
+func (s *pgStore) RevokeRefreshTokenFamily(familyID string) error {
+	return RevokeRefreshTokenFamily(familyID)
+}
+func (s *pgStore) RevokeAllRefreshTokensForUser(userID int) error {
+	return RevokeAllRefreshTokensForUser(userID)
+}
+
+// @ API key
+func (s *pgStore) CreateAPIKey(organizationID, createdBy int, name, prefix, keyHash string, scopes []model.Permission, expiresAt *time.Time) (model.APIKey, error) {
+	return CreateAPIKey(organizationID, createdBy, name, prefix, keyHash, scopes, expiresAt)
+}
+func (s *pgStore) GetAPIKeyByHash(keyHash string) (model.APIKey, error) {
+	return GetAPIKeyByHash(keyHash)
+}
+func (s *pgStore) GetAPIKeyByID(id int) (model.APIKey, error) {
+	return GetAPIKeyByID(id)
+}
+func (s *pgStore) ListAPIKeys(organizationID int) ([]model.APIKey, error) {
+	return ListAPIKeys(organizationID)
+}
+func (s *pgStore) RevokeAPIKey(id int) error {
+	return RevokeAPIKey(id)
+}
+func (s *pgStore) TouchAPIKey(id int) error {
+	return TouchAPIKey(id)
+}
+
+// @ Audit
+func (s *pgStore) CreateAuditEvent(e model.AuditEvent) error {
+	return CreateAuditEvent(e)
+}
+
+func (s *pgStore) ListAuditEvents(f AuditFilter) ([]model.AuditEvent, int, error) {
+	return ListAuditEvents(f)
+}
+
+// @ Invitation
+func (s *pgStore) CreateInvitation(organizationID, invitedBy int, email, role, tokenHash string, expiresAt time.Time) (model.Invitation, error) {
+	return CreateInvitation(organizationID, invitedBy, email, role, tokenHash, expiresAt)
+}
+func (s *pgStore) GetInvitationByTokenHash(tokenHash string) (model.Invitation, error) {
+	return GetInvitationByTokenHash(tokenHash)
+}
+func (s *pgStore) ListPendingInvitations(organizationID int) ([]model.Invitation, error) {
+	return ListPendingInvitations(organizationID)
+}
+func (s *pgStore) RevokeInvitation(organizationID, id int) error {
+	return RevokeInvitation(organizationID, id)
+}
+func (s *pgStore) AcceptInvitation(id, userID int) error {
+	return AcceptInvitation(id, userID)
+}
+
+// @ Organization
+func (s *pgStore) CreateOrganization(name string, createdBy int) (model.Organization, error) {
+	return CreateOrganization(name, createdBy)
+}
+func (s *pgStore) GetOrganizationByID(id int) (model.Organization, error) {
+	return GetOrganizationByID(id)
+}
+func (s *pgStore) ListOrganizationsForUser(userID int) ([]model.Organization, error) {
+	return ListOrganizationsForUser(userID)
+}
+func (s *pgStore) AddOrganizationMember(organizationID, userID int, role string) error {
+	return AddOrganizationMember(organizationID, userID, role)
+}
+func (s *pgStore) RemoveOrganizationMember(organizationID, userID int) error {
+	return RemoveOrganizationMember(organizationID, userID)
+}
+func (s *pgStore) ListOrganizationMembers(organizationID int) ([]model.OrganizationMember, error) {
+	return ListOrganizationMembers(organizationID)
+}
+func (s *pgStore) GetOrganizationMembership(organizationID, userID int) (model.OrganizationMember, error) {
+	return GetOrganizationMembership(organizationID, userID)
+}
+func (s *pgStore) GetDefaultOrganizationMembership(userID int) (model.OrganizationMember, error) {
+	return GetDefaultOrganizationMembership(userID)
+}
+func (s *pgStore) UpdateOrganizationMemberRole(organizationID, userID int, role string) error {
+	return UpdateOrganizationMemberRole(organizationID, userID, role)
+}
+func (s *pgStore) CountOrganizationMembersWithRole(organizationID int, role string) (int, error) {
+	return CountOrganizationMembersWithRole(organizationID, role)
+}
+func (s *pgStore) SetOrganizationRequireTwoFactor(organizationID int, required bool) error {
+	return SetOrganizationRequireTwoFactor(organizationID, required)
+}
+
+// @ Role
+func (s *pgStore) ListRoles() ([]model.Role, error) {
+	return ListRoles()
+}
+func (s *pgStore) GetRole(name string) (model.Role, error) {
+	return GetRole(name)
+}
 
 // @ Screen
 func (s *pgStore) GetScreenByID(id int) (model.Screen, error) {
 	return GetScreenByID(id)
 }
-func (s *pgStore) ListScreens() ([]model.Screen, error) {
-	return ListScreens()
+func (s *pgStore) ListScreens(organizationID int) ([]model.Screen, error) {
+	return ListScreens(organizationID)
 }
-func (s *pgStore) CreateScreen(name string, location *string, createdBy int) (model.Screen, error) {
-	return CreateScreen(name, location, createdBy)
+func (s *pgStore) CreateScreen(name string, location *string, organizationID, createdBy int) (model.Screen, error) {
+	return CreateScreen(name, location, organizationID, createdBy)
 }
 func (s *pgStore) UpdateScreen(id int, name, location *string) error {
 	return UpdateScreen(id, name, location)
@@ -147,8 +436,20 @@ func (s *pgStore) UpdateScreenIP(id int, ip *string) error {
 func (s *pgStore) DeleteScreen(id int) error {
 	return DeleteScreen(id)
 }
-func (s *pgStore) AssignScreenToUser(screenID, userID int) error {
-	return AssignScreenToUser(screenID, userID)
+func (s *pgStore) AssignScreenToUser(screenID, userID, assignedBy int) error {
+	return AssignScreenToUser(screenID, userID, assignedBy)
+}
+func (s *pgStore) UnassignScreenFromUser(screenID, userID int) error {
+	return UnassignScreenFromUser(screenID, userID)
+}
+func (s *pgStore) IsScreenAssignedToUser(screenID, userID int) (bool, error) {
+	return IsScreenAssignedToUser(screenID, userID)
+}
+func (s *pgStore) ListScreenAssignments(screenID int) ([]model.ScreenAssignment, error) {
+	return ListScreenAssignments(screenID)
+}
+func (s *pgStore) ListScreensAssignedToUser(organizationID, userID int) ([]model.Screen, error) {
+	return ListScreensAssignedToUser(organizationID, userID)
 }
 func (s *pgStore) AssignDeviceIDToScreen(screenID int, deviceID *string) error {
 	return AssignDeviceIDToScreen(screenID, deviceID)
@@ -172,9 +473,10 @@ func (s *pgStore) IsScreenPairedByDeviceID(deviceID *string) (bool, error) {
 // @ Content
 func (s *pgStore) CreateContent(
 	name, typ, url string,
-	resWidth, resHeight, createdBy int,
+	resWidth, resHeight, organizationID, createdBy int,
+	asset *model.ContentAsset,
 ) (model.Content, error) {
-	return CreateContent(name, typ, url, resWidth, resHeight, createdBy)
+	return CreateContent(name, typ, url, resWidth, resHeight, organizationID, createdBy, asset)
 }
 func (s *pgStore) GetContentByID(id int) (model.Content, error) {
 	return GetContentByID(id)
@@ -182,11 +484,11 @@ func (s *pgStore) GetContentByID(id int) (model.Content, error) {
 func (s *pgStore) ListContent() ([]model.Content, error) {
 	return ListContent()
 }
-func (s *pgStore) SearchContent(name, contentType *string, createdBy *int) ([]model.Content, error) {
-	return SearchContent(name, contentType, createdBy)
+func (s *pgStore) SearchContent(name, contentType *string, organizationID *int) ([]model.Content, error) {
+	return SearchContent(name, contentType, organizationID)
 }
-func (s *pgStore) SearchContentMultiple(names, types []string, createdBy *int) ([]model.Content, error) {
-	return SearchContentMultiple(names, types, createdBy)
+func (s *pgStore) SearchContentMultiple(names, types []string, organizationID *int) ([]model.Content, error) {
+	return SearchContentMultiple(names, types, organizationID)
 }
 func (s *pgStore) UpdateContent(id int, name, url *string, width int, height int) error {
 	return UpdateContent(id, name, url, width, height)
@@ -194,16 +496,51 @@ func (s *pgStore) UpdateContent(id int, name, url *string, width int, height int
 func (s *pgStore) DeleteContent(id int) error {
 	return DeleteContent(id)
 }
+func (s *pgStore) IsContentFileInUse(organizationID int, sha256, url string) (bool, error) {
+	return IsContentFileInUse(organizationID, sha256, url)
+}
+func (s *pgStore) FindContentFileBySHA256(organizationID int, sha256 string) (string, error) {
+	return FindContentFileBySHA256(organizationID, sha256)
+}
+func (s *pgStore) LockContentFile(organizationID int, sha256 string) (func(), error) {
+	return LockContentFile(organizationID, sha256)
+}
+
+// @ Content Uploads
+func (s *pgStore) CreateContentUpload(u model.ContentUpload) (model.ContentUpload, error) {
+	return CreateContentUpload(u)
+}
+func (s *pgStore) GetContentUpload(id string) (model.ContentUpload, error) {
+	return GetContentUpload(id)
+}
+func (s *pgStore) ClaimUploadChunk(id string, offset int64, lease string, staleAfter time.Duration) (model.ContentUpload, error) {
+	return ClaimUploadChunk(id, offset, lease, staleAfter)
+}
+func (s *pgStore) ReleaseUploadChunk(id, lease string) error {
+	return ReleaseUploadChunk(id, lease)
+}
+func (s *pgStore) RecordUploadChunk(id, lease string, received int64, hashState []byte, mimeType *string, expiresAt time.Time) (model.ContentUpload, error) {
+	return RecordUploadChunk(id, lease, received, hashState, mimeType, expiresAt)
+}
+func (s *pgStore) SetContentUploadStatus(id, from, to string) (model.ContentUpload, error) {
+	return SetContentUploadStatus(id, from, to)
+}
+func (s *pgStore) DeleteContentUpload(id string) (model.ContentUpload, error) {
+	return DeleteContentUpload(id)
+}
+func (s *pgStore) DeleteExpiredContentUploads(before time.Time) ([]model.ContentUpload, error) {
+	return DeleteExpiredContentUploads(before)
+}
 
 // @ Playlist
-func (s *pgStore) CreatePlaylist(name, description string, createdBy int) (model.Playlist, error) {
-	return CreatePlaylist(name, description, createdBy)
+func (s *pgStore) CreatePlaylist(name, description string, organizationID, createdBy int) (model.Playlist, error) {
+	return CreatePlaylist(name, description, organizationID, createdBy)
 }
 func (s *pgStore) GetPlaylistByID(id int) (model.Playlist, error) {
 	return GetPlaylistByID(id)
 }
-func (s *pgStore) ListPlaylists() ([]model.Playlist, error) {
-	return ListPlaylists()
+func (s *pgStore) ListPlaylists(organizationID int) ([]model.Playlist, error) {
+	return ListPlaylists(organizationID)
 }
 func (s *pgStore) UpdatePlaylist(id int, name, description *string) error {
 	return UpdatePlaylist(id, name, description)
@@ -216,11 +553,11 @@ func (s *pgStore) DeletePlaylist(id int) error {
 func (s *pgStore) AddItemToPlaylist(playlistID, contentID, position, duration int) (model.PlaylistItem, error) {
 	return AddItemToPlaylist(playlistID, contentID, position, duration)
 }
-func (s *pgStore) UpdatePlaylistItem(itemID int, position, duration *int) error {
-	return UpdatePlaylistItem(itemID, position, duration)
+func (s *pgStore) UpdatePlaylistItem(playlistID, itemID int, position, duration *int) error {
+	return UpdatePlaylistItem(playlistID, itemID, position, duration)
 }
-func (s *pgStore) RemovePlaylistItem(itemID int) error {
-	return RemovePlaylistItem(itemID)
+func (s *pgStore) RemovePlaylistItem(playlistID, itemID int) error {
+	return RemovePlaylistItem(playlistID, itemID)
 }
 func (s *pgStore) ListPlaylistItems(playlistID int) ([]model.PlaylistItem, error) {
 	return ListPlaylistItems(playlistID)
@@ -239,16 +576,21 @@ func (s *pgStore) GetPlaylistForScreen(screenID int) (model.Playlist, error) {
 func (s *pgStore) GetScreensUsingPlaylist(playlistID int) ([]model.Screen, error) {
 	return GetScreensUsingPlaylist(playlistID)
 }
+func (s *pgStore) ListDeviceIDsForPlaylist(playlistID int) ([]string, error) {
+	return ListDeviceIDsForPlaylist(playlistID)
+}
 func (s *pgStore) GetPlaylistContentForScreen(screenID int) (string, []ContentItem, error) {
 	return GetPlaylistContentForScreen(screenID)
 }
 
 // @ Schedules
-func (s *pgStore) CreateSchedule(name string, createdBy int) (model.Schedule, error) {
-	return CreateSchedule(name, createdBy)
+func (s *pgStore) CreateSchedule(name string, organizationID, createdBy int) (model.Schedule, error) {
+	return CreateSchedule(name, organizationID, createdBy)
+}
+func (s *pgStore) DeleteSchedule(scheduleID int) error { return DeleteSchedule(scheduleID) }
+func (s *pgStore) ListSchedules(organizationID int) ([]model.Schedule, error) {
+	return ListSchedules(organizationID)
 }
-func (s *pgStore) DeleteSchedule(scheduleID int) error                 { return DeleteSchedule(scheduleID) }
-func (s *pgStore) ListSchedules(ownerID int) ([]model.Schedule, error) { return ListSchedules(ownerID) }
 func (s *pgStore) GetScheduleByID(scheduleID int) (model.Schedule, error) {
 	return GetSchedule(scheduleID)
 }
@@ -275,33 +617,39 @@ func (s *pgStore) ListScheduleOccurrences(scheduleID int, from, to time.Time) ([
 func (s *pgStore) GetScheduleByWindowID(windowID int) (model.Schedule, error) {
 	return GetScheduleByWindowID(windowID)
 }
-func (s *pgStore) RenameScreenGroup(userID, groupID int, newName, newDescription *string) (model.ScreenGroup, error) {
-	return RenameScreenGroup(userID, groupID, newName, newDescription)
+func (s *pgStore) ListDeviceIDsForSchedule(scheduleID int) ([]string, error) {
+	return ListDeviceIDsForSchedule(scheduleID)
+}
+func (s *pgStore) ListScheduleIDsForScreen(screenID int) ([]int, error) {
+	return ListScheduleIDsForScreen(screenID)
+}
+func (s *pgStore) RenameScreenGroup(organizationID, groupID int, newName, newDescription *string) (model.ScreenGroup, error) {
+	return RenameScreenGroup(organizationID, groupID, newName, newDescription)
 }
 
-func (s *pgStore) CreateScreenGroup(userID int, name, description *string) (model.ScreenGroup, error) {
-	return CreateScreenGroup(userID, name, description)
+func (s *pgStore) CreateScreenGroup(organizationID, userID int, name, description *string) (model.ScreenGroup, error) {
+	return CreateScreenGroup(organizationID, userID, name, description)
 }
-func (s *pgStore) DeleteScreenGroup(userID, groupID int) error {
-	return DeleteScreenGroup(userID, groupID)
+func (s *pgStore) DeleteScreenGroup(organizationID, groupID int) error {
+	return DeleteScreenGroup(organizationID, groupID)
 }
 func (s *pgStore) GetScreenGroupByID(groupID int) (model.ScreenGroup, error) {
 	return GetScreenGroupByID(groupID)
 }
-func (s *pgStore) ListScreenGroups(userID int) ([]model.ScreenGroup, error) {
-	return ListScreenGroups(userID)
+func (s *pgStore) ListScreenGroups(organizationID int) ([]model.ScreenGroup, error) {
+	return ListScreenGroups(organizationID)
 }
-func (s *pgStore) AddScreenToGroup(userID, groupID, screenID int) error {
-	return AddScreenToGroup(userID, groupID, screenID)
+func (s *pgStore) AddScreenToGroup(organizationID, groupID, screenID int) error {
+	return AddScreenToGroup(organizationID, groupID, screenID)
 }
-func (s *pgStore) RemoveScreenFromGroup(userID, groupID, screenID int) error {
-	return RemoveScreenFromGroup(userID, groupID, screenID)
+func (s *pgStore) RemoveScreenFromGroup(organizationID, groupID, screenID int) error {
+	return RemoveScreenFromGroup(organizationID, groupID, screenID)
 }
-func (s *pgStore) ListScreensInGroup(userID, groupID int) ([]model.Screen, error) {
-	return ListScreensInGroup(userID, groupID)
+func (s *pgStore) ListScreensInGroup(organizationID, groupID int) ([]model.Screen, error) {
+	return ListScreensInGroup(organizationID, groupID)
 }
-func (s *pgStore) ListGroupsForScreen(userID, screenID int) ([]model.ScreenGroup, error) {
-	return ListGroupsForScreen(userID, screenID)
+func (s *pgStore) ListGroupsForScreen(organizationID, screenID int) ([]model.ScreenGroup, error) {
+	return ListGroupsForScreen(organizationID, screenID)
 }
 
 func (s *pgStore) ResolvePlaylistForScreenAt(screenID int, at time.Time) (int, error) {
@@ -310,9 +658,124 @@ func (s *pgStore) ResolvePlaylistForScreenAt(screenID int, at time.Time) (int, e
 func (s *pgStore) GetScreenByDeviceID(deviceID *string) (model.Screen, error) {
 	return GetScreenByDeviceID(deviceID)
 }
+func (s *pgStore) GetScreenByDeviceTokenHash(tokenHash string) (model.Screen, error) {
+	return GetScreenByDeviceTokenHash(tokenHash)
+}
+func (s *pgStore) SetScreenDeviceToken(screenID int, tokenHash *string) error {
+	return SetScreenDeviceToken(screenID, tokenHash)
+}
+func (s *pgStore) BindDeviceToScreen(screenID int, deviceID, tokenHash string, boundBy int) error {
+	return BindDeviceToScreen(screenID, deviceID, tokenHash, boundBy)
+}
+func (s *pgStore) UnbindScreenDevice(screenID, unboundBy int, reason string) error {
+	return UnbindScreenDevice(screenID, unboundBy, reason)
+}
+func (s *pgStore) ListScreenDevices(screenID int) ([]model.ScreenDevice, error) {
+	return ListScreenDevices(screenID)
+}
+func (s *pgStore) RecordScreenHeartbeat(screenID int, hb model.Heartbeat) error {
+	return RecordScreenHeartbeat(screenID, hb)
+}
+func (s *pgStore) MarkScreenSeen(screenID int) error {
+	return MarkScreenSeen(screenID)
+}
 func (s *pgStore) GetEffectivePlaylistForScreen(screenID int, now time.Time) (model.Playlist, []ContentItem, string, error) {
 	return GetEffectivePlaylistForScreen(screenID, now)
 }
 func (s *pgStore) GetPlaylistContentByPlaylistID(playlistID int) (string, []ContentItem, error) {
 	return GetPlaylistContentByPlaylistID(playlistID)
 }
+
+// @ Alerting
+func (s *pgStore) CreateAlertRule(r model.AlertRule) (model.AlertRule, error) {
+	return CreateAlertRule(r)
+}
+func (s *pgStore) UpdateAlertRule(r model.AlertRule) (model.AlertRule, error) {
+	return UpdateAlertRule(r)
+}
+func (s *pgStore) GetAlertRule(organizationID, id int) (model.AlertRule, error) {
+	return GetAlertRule(organizationID, id)
+}
+func (s *pgStore) ListAlertRules(organizationID int) ([]model.AlertRule, error) {
+	return ListAlertRules(organizationID)
+}
+func (s *pgStore) ListEnabledAlertRules() ([]model.AlertRule, error) {
+	return ListEnabledAlertRules()
+}
+func (s *pgStore) DeleteAlertRule(organizationID, id int) error {
+	return DeleteAlertRule(organizationID, id)
+}
+func (s *pgStore) ListAlertRuleScreens(ruleID int) ([]model.Screen, error) {
+	return ListAlertRuleScreens(ruleID)
+}
+func (s *pgStore) ListAlertRuleStates(ruleID int) (map[int]string, error) {
+	return ListAlertRuleStates(ruleID)
+}
+func (s *pgStore) SetAlertState(ruleID, screenID int, state string) (bool, error) {
+	return SetAlertState(ruleID, screenID, state)
+}
+func (s *pgStore) MarkAlertNotified(ruleID, screenID int, state string) error {
+	return MarkAlertNotified(ruleID, screenID, state)
+}
+func (s *pgStore) ClaimAlertRetries(ruleID int, retryAfter time.Duration, maxAttempts int) (map[int]string, error) {
+	return ClaimAlertRetries(ruleID, retryAfter, maxAttempts)
+}
+func (s *pgStore) ListFiringAlerts(organizationID int) ([]model.AlertState, error) {
+	return ListFiringAlerts(organizationID)
+}
+
+// @ Proof-of-play
+func (s *pgStore) RecordPlayEvents(organizationID, screenID int, events []model.PlayEvent) (int, error) {
+	return RecordPlayEvents(organizationID, screenID, events)
+}
+func (s *pgStore) PlayReport(f PlayReportFilter) ([]model.PlayStat, error) {
+	return PlayReport(f)
+}
+
+// @ Device commands
+func (s *pgStore) CreateDeviceCommand(c model.DeviceCommand) (model.DeviceCommand, error) {
+	return CreateDeviceCommand(c)
+}
+func (s *pgStore) MarkDeviceCommandSent(id string) error {
+	return MarkDeviceCommandSent(id)
+}
+func (s *pgStore) AckDeviceCommand(screenID int, id, status string, message *string, result json.RawMessage) (model.DeviceCommand, error) {
+	return AckDeviceCommand(screenID, id, status, message, result)
+}
+func (s *pgStore) ListDeviceCommands(screenID, limit int) ([]model.DeviceCommand, error) {
+	return ListDeviceCommands(screenID, limit)
+}
+func (s *pgStore) ListPendingDeviceCommands(screenID int) ([]model.DeviceCommand, error) {
+	return ListPendingDeviceCommands(screenID)
+}
+
+// @ Screenshots
+func (s *pgStore) CreateScreenshot(shot model.Screenshot) (model.Screenshot, error) {
+	return CreateScreenshot(shot)
+}
+func (s *pgStore) ListScreenshots(screenID int) ([]model.Screenshot, error) {
+	return ListScreenshots(screenID)
+}
+func (s *pgStore) GetScreenshot(screenID, id int) (model.Screenshot, error) {
+	return GetScreenshot(screenID, id)
+}
+func (s *pgStore) DeleteScreenshot(screenID, id int) (model.Screenshot, error) {
+	return DeleteScreenshot(screenID, id)
+}
+func (s *pgStore) PruneScreenshots(screenID, keep int, olderThan time.Time) ([]model.Screenshot, error) {
+	return PruneScreenshots(screenID, keep, olderThan)
+}
+
+// @ Telemetry
+func (s *pgStore) RecordScreenTelemetry(screenID int, ip *string, t model.Telemetry) error {
+	return RecordScreenTelemetry(screenID, ip, t)
+}
+func (s *pgStore) GetLatestScreenTelemetry(screenID int) (model.TelemetrySample, error) {
+	return GetLatestScreenTelemetry(screenID)
+}
+func (s *pgStore) ListLatestTelemetry(organizationID int, since time.Time) ([]model.TelemetrySample, error) {
+	return ListLatestTelemetry(organizationID, since)
+}
+func (s *pgStore) ScreenTelemetryHistory(screenID int, from, to time.Time, bucket time.Duration) ([]model.TelemetryBucket, error) {
+	return ScreenTelemetryHistory(screenID, from, to, bucket)
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+const telemetryColumns = `screen_id, recorded_at, cpu_percent, memory_used_bytes, memory_total_bytes,
+	temperature_celsius, storage_used_bytes, storage_total_bytes, network_type, signal_dbm,
+	rx_bytes_per_second, tx_bytes_per_second, ip_address`
+
+// RecordScreenTelemetry appends a telemetry sample, reported from ip, to the screen's history.
+func RecordScreenTelemetry(screenID int, ip *string, t model.Telemetry) error {
+	_, err := DB.Exec(`
+		INSERT INTO screen_telemetry (screen_id, recorded_at, cpu_percent, memory_used_bytes, memory_total_bytes,
+		                              temperature_celsius, storage_used_bytes, storage_total_bytes, network_type,
+		                              signal_dbm, rx_bytes_per_second, tx_bytes_per_second, ip_address)
+		VALUES ($1, now(), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+	`, screenID, t.CPUPercent, t.MemoryUsedBytes, t.MemoryTotalBytes, t.TemperatureCelsius,
+		t.StorageUsedBytes, t.StorageTotalBytes, t.NetworkType, t.SignalDBM, t.RxBytesPerSecond, t.TxBytesPerSecond, ip)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to record screen telemetry")
+	}
+	return err
+}
+
+// GetLatestScreenTelemetry returns the screen's most recent sample, or sql.ErrNoRows if it
+// has never reported any.
+func GetLatestScreenTelemetry(screenID int) (model.TelemetrySample, error) {
+	var out model.TelemetrySample
+	err := DB.Get(&out, `
+		SELECT `+telemetryColumns+`
+		  FROM screen_telemetry
+		 WHERE screen_id = $1
+		 ORDER BY recorded_at DESC
+		 LIMIT 1;
+	`, screenID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to get latest screen telemetry")
+	}
+	return out, err
+}
+
+// ListLatestTelemetry returns the most recent sample of every screen in the organization
+// that has reported since the given time.
+func ListLatestTelemetry(organizationID int, since time.Time) ([]model.TelemetrySample, error) {
+	var out []model.TelemetrySample
+	err := DB.Select(&out, `
+		SELECT DISTINCT ON (t.screen_id) t.screen_id, t.recorded_at, t.cpu_percent, t.memory_used_bytes,
+		       t.memory_total_bytes, t.temperature_celsius, t.storage_used_bytes, t.storage_total_bytes,
+		       t.network_type, t.signal_dbm, t.rx_bytes_per_second, t.tx_bytes_per_second, t.ip_address
+		  FROM screen_telemetry t
+		  JOIN screens s ON s.id = t.screen_id
+		 WHERE s.organization_id = $1
+		   AND t.recorded_at >= $2
+		 ORDER BY t.screen_id, t.recorded_at DESC;
+	`, organizationID, since)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", organizationID).Msg("failed to list latest telemetry")
+	}
+	return out, err
+}
+
+// ScreenTelemetryHistory aggregates the screen's samples in [from, to) into buckets of the
+// given width, oldest first. Buckets without samples are omitted.
+func ScreenTelemetryHistory(screenID int, from, to time.Time, bucket time.Duration) ([]model.TelemetryBucket, error) {
+	var out []model.TelemetryBucket
+	err := DB.Select(&out, `
+		SELECT to_timestamp(floor(extract(epoch FROM recorded_at) / $4::float8) * $4::float8) AS bucket_start,
+		       COUNT(*)                          AS samples,
+		       AVG(cpu_percent)                  AS cpu_percent,
+		       AVG(memory_used_bytes)::float8    AS memory_used_bytes,
+		       MAX(memory_total_bytes)::float8   AS memory_total_bytes,
+		       AVG(temperature_celsius)          AS temperature_celsius,
+		       AVG(storage_used_bytes)::float8   AS storage_used_bytes,
+		       MAX(storage_total_bytes)::float8  AS storage_total_bytes,
+		       AVG(signal_dbm)::float8           AS signal_dbm,
+		       AVG(rx_bytes_per_second)::float8  AS rx_bytes_per_second,
+		       AVG(tx_bytes_per_second)::float8  AS tx_bytes_per_second
+		  FROM screen_telemetry
+		 WHERE screen_id = $1
+		   AND recorded_at >= $2 AND recorded_at < $3
+		 GROUP BY bucket_start
+		 ORDER BY bucket_start;
+	`, screenID, from, to, int64(bucket/time.Second))
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to aggregate screen telemetry")
+	}
+	return out, err
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+// SetPendingTOTPSecret stores a freshly enrolled secret; it is not trusted until EnableTOTP.
+func SetPendingTOTPSecret(userID int, secret string) error {
+	_, err := DB.Exec(`
+		UPDATE users
+		   SET totp_secret = $2, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = now()
+		 WHERE id = $1;
+	`, userID, secret)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to store pending totp secret")
+	}
+	return err
+}
+
+func EnableTOTP(userID int) error {
+	_, err := DB.Exec(`
+		UPDATE users
+		   SET totp_enabled_at = now(), updated_at = now()
+		 WHERE id = $1 AND totp_secret IS NOT NULL;
+	`, userID)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to enable totp")
+	}
+	return err
+}
+
+// DisableTOTP clears the secret and every recovery code.
+func DisableTOTP(userID int) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`
+		UPDATE users
+		   SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = now()
+		 WHERE id = $1;
+	`, userID); err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to disable totp")
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1;`, userID); err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to delete recovery codes")
+		return err
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+// MarkTOTPStepUsed records the step of an accepted code. It returns false if that step,
+// or a later one, was already used, so a code cannot be replayed within its window.
+func MarkTOTPStepUsed(userID int, step int64) (bool, error) {
+	res, err := DB.Exec(`
+		UPDATE users
+		   SET totp_last_step = $2
+		 WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2);
+	`, userID, step)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to record totp step")
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// ReplaceRecoveryCodes invalidates the user's previous recovery codes and stores new hashes.
+func ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1;`, userID); err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to delete recovery codes")
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err = tx.Exec(`
+			INSERT INTO user_recovery_codes (user_id, code_hash, created_at)
+			VALUES ($1, $2, now());
+		`, userID, hash); err != nil {
+			log.Error().Err(err).Int("user_id", userID).Msg("failed to store recovery code")
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+// ConsumeRecoveryCode spends an unused recovery code, returning false if none matched.
+func ConsumeRecoveryCode(userID int, codeHash string) (bool, error) {
+	res, err := DB.Exec(`
+		UPDATE user_recovery_codes
+		   SET used_at = now()
+		 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+	`, userID, codeHash)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to consume recovery code")
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+func CountUnusedRecoveryCodes(userID int) (int, error) {
+	var n int
+	err := DB.Get(&n, `
+		SELECT COUNT(*) FROM user_recovery_codes
+		 WHERE user_id = $1 AND used_at IS NULL;
+	`, userID)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to count recovery codes")
+	}
+	return n, err
+}
+
+// UserRequiresTwoFactor reports whether any organization the user belongs to requires 2FA.
+func UserRequiresTwoFactor(userID int) (bool, error) {
+	var required bool
+	err := DB.Get(&required, `
+		SELECT EXISTS (
+			SELECT 1
+			  FROM organization_members m
+			  JOIN organizations o ON o.id = m.organization_id
+			 WHERE m.user_id = $1 AND o.require_two_factor
+		);
+	`, userID)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to check two-factor requirement")
+	}
+	return required, err
+}
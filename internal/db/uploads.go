@@ -0,0 +1,139 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+const uploadColumns = `id, organization_id, created_by, name, type, width, height, filename, size_bytes,
+	received_bytes, parts, mime_type, hash_state, storage_key, storage_upload_id, status, created_at,
+	updated_at, expires_at`
+
+// CreateContentUpload records a chunked upload that has been started in storage.
+func CreateContentUpload(u model.ContentUpload) (model.ContentUpload, error) {
+	var out model.ContentUpload
+	err := DB.Get(&out, `
+		INSERT INTO content_uploads (id, organization_id, created_by, name, type, width, height, filename,
+		                             size_bytes, mime_type, storage_key, storage_upload_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING `+uploadColumns+`;
+	`, u.ID, u.OrganizationID, u.CreatedBy, u.Name, u.Type, u.Width, u.Height, u.Filename,
+		u.SizeBytes, u.MimeType, u.StorageKey, u.StorageUploadID, u.ExpiresAt)
+	if err != nil {
+		log.Error().Err(err).Int("organization_id", u.OrganizationID).Msg("failed to create content upload")
+	}
+	return out, err
+}
+
+// GetContentUpload returns the upload, or sql.ErrNoRows if there is none with that ID.
+func GetContentUpload(id string) (model.ContentUpload, error) {
+	var out model.ContentUpload
+	err := DB.Get(&out, `SELECT `+uploadColumns+` FROM content_uploads WHERE id = $1;`, id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Str("upload_id", id).Msg("failed to get content upload")
+	}
+	return out, err
+}
+
+// ClaimUploadChunk reserves the upload's next chunk at offset for the holder of lease, so
+// only one request writes it. It returns sql.ErrNoRows if the upload is no longer at that
+// offset or not accepting chunks, which is how a concurrent write of the same chunk loses.
+// A claim left behind by a request that died is taken over once staleAfter has passed.
+func ClaimUploadChunk(id string, offset int64, lease string, staleAfter time.Duration) (model.ContentUpload, error) {
+	var out model.ContentUpload
+	err := DB.Get(&out, `
+		UPDATE content_uploads
+		   SET status = 'writing', write_lease = $3, updated_at = now()
+		 WHERE id = $1 AND received_bytes = $2
+		   AND (status = 'uploading' OR (status = 'writing' AND updated_at < now() - make_interval(secs => $4)))
+		RETURNING `+uploadColumns+`;
+	`, id, offset, lease, staleAfter.Seconds())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Str("upload_id", id).Msg("failed to claim upload chunk")
+	}
+	return out, err
+}
+
+// ReleaseUploadChunk gives up a claim whose chunk could not be written.
+func ReleaseUploadChunk(id, lease string) error {
+	_, err := DB.Exec(`
+		UPDATE content_uploads
+		   SET status = 'uploading', write_lease = NULL, updated_at = now()
+		 WHERE id = $1 AND status = 'writing' AND write_lease = $2;
+	`, id, lease)
+	if err != nil {
+		log.Error().Err(err).Str("upload_id", id).Msg("failed to release upload chunk")
+	}
+	return err
+}
+
+// RecordUploadChunk counts the chunk claimed with lease, saving the running hash, pushing
+// the expiry out and accepting the next chunk. It returns sql.ErrNoRows if the claim was
+// taken over in the meantime.
+func RecordUploadChunk(id, lease string, received int64, hashState []byte, mimeType *string, expiresAt time.Time) (model.ContentUpload, error) {
+	var out model.ContentUpload
+	err := DB.Get(&out, `
+		UPDATE content_uploads
+		   SET received_bytes = $3,
+		       parts          = parts + 1,
+		       hash_state     = $4,
+		       mime_type      = COALESCE($5, mime_type),
+		       expires_at     = $6,
+		       status         = 'uploading',
+		       write_lease    = NULL,
+		       updated_at     = now()
+		 WHERE id = $1 AND status = 'writing' AND write_lease = $2
+		RETURNING `+uploadColumns+`;
+	`, id, lease, received, hashState, mimeType, expiresAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Str("upload_id", id).Msg("failed to record upload chunk")
+	}
+	return out, err
+}
+
+// SetContentUploadStatus moves the upload from one status to another and returns it, or
+// sql.ErrNoRows if it is not in the from status.
+func SetContentUploadStatus(id, from, to string) (model.ContentUpload, error) {
+	var out model.ContentUpload
+	err := DB.Get(&out, `
+		UPDATE content_uploads
+		   SET status = $3, updated_at = now()
+		 WHERE id = $1 AND status = $2
+		RETURNING `+uploadColumns+`;
+	`, id, from, to)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Str("upload_id", id).Msg("failed to set content upload status")
+	}
+	return out, err
+}
+
+// DeleteContentUpload removes the upload and returns it, or sql.ErrNoRows if it is gone.
+func DeleteContentUpload(id string) (model.ContentUpload, error) {
+	var out model.ContentUpload
+	err := DB.Get(&out, `DELETE FROM content_uploads WHERE id = $1 RETURNING `+uploadColumns+`;`, id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Str("upload_id", id).Msg("failed to delete content upload")
+	}
+	return out, err
+}
+
+// DeleteExpiredContentUploads removes the uploads abandoned before the given time and returns
+// them, so their partial files can be discarded.
+func DeleteExpiredContentUploads(before time.Time) ([]model.ContentUpload, error) {
+	var out []model.ContentUpload
+	err := DB.Select(&out, `
+		DELETE FROM content_uploads
+		 WHERE expires_at < $1
+		RETURNING `+uploadColumns+`;
+	`, before)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to delete expired content uploads")
+	}
+	return out, err
+}
@@ -0,0 +1,148 @@
+package endpoints
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/auth/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+func mapAPIKey(k model.APIKey) packets.APIKeyResponse {
+	scopes := make([]string, 0, len(k.Scopes))
+	for _, s := range k.Scopes {
+		scopes = append(scopes, string(s))
+	}
+	return packets.APIKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     scopes,
+		CreatedBy:  k.CreatedBy,
+		ExpiresAt:  formatOptionalTime(k.ExpiresAt),
+		LastUsedAt: formatOptionalTime(k.LastUsedAt),
+		RevokedAt:  formatOptionalTime(k.RevokedAt),
+		CreatedAt:  k.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.RFC3339)
+	return &s
+}
+
+// POST /api/admin/auth/api_keys
+func (a *AccountManager) createAPIKey(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	if apiErr := requireInteractiveSession(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+	membership, ok := middleware.GetCurrentMembership(ctx)
+	if !ok {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+
+	var request packets.CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	scopes := make([]model.Permission, 0, len(request.Scopes))
+	for _, raw := range request.Scopes {
+		scope := model.Permission(raw)
+		if !scope.IsValid() {
+			return nil, &api.APIError{Code: http.StatusBadRequest, Message: "unknown scope: " + raw}
+		}
+		// a key can never do more than the person who created it
+		if !membership.Can(scope) {
+			return nil, &api.APIError{Code: http.StatusForbidden, Message: "your role does not grant scope: " + raw}
+		}
+		scopes = append(scopes, scope)
+	}
+
+	var expiresAt *time.Time
+	if request.ExpiresInDays != nil {
+		t := time.Now().AddDate(0, 0, *request.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	key, prefix, hash, err := middleware.GenerateAPIKey()
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate api key"}
+	}
+
+	created, err := a.store.CreateAPIKey(membership.OrganizationID, user.ID, request.Name, prefix, hash, scopes, expiresAt)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not create api key"}
+	}
+
+	// the plaintext key is only ever returned here
+	return packets.CreatedAPIKeyResponse{
+		APIKeyResponse: mapAPIKey(created),
+		Key:            key,
+	}, nil
+}
+
+// GET /api/admin/auth/api_keys
+func (a *AccountManager) listAPIKeys(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	if apiErr := requireInteractiveSession(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+	membership, ok := middleware.GetCurrentMembership(ctx)
+	if !ok {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+
+	keys, err := a.store.ListAPIKeys(membership.OrganizationID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list api keys"}
+	}
+
+	// members see their own keys; those who manage the organization see all of them
+	seeAll := membership.Can(model.PermMembersManage)
+	out := make([]packets.APIKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		if seeAll || k.CreatedBy == user.ID {
+			out = append(out, mapAPIKey(k))
+		}
+	}
+	return out, nil
+}
+
+// DELETE /api/admin/auth/api_keys/:id
+func (a *AccountManager) revokeAPIKey(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	if apiErr := requireInteractiveSession(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+	membership, ok := middleware.GetCurrentMembership(ctx)
+	if !ok {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+
+	key, err := a.store.GetAPIKeyByID(id)
+	if err != nil || key.OrganizationID != membership.OrganizationID {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "api key not found"}
+	}
+	if key.CreatedBy != user.ID && !membership.Can(model.PermMembersManage) {
+		log.Warn().Int("api_key_id", id).Int("user_id", user.ID).Msg("unauthorized api key revocation attempt")
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+
+	if err := a.store.RevokeAPIKey(id); err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "api key not found or already revoked"}
+	}
+	return gin.H{"revoked": true}, nil
+}
@@ -5,44 +5,88 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 
 	"github.com/Nixie-Tech-LLC/medusa/internal/db"
 	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
 	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/auth/packets"
 	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/mailer"
 	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+	"github.com/Nixie-Tech-LLC/medusa/internal/oidc"
 )
 
-// AuthPublicModule mounts public auth endpoints (/auth/signup, /auth/login)
-func AuthPublicModule(jwtSecret string, store db.Store) api.Module {
-	ctl := newAccountManager(jwtSecret, store)
+// AuthPublicModule mounts public auth endpoints (/auth/signup, /auth/login, /auth/refresh, password reset,
+// invitations, SSO). sso may be nil when no identity provider is configured. With openSignup off, new
+// accounts can only be created by accepting an invitation.
+func AuthPublicModule(jwtSecret string, store db.Store, mail mailer.Mailer, publicURL string, sso *oidc.Provider, openSignup bool) api.Module {
+	ctl := newAccountManager(jwtSecret, store, mail, publicURL, sso)
+	ctl.openSignup = openSignup
 	return api.ModuleFunc(func(c *api.Controller) {
 		c.PUBLIC_POST("/auth/signup", 	ctl.userSignup)
 		c.PUBLIC_POST("/auth/login", 	ctl.userLogin)
+		c.PUBLIC_POST("/auth/login/2fa", 	ctl.completeTwoFactorLogin)
+		c.PUBLIC_POST("/auth/refresh", 	ctl.refreshSession)
+
+		c.PUBLIC_POST("/auth/password/reset", 			ctl.requestPasswordReset)
+		c.PUBLIC_POST("/auth/password/reset/confirm", 	ctl.confirmPasswordReset)
+
+		c.PUBLIC_POST("/auth/invitations/accept", 	ctl.acceptInvitation)
+
+		c.PUBLIC_GET("/auth/oidc/authorize", 	ctl.ssoAuthorize)
+		c.PUBLIC_POST("/auth/oidc/callback", 	ctl.ssoCallback)
 	})
 }
 
 // AuthSessionModule mounts private session/profile endpoints (JWT required)
-func AuthSessionModule(jwtSecret string, store db.Store) api.Module {
-	ctl := newAccountManager(jwtSecret, store)
+func AuthSessionModule(jwtSecret string, store db.Store, mail mailer.Mailer, publicURL string) api.Module {
+	ctl := newAccountManager(jwtSecret, store, mail, publicURL, nil)
 	return api.ModuleFunc(func(c *api.Controller) {
 		c.GET("/auth/current_profile", ctl.getCurrentProfile)
 		c.PUT("/auth/current_profile", ctl.updateCurrentProfile)
+		c.PUT("/auth/password", ctl.changePassword)
+		c.POST("/auth/logout", ctl.logout)
+		c.POST("/auth/logout_all", ctl.logoutAll)
+
+		// two-factor authentication
+		c.GET("/auth/2fa", ctl.getTwoFactorStatus)
+		c.POST("/auth/2fa/enroll", ctl.enrollTwoFactor)
+		c.POST("/auth/2fa/enable", ctl.enableTwoFactor)
+		c.POST("/auth/2fa/disable", ctl.disableTwoFactor)
+		c.POST("/auth/2fa/recovery_codes", ctl.regenerateRecoveryCodes)
+
+		// api keys for machine clients
+		c.GET("/auth/api_keys", ctl.listAPIKeys)
+		c.POST("/auth/api_keys", ctl.createAPIKey)
+		c.DELETE("/auth/api_keys/:id", ctl.revokeAPIKey)
 	})
 }
 
 type AccountManager struct {
 	jwtSecret string
 	store     db.Store
+	mailer    mailer.Mailer
+	publicURL string
+	sso       *oidc.Provider
+
+	// when false only invited users may create accounts
+	openSignup bool
 }
 
-func newAccountManager(secret string, store db.Store) *AccountManager {
-	return &AccountManager{jwtSecret: secret, store: store}
+func newAccountManager(secret string, store db.Store, mail mailer.Mailer, publicURL string, sso *oidc.Provider) *AccountManager {
+	return &AccountManager{jwtSecret: secret, store: store, mailer: mail, publicURL: publicURL, sso: sso}
 }
 
 // POST /api/admin/auth/signup
 func (a *AccountManager) userSignup(ctx *gin.Context) (any, *api.APIError) {
+	if !a.openSignup {
+		return nil, signupDisabledError()
+	}
+	if apiErr := a.throttleIP(ctx, "signup", signupAttemptsPerIP, signupIPWindow); apiErr != nil {
+		return nil, apiErr
+	}
+
 	var request packets.SignupRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
@@ -58,17 +102,13 @@ func (a *AccountManager) userSignup(ctx *gin.Context) (any, *api.APIError) {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not hash password"}
 	}
 
-	userID, err := a.store.CreateUser(request.Email, hashed, request.Name)
+	// every account starts with a personal organization it can later share
+	userID, err := a.store.CreateUserWithOrganization(request.Email, hashed, request.Name, personalOrganizationName(request.Email, request.Name))
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not create user"}
 	}
 
-	token, err := middleware.GenerateJWT(userID, a.jwtSecret)
-	if err != nil {
-		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate token"}
-	}
-
-	return gin.H{"token": token}, nil
+	return a.issueSession(ctx, userID)
 }
 
 // POST /api/admin/auth/login
@@ -78,17 +118,162 @@ func (a *AccountManager) userLogin(ctx *gin.Context) (any, *api.APIError) {
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
 	}
 
+	if apiErr := a.throttleIP(ctx, "login", loginAttemptsPerIP, loginIPWindow); apiErr != nil {
+		return nil, apiErr
+	}
+	email := normalizeEmail(request.Email)
+	if apiErr := a.checkLoginAllowed(ctx, email); apiErr != nil {
+		return nil, apiErr
+	}
+
 	foundUser, err := a.store.GetUserByEmail(request.Email)
 	if err != nil || foundUser == nil || !middleware.CheckPassword(foundUser.HashedPassword, request.Password) {
+		a.recordLoginFailure(ctx, email, foundUser)
 		return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "invalid credentials"}
 	}
+	a.clearLoginFailures(ctx, email)
+
+	if foundUser.TwoFactorEnabled() {
+		return a.startTwoFactorChallenge(ctx, foundUser.ID)
+	}
+
+	return a.issueSession(ctx, foundUser.ID)
+}
 
-	token, err := middleware.GenerateJWT(foundUser.ID, a.jwtSecret)
+// POST /api/admin/auth/refresh
+func (a *AccountManager) refreshSession(ctx *gin.Context) (any, *api.APIError) {
+	var request packets.RefreshRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	current, err := a.store.GetRefreshTokenByHash(middleware.HashToken(request.RefreshToken))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "invalid refresh token"}
+	}
+
+	// a rotated token being presented again means it leaked; end the whole session
+	if current.RevokedAt != nil {
+		log.Warn().Int("user_id", current.UserID).Str("family_id", current.FamilyID).
+			Msg("revoked refresh token reused, revoking session")
+		_ = a.store.RevokeRefreshTokenFamily(current.FamilyID)
+		return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "invalid refresh token"}
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "refresh token expired"}
+	}
+
+	refreshToken, refreshHash, err := middleware.GenerateSecretToken()
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate token"}
+	}
+	userAgent, ip := clientMeta(ctx)
+	if err := a.store.RotateRefreshToken(current.ID, refreshHash, time.Now().Add(middleware.RefreshTokenTTL), userAgent, ip); err != nil {
+		return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "invalid refresh token"}
+	}
+
+	token, err := middleware.GenerateJWT(current.UserID, current.FamilyID, a.jwtSecret)
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate token"}
 	}
 
-	return gin.H{"token": token}, nil
+	return packets.SessionResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(middleware.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// POST /api/admin/auth/logout
+func (a *AccountManager) logout(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	claims, ok := middleware.GetCurrentToken(ctx)
+	if !ok {
+		return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "unauthorized"}
+	}
+
+	if claims.SessionID != "" {
+		if err := a.store.RevokeRefreshTokenFamily(claims.SessionID); err != nil {
+			return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not end session"}
+		}
+	}
+	if err := middleware.RevokeAccessToken(ctx, claims); err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not revoke token"}
+	}
+
+	return gin.H{"logged_out": true}, nil
+}
+
+// POST /api/admin/auth/logout_all
+func (a *AccountManager) logoutAll(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	if apiErr := requireInteractiveSession(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := a.revokeAllSessions(ctx, user.ID); apiErr != nil {
+		return nil, apiErr
+	}
+	return gin.H{"logged_out": true}, nil
+}
+
+// issueSession starts a new refresh token family for the user and returns its first token pair.
+func (a *AccountManager) issueSession(ctx *gin.Context, userID int) (any, *api.APIError) {
+	familyID := uuid.NewString()
+
+	refreshToken, refreshHash, err := middleware.GenerateSecretToken()
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate token"}
+	}
+	userAgent, ip := clientMeta(ctx)
+	if err := a.store.CreateRefreshToken(userID, familyID, refreshHash, time.Now().Add(middleware.RefreshTokenTTL), userAgent, ip); err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not start session"}
+	}
+
+	token, err := middleware.GenerateJWT(userID, familyID, a.jwtSecret)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate token"}
+	}
+
+	return packets.SessionResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(middleware.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// revokeAllSessions ends every refresh token family and invalidates outstanding access tokens.
+func (a *AccountManager) revokeAllSessions(ctx *gin.Context, userID int) *api.APIError {
+	if err := a.store.RevokeAllRefreshTokensForUser(userID); err != nil {
+		return &api.APIError{Code: http.StatusInternalServerError, Message: "could not end sessions"}
+	}
+	if err := middleware.RevokeAllAccessTokens(ctx, userID); err != nil {
+		return &api.APIError{Code: http.StatusInternalServerError, Message: "could not revoke tokens"}
+	}
+	return nil
+}
+
+// requireInteractiveSession keeps account-level operations away from API keys, which act
+// for a user but must not be able to manage that user's credentials.
+func requireInteractiveSession(ctx *gin.Context) *api.APIError {
+	if _, ok := middleware.GetCurrentAPIKey(ctx); ok {
+		return &api.APIError{Code: http.StatusForbidden, Message: "not available to api keys"}
+	}
+	return nil
+}
+
+func clientMeta(ctx *gin.Context) (userAgent, ip *string) {
+	if ua := ctx.Request.UserAgent(); ua != "" {
+		userAgent = &ua
+	}
+	if addr := ctx.ClientIP(); addr != "" {
+		ip = &addr
+	}
+	return userAgent, ip
+}
+
+func personalOrganizationName(email string, name *string) string {
+	if name != nil && *name != "" {
+		return *name + "'s workspace"
+	}
+	return email + "'s workspace"
 }
 
 // GET /api/admin/auth/current_profile
@@ -104,6 +289,11 @@ func (a *AccountManager) getCurrentProfile(ctx *gin.Context, user *model.User) (
 
 // PUT /api/admin/auth/current_profile
 func (a *AccountManager) updateCurrentProfile(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	// a key that could change the email could then take the account over with a password reset
+	if apiErr := requireInteractiveSession(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+
 	var request packets.UpdateCurrentProfileRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
@@ -0,0 +1,99 @@
+package endpoints
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/auth/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+// POST /api/admin/auth/invitations/accept
+// New users sign up with the invited email; existing users confirm with their password.
+// Either way the account joins the inviting organization with the invited role.
+func (a *AccountManager) acceptInvitation(ctx *gin.Context) (any, *api.APIError) {
+	if apiErr := a.throttleIP(ctx, "signup", signupAttemptsPerIP, signupIPWindow); apiErr != nil {
+		return nil, apiErr
+	}
+
+	var request packets.AcceptInvitationRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	invitation, err := a.store.GetInvitationByTokenHash(middleware.HashToken(request.Token))
+	if err != nil || !invitation.IsPending() {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid or expired invitation"}
+	}
+
+	email := normalizeEmail(invitation.Email)
+	user, _ := a.store.GetUserByEmail(invitation.Email)
+	if user != nil {
+		if apiErr := a.checkLoginAllowed(ctx, email); apiErr != nil {
+			return nil, apiErr
+		}
+		if !middleware.CheckPassword(user.HashedPassword, request.Password) {
+			a.recordLoginFailure(ctx, email, user)
+			return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "invalid credentials"}
+		}
+		a.clearLoginFailures(ctx, email)
+		if err := a.store.AcceptInvitation(invitation.ID, user.ID); err != nil {
+			return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid or expired invitation"}
+		}
+	} else {
+		if len(request.Password) < 8 {
+			return nil, &api.APIError{Code: http.StatusBadRequest, Message: "password must be at least 8 characters"}
+		}
+		hashed, err := middleware.HashPassword(request.Password)
+		if err != nil {
+			return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not hash password"}
+		}
+		// invited accounts start in the inviting organization instead of a personal one
+		userID, err := a.store.CreateUserFromInvitation(invitation.Email, hashed, request.Name, invitation.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid or expired invitation"}
+		}
+		if err != nil {
+			return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not create user"}
+		}
+		if user, err = a.store.GetUserByID(userID); err != nil {
+			return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not fetch user"}
+		}
+	}
+	a.auditInvitationAccepted(ctx, invitation, user.ID)
+
+	if user.TwoFactorEnabled() {
+		return a.startTwoFactorChallenge(ctx, user.ID)
+	}
+	return a.issueSession(ctx, user.ID)
+}
+
+func (a *AccountManager) auditInvitationAccepted(ctx *gin.Context, invitation model.Invitation, userID int) {
+	ip := ctx.ClientIP()
+	id := strconv.Itoa(invitation.ID)
+	event := model.AuditEvent{
+		OrganizationID: &invitation.OrganizationID,
+		ActorUserID:    &userID,
+		Action:         "organization.accept_invitation",
+		EntityType:     "invitation",
+		EntityID:       &id,
+		IP:             &ip,
+	}
+
+	log.Info().Int("invitation_id", invitation.ID).Int("user_id", userID).
+		Int("organization_id", invitation.OrganizationID).Str("role", invitation.Role).Msg("invitation accepted")
+	_ = a.store.CreateAuditEvent(event)
+}
+
+// signupDisabledError is returned wherever a new account would otherwise be created
+// while open signup is turned off.
+func signupDisabledError() *api.APIError {
+	return &api.APIError{Code: http.StatusForbidden, Message: "signup is disabled, ask an organization owner for an invitation"}
+}
@@ -0,0 +1,124 @@
+package endpoints
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/auth/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/mailer"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+const passwordResetTTL = time.Hour
+
+// PUT /api/admin/auth/password
+func (a *AccountManager) changePassword(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	if apiErr := requireInteractiveSession(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+	var request packets.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	if !middleware.CheckPassword(user.HashedPassword, request.CurrentPassword) {
+		return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "current password is incorrect"}
+	}
+
+	hashed, err := middleware.HashPassword(request.NewPassword)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not hash password"}
+	}
+	if err := a.store.UpdateUserPassword(user.ID, hashed); err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not update password"}
+	}
+
+	// every other session was opened with the old password; keep only the caller signed in
+	if apiErr := a.revokeAllSessions(ctx, user.ID); apiErr != nil {
+		return nil, apiErr
+	}
+	return a.issueSession(ctx, user.ID)
+}
+
+// POST /api/admin/auth/password/reset
+func (a *AccountManager) requestPasswordReset(ctx *gin.Context) (any, *api.APIError) {
+	var request packets.PasswordResetRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	// the response never reveals whether the email is registered
+	accepted := gin.H{"sent": true}
+
+	user, err := a.store.GetUserByEmail(request.Email)
+	if err != nil || user == nil {
+		log.Info().Str("email", request.Email).Msg("password reset requested for unknown email")
+		return accepted, nil
+	}
+
+	token, hash, err := middleware.GenerateSecretToken()
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate token"}
+	}
+	if err := a.store.CreatePasswordResetToken(user.ID, hash, time.Now().Add(passwordResetTTL)); err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not create reset token"}
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Someone asked to reset the password for this account.\n\n"+
+				"%s\n\n"+
+				"The link expires in %d minutes. If you did not ask for this, you can ignore this email.",
+			a.passwordResetLink(token), int(passwordResetTTL.Minutes()),
+		),
+	}
+	if err := a.mailer.Send(ctx, msg); err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not send reset email"}
+	}
+
+	return accepted, nil
+}
+
+// POST /api/admin/auth/password/reset/confirm
+func (a *AccountManager) confirmPasswordReset(ctx *gin.Context) (any, *api.APIError) {
+	var request packets.ConfirmPasswordResetRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	userID, err := a.store.ConsumePasswordResetToken(middleware.HashToken(request.Token))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid or expired reset token"}
+	}
+
+	hashed, err := middleware.HashPassword(request.NewPassword)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not hash password"}
+	}
+	if err := a.store.UpdateUserPassword(userID, hashed); err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not update password"}
+	}
+
+	if apiErr := a.revokeAllSessions(ctx, userID); apiErr != nil {
+		return nil, apiErr
+	}
+	return gin.H{"reset": true}, nil
+}
+
+// passwordResetLink points at the dashboard's reset page, or is the bare token when
+// no public URL is configured.
+func (a *AccountManager) passwordResetLink(token string) string {
+	if a.publicURL == "" {
+		return "Reset token: " + token
+	}
+	return fmt.Sprintf("%s/reset-password?token=%s", a.publicURL, url.QueryEscape(token))
+}
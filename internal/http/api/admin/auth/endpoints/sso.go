@@ -0,0 +1,157 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/auth/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+	"github.com/Nixie-Tech-LLC/medusa/internal/oidc"
+	"github.com/Nixie-Tech-LLC/medusa/internal/redis"
+)
+
+// how long a user has to finish logging in at the identity provider
+const ssoStateTTL = 10 * time.Minute
+
+func ssoStateKey(state string) string { return "auth:oidc:state:" + state }
+
+// ssoState is kept server-side between authorize and callback.
+type ssoState struct {
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// GET /api/admin/auth/oidc/authorize
+func (a *AccountManager) ssoAuthorize(ctx *gin.Context) (any, *api.APIError) {
+	if a.sso == nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "single sign-on is not configured"}
+	}
+
+	state, _, err := middleware.GenerateSecretToken()
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate state"}
+	}
+	nonce, _, err := middleware.GenerateSecretToken()
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate nonce"}
+	}
+	verifier, _, err := middleware.GenerateSecretToken()
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate verifier"}
+	}
+
+	authURL, err := a.sso.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to build oidc authorization url")
+		return nil, &api.APIError{Code: http.StatusBadGateway, Message: "identity provider unavailable"}
+	}
+
+	payload, _ := json.Marshal(ssoState{Nonce: nonce, CodeVerifier: verifier})
+	if err := redis.Rdb.Set(ctx, ssoStateKey(state), payload, ssoStateTTL).Err(); err != nil {
+		log.Error().Err(err).Msg("failed to store oidc state")
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not start sign-on"}
+	}
+
+	return packets.SSOAuthorizeResponse{AuthorizationURL: authURL}, nil
+}
+
+// POST /api/admin/auth/oidc/callback
+func (a *AccountManager) ssoCallback(ctx *gin.Context) (any, *api.APIError) {
+	if a.sso == nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "single sign-on is not configured"}
+	}
+
+	var request packets.SSOCallbackRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	// state is single-use: it is deleted as it is read
+	raw, err := redis.Rdb.GetDel(ctx, ssoStateKey(request.State)).Bytes()
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "sign-on expired or already used, try again"}
+	}
+	var state ssoState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid sign-on state"}
+	}
+
+	claims, err := a.sso.Exchange(ctx, request.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		log.Warn().Err(err).Msg("oidc code exchange failed")
+		return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "sign-on failed"}
+	}
+
+	user, apiErr := a.userForIdentity(claims)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if user.TwoFactorEnabled() {
+		return a.startTwoFactorChallenge(ctx, user.ID)
+	}
+	return a.issueSession(ctx, user.ID)
+}
+
+// userForIdentity finds the user linked to the identity, links an existing account with
+// the same verified email, or provisions a new account on first login.
+func (a *AccountManager) userForIdentity(claims *oidc.Claims) (*model.User, *api.APIError) {
+	if user, err := a.store.GetUserByIdentity(claims.Issuer, claims.Subject); err == nil && user != nil {
+		_ = a.store.LinkUserIdentity(user.ID, claims.Issuer, claims.Subject, claims.Email)
+		return user, nil
+	}
+
+	if !claims.CanLinkByEmail() {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "identity provider did not supply a verified email"}
+	}
+
+	user, _ := a.store.GetUserByEmail(claims.Email)
+	if user == nil {
+		if !a.openSignup {
+			return nil, signupDisabledError()
+		}
+		var apiErr *api.APIError
+		if user, apiErr = a.provisionSSOUser(claims); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	if err := a.store.LinkUserIdentity(user.ID, claims.Issuer, claims.Subject, claims.Email); err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not link identity"}
+	}
+	log.Info().Int("user_id", user.ID).Str("issuer", claims.Issuer).Msg("linked oidc identity")
+	return user, nil
+}
+
+func (a *AccountManager) provisionSSOUser(claims *oidc.Claims) (*model.User, *api.APIError) {
+	// SSO accounts get a random password nobody knows; a password can be set later via reset
+	unusable, _, err := middleware.GenerateSecretToken()
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not provision user"}
+	}
+	hashed, err := middleware.HashPassword(unusable)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not provision user"}
+	}
+
+	var name *string
+	if claims.Name != "" {
+		name = &claims.Name
+	}
+	userID, err := a.store.CreateUserWithOrganization(claims.Email, hashed, name, personalOrganizationName(claims.Email, name))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not create user"}
+	}
+
+	user, err := a.store.GetUserByID(userID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not fetch user"}
+	}
+	return user, nil
+}
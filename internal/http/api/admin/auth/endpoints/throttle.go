@@ -0,0 +1,126 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+	"github.com/Nixie-Tech-LLC/medusa/internal/redis"
+)
+
+// Brute-force limits. Redis errors never block a request: throttling degrades to off
+// rather than locking everyone out.
+const (
+	loginAttemptsPerIP  = 30
+	loginIPWindow       = 15 * time.Minute
+	signupAttemptsPerIP = 5
+	signupIPWindow      = time.Hour
+
+	loginFailureWindow   = 15 * time.Minute // failures older than this are forgotten
+	loginDelayAfter      = 3                // failures before each retry has to wait
+	loginMaxDelay        = 30 * time.Second
+	loginLockoutAfter    = 10
+	loginLockoutDuration = 15 * time.Minute
+)
+
+func loginFailuresKey(email string) string { return "auth:login:failures:" + email }
+func loginDelayKey(email string) string    { return "auth:login:delay:" + email }
+func loginLockoutKey(email string) string  { return "auth:login:lockout:" + email }
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func tooManyRequests(ctx *gin.Context, retryAfter time.Duration, message string) *api.APIError {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	return &api.APIError{Code: http.StatusTooManyRequests, Message: message}
+}
+
+// throttleIP counts one attempt at action from the client's IP within window.
+func (a *AccountManager) throttleIP(ctx *gin.Context, action string, limit int, window time.Duration) *api.APIError {
+	key := fmt.Sprintf("auth:%s:ip:%s", action, ctx.ClientIP())
+	n, err := redis.IncrWithin(ctx, key, window)
+	if err != nil || n <= int64(limit) {
+		return nil
+	}
+
+	ttl, err := redis.Rdb.TTL(ctx, key).Result()
+	if err != nil || ttl <= 0 {
+		ttl = window
+	}
+	log.Warn().Str("ip", ctx.ClientIP()).Str("action", action).Int64("attempts", n).Msg("rate limit exceeded")
+	return tooManyRequests(ctx, ttl, "too many attempts, try again later")
+}
+
+// checkLoginAllowed rejects attempts against a locked account, or ones made before the
+// progressive delay since the last failure has passed.
+func (a *AccountManager) checkLoginAllowed(ctx *gin.Context, email string) *api.APIError {
+	if ttl, err := redis.Rdb.TTL(ctx, loginLockoutKey(email)).Result(); err == nil && ttl > 0 {
+		return tooManyRequests(ctx, ttl, "account temporarily locked after repeated failed logins")
+	}
+	if ttl, err := redis.Rdb.TTL(ctx, loginDelayKey(email)).Result(); err == nil && ttl > 0 {
+		return tooManyRequests(ctx, ttl, "too many failed logins, wait before retrying")
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login; past loginDelayAfter failures each retry must
+// wait twice as long as the last, and at loginLockoutAfter the account is locked.
+func (a *AccountManager) recordLoginFailure(ctx *gin.Context, email string, user *model.User) {
+	failures, err := redis.IncrWithin(ctx, loginFailuresKey(email), loginFailureWindow)
+	if err != nil {
+		return
+	}
+
+	if failures >= loginLockoutAfter {
+		redis.Set(ctx, loginLockoutKey(email), failures, loginLockoutDuration)
+		redis.Rdb.Del(ctx, loginFailuresKey(email), loginDelayKey(email))
+		a.auditLockout(ctx, email, user, failures)
+		return
+	}
+
+	if failures >= loginDelayAfter {
+		delay := time.Second << (failures - loginDelayAfter)
+		if delay > loginMaxDelay {
+			delay = loginMaxDelay
+		}
+		redis.Set(ctx, loginDelayKey(email), 1, delay)
+	}
+}
+
+func (a *AccountManager) clearLoginFailures(ctx *gin.Context, email string) {
+	redis.Rdb.Del(ctx, loginFailuresKey(email), loginDelayKey(email))
+}
+
+func (a *AccountManager) auditLockout(ctx *gin.Context, email string, user *model.User, failures int64) {
+	ip := ctx.ClientIP()
+	metadata, _ := json.Marshal(gin.H{
+		"email":           email,
+		"failures":        failures,
+		"locked_for_secs": int(loginLockoutDuration.Seconds()),
+		"user_agent":      ctx.Request.UserAgent(),
+	})
+
+	event := model.AuditEvent{
+		Action:     "auth.lockout",
+		EntityType: "user",
+		Metadata:   metadata,
+		IP:         &ip,
+	}
+	if user != nil {
+		id := strconv.Itoa(user.ID)
+		event.EntityID = &id
+	}
+
+	log.Warn().Str("email", email).Str("ip", ip).Int64("failures", failures).Msg("account locked after failed logins")
+	_ = a.store.CreateAuditEvent(event)
+}
@@ -0,0 +1,283 @@
+package endpoints
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/auth/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+	"github.com/Nixie-Tech-LLC/medusa/internal/redis"
+	"github.com/Nixie-Tech-LLC/medusa/internal/totp"
+)
+
+const (
+	totpIssuer = "Medusa"
+
+	// a password-verified login waits this long for its second factor
+	twoFactorChallengeTTL      = 5 * time.Minute
+	twoFactorChallengeAttempts = 5
+
+	recoveryCodeCount = 10
+)
+
+func twoFactorChallengeKey(hash string) string { return "auth:2fa:challenge:" + hash }
+func twoFactorAttemptsKey(hash string) string  { return "auth:2fa:attempts:" + hash }
+
+// startTwoFactorChallenge is returned by login instead of a session when the account has 2FA.
+func (a *AccountManager) startTwoFactorChallenge(ctx *gin.Context, userID int) (any, *api.APIError) {
+	token, hash, err := middleware.GenerateSecretToken()
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate token"}
+	}
+	if err := redis.Rdb.Set(ctx, twoFactorChallengeKey(hash), userID, twoFactorChallengeTTL).Err(); err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to store two-factor challenge")
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not start two-factor challenge"}
+	}
+
+	return packets.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         int(twoFactorChallengeTTL.Seconds()),
+	}, nil
+}
+
+// POST /api/admin/auth/login/2fa
+func (a *AccountManager) completeTwoFactorLogin(ctx *gin.Context) (any, *api.APIError) {
+	var request packets.TwoFactorLoginRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	hash := middleware.HashToken(request.ChallengeToken)
+	raw, err := redis.Rdb.Get(ctx, twoFactorChallengeKey(hash)).Result()
+	if errors.Is(err, goredis.Nil) {
+		return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "two-factor challenge expired, log in again"}
+	}
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not verify challenge"}
+	}
+	userID, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "invalid challenge"}
+	}
+
+	if n, err := redis.IncrWithin(ctx, twoFactorAttemptsKey(hash), twoFactorChallengeTTL); err == nil && n > twoFactorChallengeAttempts {
+		redis.Rdb.Del(ctx, twoFactorChallengeKey(hash), twoFactorAttemptsKey(hash))
+		return nil, &api.APIError{Code: http.StatusTooManyRequests, Message: "too many attempts, log in again"}
+	}
+
+	user, err := a.store.GetUserByID(userID)
+	if err != nil || !user.TwoFactorEnabled() {
+		return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "invalid challenge"}
+	}
+
+	if apiErr := a.verifySecondFactor(user, request.Code, request.RecoveryCode); apiErr != nil {
+		return nil, apiErr
+	}
+
+	redis.Rdb.Del(ctx, twoFactorChallengeKey(hash), twoFactorAttemptsKey(hash))
+	return a.issueSession(ctx, user.ID)
+}
+
+// GET /api/admin/auth/2fa
+func (a *AccountManager) getTwoFactorStatus(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	remaining, err := a.store.CountUnusedRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not count recovery codes"}
+	}
+	required, err := a.store.UserRequiresTwoFactor(user.ID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not check two-factor requirement"}
+	}
+
+	return packets.TwoFactorStatusResponse{
+		Enabled:                user.TwoFactorEnabled(),
+		RequiredByOrganization: required,
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// POST /api/admin/auth/2fa/enroll
+func (a *AccountManager) enrollTwoFactor(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	if apiErr := requireInteractiveSession(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+	if user.TwoFactorEnabled() {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "two-factor authentication is already enabled"}
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate secret"}
+	}
+	if err := a.store.SetPendingTOTPSecret(user.ID, secret); err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not store secret"}
+	}
+
+	return packets.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// POST /api/admin/auth/2fa/enable
+func (a *AccountManager) enableTwoFactor(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	if apiErr := requireInteractiveSession(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+
+	var request packets.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	if user.TwoFactorEnabled() {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "two-factor authentication is already enabled"}
+	}
+	if user.TOTPSecret == nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "enroll before enabling two-factor authentication"}
+	}
+
+	// the first code proves the authenticator app holds the secret
+	if apiErr := a.verifyTOTP(user.ID, *user.TOTPSecret, request.Code); apiErr != nil {
+		return nil, apiErr
+	}
+	if err := a.store.EnableTOTP(user.ID); err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not enable two-factor authentication"}
+	}
+
+	return a.issueRecoveryCodes(user.ID)
+}
+
+// POST /api/admin/auth/2fa/disable
+func (a *AccountManager) disableTwoFactor(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	if apiErr := requireInteractiveSession(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+
+	var request packets.DisableTwoFactorRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	if !user.TwoFactorEnabled() {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "two-factor authentication is not enabled"}
+	}
+	if !middleware.CheckPassword(user.HashedPassword, request.Password) {
+		return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "password is incorrect"}
+	}
+	if apiErr := a.verifySecondFactor(user, request.Code, request.RecoveryCode); apiErr != nil {
+		return nil, apiErr
+	}
+
+	required, err := a.store.UserRequiresTwoFactor(user.ID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not check two-factor requirement"}
+	}
+	if required {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "an organization you belong to requires two-factor authentication"}
+	}
+
+	if err := a.store.DisableTOTP(user.ID); err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not disable two-factor authentication"}
+	}
+	return gin.H{"enabled": false}, nil
+}
+
+// POST /api/admin/auth/2fa/recovery_codes
+func (a *AccountManager) regenerateRecoveryCodes(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	if apiErr := requireInteractiveSession(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+
+	var request packets.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	if !user.TwoFactorEnabled() {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "two-factor authentication is not enabled"}
+	}
+	if apiErr := a.verifyTOTP(user.ID, *user.TOTPSecret, request.Code); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return a.issueRecoveryCodes(user.ID)
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused recovery code.
+func (a *AccountManager) verifySecondFactor(user *model.User, code, recoveryCode string) *api.APIError {
+	switch {
+	case code != "":
+		return a.verifyTOTP(user.ID, *user.TOTPSecret, code)
+	case recoveryCode != "":
+		ok, err := a.store.ConsumeRecoveryCode(user.ID, hashRecoveryCode(recoveryCode))
+		if err != nil {
+			return &api.APIError{Code: http.StatusInternalServerError, Message: "could not verify recovery code"}
+		}
+		if !ok {
+			return &api.APIError{Code: http.StatusUnauthorized, Message: "invalid recovery code"}
+		}
+		return nil
+	default:
+		return &api.APIError{Code: http.StatusBadRequest, Message: "code or recovery_code is required"}
+	}
+}
+
+func (a *AccountManager) verifyTOTP(userID int, secret, code string) *api.APIError {
+	step, ok := totp.Verify(secret, code, time.Now())
+	if !ok {
+		return &api.APIError{Code: http.StatusUnauthorized, Message: "invalid two-factor code"}
+	}
+	fresh, err := a.store.MarkTOTPStepUsed(userID, step)
+	if err != nil {
+		return &api.APIError{Code: http.StatusInternalServerError, Message: "could not verify two-factor code"}
+	}
+	if !fresh {
+		return &api.APIError{Code: http.StatusUnauthorized, Message: "two-factor code already used"}
+	}
+	return nil
+}
+
+// issueRecoveryCodes replaces the user's recovery codes; the plaintext is only shown once.
+func (a *AccountManager) issueRecoveryCodes(userID int) (any, *api.APIError) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate recovery codes"}
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	if err := a.store.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not store recovery codes"}
+	}
+	return packets.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// generateRecoveryCode returns a code like “k3v9q-7xh2m”.
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	s := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:10]
+	return s[:5] + "-" + s[5:], nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes so codes can be typed loosely.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	return middleware.HashToken(normalized)
+}
@@ -0,0 +1,219 @@
+package endpoints
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/alerting"
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+// screens are reported offline after 10 minutes without contact unless the rule says otherwise
+const defaultOfflineAfterSeconds = 600
+
+type AlertController struct {
+	store db.Store
+}
+
+func newAlertController(store db.Store) *AlertController {
+	return &AlertController{store: store}
+}
+
+// AlertModule mounts the /alert-rules endpoints and the list of firing /alerts.
+// Rules are evaluated in the background by the alerting package.
+func AlertModule(store db.Store) api.Module {
+	ctl := newAlertController(store)
+	return api.ModuleFunc(func(c *api.Controller) {
+		c.GET("/alert-rules", ctl.listAlertRules, model.PermScreensRead)
+		c.POST("/alert-rules", ctl.createAlertRule, model.PermScreensWrite)
+		c.PUT("/alert-rules/:id", ctl.updateAlertRule, model.PermScreensWrite)
+		c.DELETE("/alert-rules/:id", ctl.deleteAlertRule, model.PermScreensWrite)
+
+		c.GET("/alerts", ctl.listFiringAlerts, model.PermScreensRead)
+	})
+}
+
+func mapAlertRule(r model.AlertRule) packets.AlertRuleResponse {
+	return packets.AlertRuleResponse{
+		ID:                  r.ID,
+		Name:                r.Name,
+		ScreenID:            r.ScreenID,
+		GroupID:             r.GroupID,
+		OfflineAfterSeconds: r.OfflineAfterSeconds,
+		Channel:             r.Channel,
+		Target:              r.Target,
+		Enabled:             r.Enabled,
+		CreatedBy:           r.CreatedBy,
+		CreatedAt:           r.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:           r.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// alertRuleFromRequest validates the request and builds the rule it describes for the
+// active organization.
+func (a *AlertController) alertRuleFromRequest(ctx *gin.Context) (model.AlertRule, *api.APIError) {
+	var request packets.AlertRuleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return model.AlertRule{}, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	orgID := currentOrganizationID(ctx)
+	rule := model.AlertRule{
+		OrganizationID:      orgID,
+		Name:                strings.TrimSpace(request.Name),
+		ScreenID:            request.ScreenID,
+		GroupID:             request.GroupID,
+		OfflineAfterSeconds: request.OfflineAfterSeconds,
+		Channel:             request.Channel,
+		Target:              strings.TrimSpace(request.Target),
+		Enabled:             request.Enabled == nil || *request.Enabled,
+	}
+	if rule.OfflineAfterSeconds == 0 {
+		rule.OfflineAfterSeconds = defaultOfflineAfterSeconds
+	}
+	if rule.OfflineAfterSeconds < 0 {
+		return rule, &api.APIError{Code: http.StatusBadRequest, Message: "offline_after_seconds must be positive"}
+	}
+
+	switch {
+	case (rule.ScreenID == nil) == (rule.GroupID == nil):
+		return rule, &api.APIError{Code: http.StatusBadRequest, Message: "exactly one of screen_id and group_id is required"}
+	case rule.ScreenID != nil:
+		screen, err := a.store.GetScreenByID(*rule.ScreenID)
+		if err != nil || screen.OrganizationID != orgID {
+			return rule, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
+		}
+	default:
+		group, err := a.store.GetScreenGroupByID(*rule.GroupID)
+		if err != nil || group.OrganizationID != orgID {
+			return rule, &api.APIError{Code: http.StatusNotFound, Message: "group not found"}
+		}
+	}
+
+	switch rule.Channel {
+	case model.AlertChannelWebhook, model.AlertChannelSlack:
+		if err := alerting.ValidateWebhookURL(rule.Target); err != nil {
+			return rule, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+		}
+	case model.AlertChannelEmail:
+		if _, err := mail.ParseAddress(rule.Target); err != nil {
+			return rule, &api.APIError{Code: http.StatusBadRequest, Message: "target must be an email address"}
+		}
+	default:
+		return rule, &api.APIError{Code: http.StatusBadRequest, Message: "channel must be webhook, slack or email"}
+	}
+
+	return rule, nil
+}
+
+// GET /api/admin/alert-rules
+func (a *AlertController) listAlertRules(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	rules, err := a.store.ListAlertRules(currentOrganizationID(ctx))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list alert rules"}
+	}
+
+	out := make([]packets.AlertRuleResponse, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, mapAlertRule(r))
+	}
+	return out, nil
+}
+
+// POST /api/admin/alert-rules
+func (a *AlertController) createAlertRule(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	rule, apiErr := a.alertRuleFromRequest(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	rule.CreatedBy = &user.ID
+
+	created, err := a.store.CreateAlertRule(rule)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not create alert rule"}
+	}
+	recordAudit(ctx, a.store, created.OrganizationID, "alert_rule.create", "alert_rule", created.ID, nil, created)
+
+	return mapAlertRule(created), nil
+}
+
+// PUT /api/admin/alert-rules/:id
+func (a *AlertController) updateAlertRule(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+	before, err := a.store.GetAlertRule(currentOrganizationID(ctx), id)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "alert rule not found"}
+	}
+
+	rule, apiErr := a.alertRuleFromRequest(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	rule.ID = id
+
+	updated, err := a.store.UpdateAlertRule(rule)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "alert rule not found"}
+	}
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not update alert rule"}
+	}
+	recordAudit(ctx, a.store, updated.OrganizationID, "alert_rule.update", "alert_rule", id, before, updated)
+
+	return mapAlertRule(updated), nil
+}
+
+// DELETE /api/admin/alert-rules/:id
+func (a *AlertController) deleteAlertRule(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+	orgID := currentOrganizationID(ctx)
+	before, _ := a.store.GetAlertRule(orgID, id)
+
+	if err := a.store.DeleteAlertRule(orgID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &api.APIError{Code: http.StatusNotFound, Message: "alert rule not found"}
+		}
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not delete alert rule"}
+	}
+	recordAudit(ctx, a.store, orgID, "alert_rule.delete", "alert_rule", id, before, nil)
+
+	return gin.H{"deleted": true}, nil
+}
+
+// GET /api/admin/alerts
+// Lists the alerts currently firing in the organization.
+func (a *AlertController) listFiringAlerts(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	alerts, err := a.store.ListFiringAlerts(currentOrganizationID(ctx))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list alerts"}
+	}
+
+	out := make([]packets.AlertResponse, 0, len(alerts))
+	for _, al := range alerts {
+		out = append(out, packets.AlertResponse{
+			RuleID:     al.RuleID,
+			RuleName:   al.RuleName,
+			ScreenID:   al.ScreenID,
+			ScreenName: al.ScreenName,
+			State:      al.State,
+			FiredAt:    al.FiredAt.Format(time.RFC3339),
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,141 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+type AuditController struct {
+	store db.Store
+}
+
+func newAuditController(store db.Store) *AuditController {
+	return &AuditController{store: store}
+}
+
+// AuditModule mounts the authenticated /audit endpoint.
+func AuditModule(store db.Store) api.Module {
+	ctl := newAuditController(store)
+	return api.ModuleFunc(func(c *api.Controller) {
+		c.GET("/audit", ctl.listAuditEvents, model.PermAuditRead)
+	})
+}
+
+// recordAudit stores who changed what in an organization. before and after are the
+// entity's state around the change and may be nil. A failure to record is logged but
+// never fails the request that made the change.
+func recordAudit(ctx *gin.Context, store db.Store, orgID int, action, entityType string, entityID int, before, after any) {
+	recordAuditKey(ctx, store, orgID, action, entityType, strconv.Itoa(entityID), before, after)
+}
+
+// recordAuditKey is recordAudit for entities keyed by a string, such as uploads.
+func recordAuditKey(ctx *gin.Context, store db.Store, orgID int, action, entityType, id string, before, after any) {
+	ip := ctx.ClientIP()
+	event := model.AuditEvent{
+		OrganizationID: &orgID,
+		Action:         action,
+		EntityType:     entityType,
+		EntityID:       &id,
+		Before:         auditJSON(before),
+		After:          auditJSON(after),
+		IP:             &ip,
+	}
+	if user, ok := middleware.GetCurrentUser(ctx); ok {
+		event.ActorUserID = &user.ID
+	}
+	if key, ok := middleware.GetCurrentAPIKey(ctx); ok {
+		event.ActorAPIKeyID = &key.ID
+	}
+
+	if err := store.CreateAuditEvent(event); err != nil {
+		log.Warn().Err(err).Str("action", action).Str("entity_id", id).Msg("could not record audit event")
+	}
+}
+
+func auditJSON(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		log.Warn().Err(err).Msg("could not marshal audit state")
+		return nil
+	}
+	return raw
+}
+
+func mapAuditEvent(e model.AuditEvent) packets.AuditEventResponse {
+	return packets.AuditEventResponse{
+		ID:            e.ID,
+		ActorUserID:   e.ActorUserID,
+		ActorAPIKeyID: e.ActorAPIKeyID,
+		Action:        e.Action,
+		EntityType:    e.EntityType,
+		EntityID:      e.EntityID,
+		Before:        e.Before,
+		After:         e.After,
+		Metadata:      e.Metadata,
+		IP:            e.IP,
+		CreatedAt:     e.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// GET /api/admin/audit?actor_user_id=&action=&entity_type=&entity_id=&from=&to=&limit=&offset=
+func (a *AuditController) listAuditEvents(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	var query packets.ListAuditEventsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	if query.From != nil && query.To != nil && !query.To.After(*query.From) {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "to must be after from"}
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultAuditPageSize
+	} else if limit > maxAuditPageSize {
+		limit = maxAuditPageSize
+	}
+
+	events, total, err := a.store.ListAuditEvents(db.AuditFilter{
+		OrganizationID: currentOrganizationID(ctx),
+		ActorUserID:    query.ActorUserID,
+		Action:         query.Action,
+		EntityType:     query.EntityType,
+		EntityID:       query.EntityID,
+		From:           query.From,
+		To:             query.To,
+		Limit:          limit,
+		Offset:         query.Offset,
+	})
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list audit events"}
+	}
+
+	out := make([]packets.AuditEventResponse, 0, len(events))
+	for _, e := range events {
+		out = append(out, mapAuditEvent(e))
+	}
+	return packets.AuditEventPageResponse{
+		Events: out,
+		Total:  total,
+		Limit:  limit,
+		Offset: query.Offset,
+	}, nil
+}
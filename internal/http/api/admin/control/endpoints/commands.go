@@ -0,0 +1,227 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+const (
+	// a command the device has not acknowledged by then is reported as expired
+	commandTTL = 15 * time.Minute
+
+	commandHistoryLimit = 50
+)
+
+type CommandController struct {
+	store db.Store
+}
+
+func newCommandController(store db.Store) *CommandController {
+	return &CommandController{store: store}
+}
+
+// CommandModule mounts the endpoints that send remote commands to screens' devices.
+// Devices acknowledge commands through POST /api/tv/commands/:id/ack.
+func CommandModule(store db.Store) api.Module {
+	ctl := newCommandController(store)
+	return api.ModuleFunc(func(c *api.Controller) {
+		c.POST("/screens/:id/commands", ctl.issueScreenCommand, model.PermScreensWrite) // body: {type, params}
+		c.GET("/screens/:id/commands", ctl.listScreenCommands, model.PermScreensRead)
+		c.POST("/screen-groups/:id/commands", ctl.issueGroupCommand, model.PermScreensWrite)
+	})
+}
+
+func mapCommand(c model.DeviceCommand, now time.Time) packets.CommandResponse {
+	resp := packets.CommandResponse{
+		ID:        c.ID,
+		ScreenID:  c.ScreenID,
+		Type:      c.Type,
+		Params:    c.Params,
+		Status:    c.StatusAt(now),
+		Message:   c.Message,
+		Result:    c.Result,
+		IssuedBy:  c.IssuedBy,
+		CreatedAt: c.CreatedAt.Format(time.RFC3339),
+		ExpiresAt: c.ExpiresAt.Format(time.RFC3339),
+	}
+	if c.SentAt != nil {
+		sentAt := c.SentAt.Format(time.RFC3339)
+		resp.SentAt = &sentAt
+	}
+	if c.AckedAt != nil {
+		ackedAt := c.AckedAt.Format(time.RFC3339)
+		resp.AckedAt = &ackedAt
+	}
+	return resp
+}
+
+// validateCommand checks the command type and its params, returning the params to store.
+func validateCommand(request packets.IssueCommandRequest) (json.RawMessage, *api.APIError) {
+	if !slices.Contains(model.CommandTypes, request.Type) {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "unknown command type"}
+	}
+
+	params := bytes.TrimSpace(request.Params)
+	if string(params) == "null" {
+		params = nil
+	}
+	if len(params) > 0 && params[0] != '{' {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "params must be an object"}
+	}
+
+	if request.Type == model.CommandSetVolume {
+		var volume struct {
+			Level *int `json:"level"`
+		}
+		if len(params) == 0 || json.Unmarshal(params, &volume) != nil ||
+			volume.Level == nil || *volume.Level < 0 || *volume.Level > 100 {
+			return nil, &api.APIError{Code: http.StatusBadRequest, Message: "set_volume requires a level between 0 and 100"}
+		}
+	}
+	return json.RawMessage(params), nil
+}
+
+// issueCommand records the command and publishes it to the screen's device. A command that
+// cannot be published stays pending for the device to collect over HTTP.
+func issueCommand(ctx *gin.Context, store db.Store, user *model.User, screen model.Screen, commandType string, params json.RawMessage) (model.DeviceCommand, *api.APIError) {
+	command, err := store.CreateDeviceCommand(model.DeviceCommand{
+		ID:             uuid.NewString(),
+		OrganizationID: screen.OrganizationID,
+		ScreenID:       screen.ID,
+		Type:           commandType,
+		Params:         params,
+		IssuedBy:       &user.ID,
+		ExpiresAt:      time.Now().Add(commandTTL),
+	})
+	if err != nil {
+		return command, &api.APIError{Code: http.StatusInternalServerError, Message: "could not create command"}
+	}
+
+	envelope, _ := json.Marshal(command.Envelope())
+	if err := middleware.PublishCommand(*screen.DeviceID, envelope); err != nil {
+		log.Warn().Err(err).Str("command_id", command.ID).Int("screen_id", screen.ID).
+			Msg("command not published, left for the device to poll")
+	} else if err := store.MarkDeviceCommandSent(command.ID); err == nil {
+		now := time.Now()
+		command.Status = model.CommandSent
+		command.SentAt = &now
+	}
+
+	recordAudit(ctx, store, screen.OrganizationID, "screen.command", "screen", screen.ID, nil,
+		gin.H{"command_id": command.ID, "type": command.Type, "params": command.Params})
+	return command, nil
+}
+
+// POST /api/admin/screens/:id/commands
+func (cc *CommandController) issueScreenCommand(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screenID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+
+	var request packets.IssueCommandRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	params, apiErr := validateCommand(request)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	screen, err := cc.store.GetScreenByID(screenID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
+	}
+	if screen.OrganizationID != currentOrganizationID(ctx) {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+	if !screen.Paired || screen.DeviceID == nil {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "screen is not paired"}
+	}
+
+	command, apiErr := issueCommand(ctx, cc.store, user, screen, request.Type, params)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return mapCommand(command, time.Now()), nil
+}
+
+// GET /api/admin/screens/:id/commands
+// Lists the screen's most recent commands with their acknowledgement status.
+func (cc *CommandController) listScreenCommands(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screenID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+
+	screen, err := cc.store.GetScreenByID(screenID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
+	}
+	if screen.OrganizationID != currentOrganizationID(ctx) {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+
+	commands, err := cc.store.ListDeviceCommands(screenID, commandHistoryLimit)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list commands"}
+	}
+
+	now := time.Now()
+	out := make([]packets.CommandResponse, 0, len(commands))
+	for _, c := range commands {
+		out = append(out, mapCommand(c, now))
+	}
+	return out, nil
+}
+
+// POST /api/admin/screen-groups/:id/commands
+// Issues the command to every paired screen in the group; unpaired screens are skipped.
+func (cc *CommandController) issueGroupCommand(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	groupID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+
+	var request packets.IssueCommandRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	params, apiErr := validateCommand(request)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	screens, err := cc.store.ListScreensInGroup(currentOrganizationID(ctx), groupID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "group not found"}
+	}
+
+	now := time.Now()
+	out := make([]packets.CommandResponse, 0, len(screens))
+	for _, screen := range screens {
+		if !screen.Paired || screen.DeviceID == nil {
+			continue
+		}
+		command, apiErr := issueCommand(ctx, cc.store, user, screen, request.Type, params)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		out = append(out, mapCommand(command, now))
+	}
+	return out, nil
+}
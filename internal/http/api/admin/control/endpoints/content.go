@@ -28,41 +28,52 @@ func newContentController(store db.Store, storage storage.Storage) *ContentContr
 func ContentModule(store db.Store, storage storage.Storage) api.Module {
 	ctl := newContentController(store, storage)
 	return api.ModuleFunc(func(c *api.Controller) {
-		c.GET("/content/:id", 		ctl.getContent)
-		c.GET("/content", 			ctl.listContent)
-		c.POST("/content", 			ctl.createContent)
-		c.PUT("/content/:id", 		ctl.updateContent)
-		c.DELETE("/content/:id", 	ctl.deleteContent)
+		c.GET("/content/:id", 		ctl.getContent, model.PermContentRead)
+		c.GET("/content", 			ctl.listContent, model.PermContentRead)
+		c.POST("/content", 			ctl.createContent, model.PermContentWrite)
+		c.PUT("/content/:id", 		ctl.updateContent, model.PermContentWrite)
+		c.DELETE("/content/:id", 	ctl.deleteContent, model.PermContentWrite)
+
+		// resumable uploads for files too large for one request
+		c.POST("/content/uploads", ctl.beginUpload, model.PermContentWrite)
+		c.GET("/content/uploads/:id", ctl.getUpload, model.PermContentWrite)
+		c.PUT("/content/uploads/:id", ctl.uploadChunk, model.PermContentWrite)
+		c.POST("/content/uploads/:id/complete", ctl.completeUpload, model.PermContentWrite)
+		c.DELETE("/content/uploads/:id", ctl.abortUpload, model.PermContentWrite)
 	})
 }
 
+func mapContent(x model.Content) packets.ContentResponse {
+	return packets.ContentResponse{
+		ID:        x.ID,
+		Name:      x.Name,
+		Type:      x.Type,
+		URL:       x.URL,
+		Width:     x.Width,
+		Height:    x.Height,
+		SHA256:    x.SHA256,
+		SizeBytes: x.SizeBytes,
+		MimeType:  x.MimeType,
+		CreatedAt: x.CreatedAt.Format(time.RFC3339),
+	}
+}
+
 func (c *ContentController) listContent(ctx *gin.Context, user *model.User) (any, *api.APIError) {
 	// Get query parameters - supports multiple values
 	nameFilters := ctx.QueryArray("name")
 	typeFilters := ctx.QueryArray("type")
 
-	userID := user.ID
+	orgID := currentOrganizationID(ctx)
 
 	// Use the new SearchContentMultiple method that handles filtering in the database
-	all, err := c.store.SearchContentMultiple(nameFilters, typeFilters, &userID)
+	all, err := c.store.SearchContentMultiple(nameFilters, typeFilters, &orgID)
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list content"}
 	}
 
 	out := make([]packets.ContentResponse, 0, len(all))
 	for _, x := range all {
-		if x.CreatedBy != user.ID {
-			continue
-		}
-		out = append(out, packets.ContentResponse{
-			ID:        x.ID,
-			Name:      x.Name,
-			Type:      x.Type,
-			URL:       x.URL,
-			Width:     x.Width,
-			Height:    x.Height,
-			CreatedAt: x.CreatedAt.Format(time.RFC3339),
-		})
+		out = append(out, mapContent(x))
 	}
 
 	return out, nil
@@ -80,22 +91,12 @@ func (c *ContentController) getContent(ctx *gin.Context, user *model.User) (any,
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "not found"}
 	}
 
-	if x.CreatedBy != user.ID {
-		log.Warn().Int("owner", x.CreatedBy).Int("user", user.ID).Msg("[content] forbidden getContent")
+	if x.OrganizationID != currentOrganizationID(ctx) {
+		log.Warn().Int("content_org", x.OrganizationID).Int("user", user.ID).Msg("[content] forbidden getContent")
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
-	resp := packets.ContentResponse{
-		ID:        x.ID,
-		Name:      x.Name,
-		Type:      x.Type,
-		URL:       x.URL,
-		Width:     x.Width,
-		Height:    x.Height,
-		CreatedAt: x.CreatedAt.Format(time.RFC3339),
-	}
-
-	return resp, nil
+	return mapContent(x), nil
 }
 
 func (c *ContentController) createContent(ctx *gin.Context, user *model.User) (any, *api.APIError) {
@@ -125,36 +126,69 @@ func (c *ContentController) createContent(ctx *gin.Context, user *model.User) (a
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "file is required"}
 	}
 
-	uploadPath, err := c.storage.SaveFile(fileHeader, fileHeader.Filename)
+	// identical files are stored once per organization
+	uploadPath, asset, err := c.storage.SaveContent(fileHeader, currentOrganizationID(ctx))
 	if err != nil {
 		log.Error().Err(err).Msg("[content] createContent: save failed")
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not save file"}
 	}
 
+	unlock, err := c.store.LockContentFile(currentOrganizationID(ctx), asset.SHA256)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not save file"}
+	}
+	defer unlock()
+	// a shared copy may have been released as unused before the lock was taken
+	if exists, err := c.storage.FileExists(uploadPath); err != nil || !exists {
+		if uploadPath, _, err = c.storage.SaveContent(fileHeader, currentOrganizationID(ctx)); err != nil {
+			log.Error().Err(err).Msg("[content] createContent: save failed")
+			return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not save file"}
+		}
+	}
+
 	content, err := c.store.CreateContent(
 		name,
 		typeVal,
 		uploadPath,
 		width,
 		height,
+		currentOrganizationID(ctx),
 		user.ID,
+		&asset,
 	)
 	if err != nil {
 		log.Error().Err(err).Msg("[content] createContent: db create failed")
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "could not create content"}
 	}
+	recordAudit(ctx, c.store, content.OrganizationID, "content.create", "content", content.ID, nil, content)
 
-	resp := packets.ContentResponse{
-		ID:        content.ID,
-		Name:      content.Name,
-		Type:      content.Type,
-		URL:       content.URL,
-		Width:     content.Width,
-		Height:    content.Height,
-		CreatedAt: content.CreatedAt.Format(time.RFC3339),
+	return mapContent(content), nil
+}
+
+// releaseContentFile deletes the stored upload behind content that no longer points at it,
+// unless other content of the organization still shares the file.
+func (c *ContentController) releaseContentFile(content model.Content) {
+	if content.SHA256 == nil {
+		// not an upload of ours
+		return
+	}
+	unlock, err := c.store.LockContentFile(content.OrganizationID, *content.SHA256)
+	if err != nil {
+		return
 	}
+	defer unlock()
+	c.deleteUnusedContentFile(content)
+}
 
-	return resp, nil
+// deleteUnusedContentFile is releaseContentFile for callers already holding the file's lock.
+func (c *ContentController) deleteUnusedContentFile(content model.Content) {
+	inUse, err := c.store.IsContentFileInUse(content.OrganizationID, *content.SHA256, content.URL)
+	if err != nil || inUse {
+		return
+	}
+	if err := c.storage.DeleteFile(content.URL); err != nil {
+		log.Warn().Err(err).Int("content_id", content.ID).Msg("[content] could not delete content file")
+	}
 }
 
 func (c *ContentController) updateContent(ctx *gin.Context, user *model.User) (any, *api.APIError) {
@@ -169,7 +203,7 @@ func (c *ContentController) updateContent(ctx *gin.Context, user *model.User) (a
 		log.Error().Int("id", contentID).Msg("[content] updateContent: not found")
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "not found"}
 	}
-	if existing.CreatedBy != user.ID {
+	if existing.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -188,6 +222,11 @@ func (c *ContentController) updateContent(ctx *gin.Context, user *model.User) (a
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: err.Error()}
 	}
 
+	updated, _ := c.store.GetContentByID(contentID)
+	if updated.URL != existing.URL {
+		c.releaseContentFile(existing)
+	}
+	recordAudit(ctx, c.store, existing.OrganizationID, "content.update", "content", contentID, existing, updated)
 	return nil, nil
 }
 
@@ -202,13 +241,15 @@ func (c *ContentController) deleteContent(ctx *gin.Context, user *model.User) (a
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "not found"}
 	}
-	if existing.CreatedBy != user.ID {
+	if existing.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
 	if err := c.store.DeleteContent(contentID); err != nil {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: err.Error()}
 	}
+	c.releaseContentFile(existing)
+	recordAudit(ctx, c.store, existing.OrganizationID, "content.delete", "content", contentID, existing, nil)
 
 	return nil, nil
 }
@@ -14,33 +14,38 @@ import (
 )
 
 
-type GroupController struct{ store db.Store }
-func newGroupController(store db.Store) *GroupController { return &GroupController{store: store} }
+type GroupController struct {
+	store    db.Store
+	presence model.PresenceThresholds
+}
+func newGroupController(store db.Store, presence model.PresenceThresholds) *GroupController {
+	return &GroupController{store: store, presence: presence}
+}
 
 // In module registration (e.g., Admin control module)
-func ScreenGroupModule(store db.Store) api.Module {
-	ctl := newGroupController(store)
+func ScreenGroupModule(store db.Store, presence model.PresenceThresholds) api.Module {
+	ctl := newGroupController(store, presence)
 	return api.ModuleFunc(func(c *api.Controller) {
 		// CRUD groups
-		c.GET("/screen-groups",                   ctl.listGroups)
-		c.POST("/screen-groups",                  ctl.createGroup)
-		c.PUT("/screen-groups/:id",               ctl.renameGroup)
-		c.DELETE("/screen-groups/:id",            ctl.deleteGroup)
+		c.GET("/screen-groups",                   ctl.listGroups, model.PermScreensRead)
+		c.POST("/screen-groups",                  ctl.createGroup, model.PermScreensWrite)
+		c.PUT("/screen-groups/:id",               ctl.renameGroup, model.PermScreensWrite)
+		c.DELETE("/screen-groups/:id",            ctl.deleteGroup, model.PermScreensWrite)
 
 		// membership
-		c.GET("/screen-groups/:id/screens",       ctl.listScreensInGroup)
-		c.POST("/screen-groups/:id/screens",      ctl.addScreenToGroup)     // body: {screen_id}
-		c.DELETE("/screen-groups/:id/screens/:sid", ctl.removeScreenFromGroup)
+		c.GET("/screen-groups/:id/screens",       ctl.listScreensInGroup, model.PermScreensRead)
+		c.POST("/screen-groups/:id/screens",      ctl.addScreenToGroup, model.PermScreensWrite)     // body: {screen_id}
+		c.DELETE("/screen-groups/:id/screens/:sid", ctl.removeScreenFromGroup, model.PermScreensWrite)
 
 		// optional: reverse lookup
-		c.GET("/screens/:id/groups",              ctl.listGroupsForScreen)
+		c.GET("/screens/:id/groups",              ctl.listGroupsForScreen, model.PermScreensRead)
 	})
 }
 
 
 // GET /api/admin/screen-groups
 func (g *GroupController) listGroups(ctx *gin.Context, user *model.User) (any, *api.APIError) {
-	groups, err := g.store.ListScreenGroups(user.ID)
+	groups, err := g.store.ListScreenGroups(currentOrganizationID(ctx))
 	if err != nil { return nil, &api.APIError{Code: http.StatusInternalServerError, Message: err.Error()} }
 	out := make([]packets.ScreenGroupResponse, 0, len(groups))
 	for _, gr := range groups {
@@ -60,10 +65,11 @@ func (g *GroupController) createGroup(ctx *gin.Context, user *model.User) (any,
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
 	}
 	name := req.Name
-	grp, err := g.store.CreateScreenGroup(user.ID, &name, req.Description)
+	grp, err := g.store.CreateScreenGroup(currentOrganizationID(ctx), user.ID, &name, req.Description)
 	if err != nil {
-		return nil, &api.APIError{Code: http.StatusConflict, Message: err.Error()} // unique name per user
+		return nil, &api.APIError{Code: http.StatusConflict, Message: err.Error()} // unique name per organization
 	}
+	recordAudit(ctx, g.store, currentOrganizationID(ctx), "screen_group.create", "screen_group", grp.ID, nil, grp)
 	return packets.ScreenGroupResponse{
 		ID: grp.ID, Name: grp.Name, Description: grp.Description,
 		CreatedAt: grp.CreatedAt.Format(time.RFC3339),
@@ -79,8 +85,10 @@ func (g *GroupController) renameGroup(ctx *gin.Context, user *model.User) (any,
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
 	}
-	grp, err := g.store.RenameScreenGroup(user.ID, id, req.Name, req.Description)
+	before, _ := g.store.GetScreenGroupByID(id)
+	grp, err := g.store.RenameScreenGroup(currentOrganizationID(ctx), id, req.Name, req.Description)
 	if err != nil { return nil, &api.APIError{Code: http.StatusNotFound, Message: "group not found"} }
+	recordAudit(ctx, g.store, currentOrganizationID(ctx), "screen_group.update", "screen_group", id, before, grp)
 	return packets.ScreenGroupResponse{
 		ID: grp.ID, Name: grp.Name, Description: grp.Description,
 		CreatedAt: grp.CreatedAt.Format(time.RFC3339),
@@ -92,9 +100,11 @@ func (g *GroupController) renameGroup(ctx *gin.Context, user *model.User) (any,
 func (g *GroupController) deleteGroup(ctx *gin.Context, user *model.User) (any, *api.APIError) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil { return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"} }
-	if err := g.store.DeleteScreenGroup(user.ID, id); err != nil {
+	before, _ := g.store.GetScreenGroupByID(id)
+	if err := g.store.DeleteScreenGroup(currentOrganizationID(ctx), id); err != nil {
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "group not found"}
 	}
+	recordAudit(ctx, g.store, currentOrganizationID(ctx), "screen_group.delete", "screen_group", id, before, nil)
 	return gin.H{"deleted": true}, nil
 }
 
@@ -102,17 +112,12 @@ func (g *GroupController) deleteGroup(ctx *gin.Context, user *model.User) (any,
 func (g *GroupController) listScreensInGroup(ctx *gin.Context, user *model.User) (any, *api.APIError) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil { return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"} }
-	scr, err := g.store.ListScreensInGroup(user.ID, id)
+	scr, err := g.store.ListScreensInGroup(currentOrganizationID(ctx), id)
 	if err != nil { return nil, &api.APIError{Code: http.StatusNotFound, Message: "group not found"} }
+	now := time.Now()
 	resp := make([]packets.ScreenResponse, 0, len(scr))
 	for _, s := range scr {
-		resp = append(resp, packets.ScreenResponse{
-			ID: s.ID, DeviceID: s.DeviceID, ClientInformation: s.ClientInformation,
-			ClientWidth: s.ClientWidth, ClientHeight: s.ClientHeight, Name: s.Name,
-			Location: s.Location, Paired: s.Paired,
-			CreatedAt: s.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: s.UpdatedAt.Format(time.RFC3339),
-		})
+		resp = append(resp, screenResponse(s, g.presence, now))
 	}
 	return resp, nil
 }
@@ -125,9 +130,11 @@ func (g *GroupController) addScreenToGroup(ctx *gin.Context, user *model.User) (
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
 	}
-	if err := g.store.AddScreenToGroup(user.ID, id, req.ScreenID); err != nil {
+	if err := g.store.AddScreenToGroup(currentOrganizationID(ctx), id, req.ScreenID); err != nil {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: err.Error()}
 	}
+	recordAudit(ctx, g.store, currentOrganizationID(ctx), "screen_group.add_screen", "screen_group", id,
+		nil, gin.H{"screen_id": req.ScreenID})
 	return gin.H{"added": true}, nil
 }
 
@@ -137,9 +144,11 @@ func (g *GroupController) removeScreenFromGroup(ctx *gin.Context, user *model.Us
 	if err != nil { return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid group id"} }
 	sid, err := strconv.Atoi(ctx.Param("sid"))
 	if err != nil { return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid screen id"} }
-	if err := g.store.RemoveScreenFromGroup(user.ID, gid, sid); err != nil {
+	if err := g.store.RemoveScreenFromGroup(currentOrganizationID(ctx), gid, sid); err != nil {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: err.Error()}
 	}
+	recordAudit(ctx, g.store, currentOrganizationID(ctx), "screen_group.remove_screen", "screen_group", gid,
+		gin.H{"screen_id": sid}, nil)
 	return gin.H{"removed": true}, nil
 }
 
@@ -147,7 +156,7 @@ func (g *GroupController) removeScreenFromGroup(ctx *gin.Context, user *model.Us
 func (g *GroupController) listGroupsForScreen(ctx *gin.Context, user *model.User) (any, *api.APIError) {
 	sid, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil { return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"} }
-	groups, err := g.store.ListGroupsForScreen(user.ID, sid)
+	groups, err := g.store.ListGroupsForScreen(currentOrganizationID(ctx), sid)
 	if err != nil { return nil, &api.APIError{Code: http.StatusInternalServerError, Message: err.Error()} }
 	out := make([]packets.ScreenGroupResponse, 0, len(groups))
 	for _, gr := range groups {
@@ -0,0 +1,151 @@
+package endpoints
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/mailer"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+type InvitationController struct {
+	store     db.Store
+	mailer    mailer.Mailer
+	publicURL string
+}
+
+func newInvitationController(store db.Store, mail mailer.Mailer, publicURL string) *InvitationController {
+	return &InvitationController{store: store, mailer: mail, publicURL: publicURL}
+}
+
+// InvitationModule mounts the authenticated /invitations endpoints for the active organization.
+// Invitations are accepted through the public /auth/invitations/accept endpoint.
+func InvitationModule(store db.Store, mail mailer.Mailer, publicURL string) api.Module {
+	ctl := newInvitationController(store, mail, publicURL)
+	return api.ModuleFunc(func(c *api.Controller) {
+		c.GET("/invitations", ctl.listInvitations, model.PermMembersManage)
+		c.POST("/invitations", ctl.createInvitation, model.PermMembersManage) // body: {email, role}
+		c.DELETE("/invitations/:id", ctl.revokeInvitation, model.PermMembersManage)
+	})
+}
+
+func mapInvitation(inv model.Invitation) packets.InvitationResponse {
+	return packets.InvitationResponse{
+		ID:        inv.ID,
+		Email:     inv.Email,
+		Role:      inv.Role,
+		InvitedBy: inv.InvitedBy,
+		ExpiresAt: inv.ExpiresAt.Format(time.RFC3339),
+		CreatedAt: inv.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// invitationLink points at the dashboard's accept page, or is the bare token when
+// no public URL is configured.
+func (i *InvitationController) invitationLink(token string) string {
+	if i.publicURL == "" {
+		return token
+	}
+	return i.publicURL + "/accept-invite?token=" + url.QueryEscape(token)
+}
+
+// GET /api/admin/invitations
+func (i *InvitationController) listInvitations(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	invitations, err := i.store.ListPendingInvitations(currentOrganizationID(ctx))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list invitations"}
+	}
+
+	out := make([]packets.InvitationResponse, 0, len(invitations))
+	for _, inv := range invitations {
+		out = append(out, mapInvitation(inv))
+	}
+	return out, nil
+}
+
+// POST /api/admin/invitations
+func (i *InvitationController) createInvitation(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	var request packets.CreateInvitationRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	email := strings.ToLower(strings.TrimSpace(request.Email))
+	if request.Role == "" {
+		request.Role = model.RoleViewer
+	}
+	if _, err := i.store.GetRole(request.Role); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "unknown role"}
+	}
+
+	orgID := currentOrganizationID(ctx)
+	org, err := i.store.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not fetch organization"}
+	}
+
+	if existing, _ := i.store.GetUserByEmail(email); existing != nil {
+		if _, err := i.store.GetOrganizationMembership(orgID, existing.ID); err == nil {
+			return nil, &api.APIError{Code: http.StatusConflict, Message: "user is already a member of this organization"}
+		}
+	}
+
+	token, hash, err := middleware.GenerateSecretToken()
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate token"}
+	}
+	inv, err := i.store.CreateInvitation(orgID, user.ID, email, request.Role, hash, time.Now().Add(invitationTTL))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not create invitation"}
+	}
+
+	inviter := user.Email
+	if user.Name != nil && *user.Name != "" {
+		inviter = *user.Name
+	}
+	msg := mailer.Message{
+		To:      email,
+		Subject: fmt.Sprintf("You have been invited to %s", org.Name),
+		Body: fmt.Sprintf(
+			"%s invited you to join %s as %s.\n\n"+
+				"%s\n\n"+
+				"The invitation expires in %d days.",
+			inviter, org.Name, request.Role, i.invitationLink(token), int(invitationTTL.Hours()/24),
+		),
+	}
+	if err := i.mailer.Send(ctx, msg); err != nil {
+		log.Error().Err(err).Int("invitation_id", inv.ID).Msg("failed to send invitation email")
+		_ = i.store.RevokeInvitation(orgID, inv.ID)
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not send invitation email"}
+	}
+
+	recordAudit(ctx, i.store, orgID, "organization.invite", "invitation", inv.ID, nil, inv)
+	return mapInvitation(inv), nil
+}
+
+// DELETE /api/admin/invitations/:id
+func (i *InvitationController) revokeInvitation(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+
+	orgID := currentOrganizationID(ctx)
+	if err := i.store.RevokeInvitation(orgID, id); err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "invitation not found"}
+	}
+	recordAudit(ctx, i.store, orgID, "organization.revoke_invitation", "invitation", id, nil, nil)
+	return gin.H{"revoked": true}, nil
+}
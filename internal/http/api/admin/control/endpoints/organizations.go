@@ -0,0 +1,337 @@
+package endpoints
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+type OrganizationController struct {
+	store db.Store
+}
+
+func newOrganizationController(store db.Store) *OrganizationController {
+	return &OrganizationController{store: store}
+}
+
+// OrganizationModule mounts all authenticated /organizations endpoints.
+func OrganizationModule(store db.Store) api.Module {
+	ctl := newOrganizationController(store)
+	return api.ModuleFunc(func(c *api.Controller) {
+		c.GET("/organizations", ctl.listOrganizations)
+		c.POST("/organizations", ctl.createOrganization)
+		c.PUT("/organizations/:id/settings", ctl.updateSettings) // body: {require_two_factor}
+
+		// membership
+		c.GET("/organizations/:id/members", ctl.listMembers)
+		c.POST("/organizations/:id/members", ctl.addMember)            // body: {email, role}
+		c.PUT("/organizations/:id/members/:user_id", ctl.updateMember) // body: {role}
+		c.DELETE("/organizations/:id/members/:user_id", ctl.removeMember)
+
+		c.GET("/roles", ctl.listRoles)
+	})
+}
+
+// currentOrganizationID returns the organization the request acts on, as resolved by JWTMiddleware.
+func currentOrganizationID(ctx *gin.Context) int {
+	membership, ok := middleware.GetCurrentMembership(ctx)
+	if !ok {
+		return 0
+	}
+	return membership.OrganizationID
+}
+
+func mapOrganization(o model.Organization) packets.OrganizationResponse {
+	return packets.OrganizationResponse{
+		ID:               o.ID,
+		Name:             o.Name,
+		CreatedBy:        o.CreatedBy,
+		RequireTwoFactor: o.RequireTwoFactor,
+		CreatedAt:        o.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        o.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func mapOrganizationMember(m model.OrganizationMember) packets.OrganizationMemberResponse {
+	return packets.OrganizationMemberResponse{
+		UserID:   m.UserID,
+		Email:    m.Email,
+		Name:     m.Name,
+		Role:     m.Role,
+		JoinedAt: m.JoinedAt.Format(time.RFC3339),
+	}
+}
+
+func mapRole(r model.Role) packets.RoleResponse {
+	perms := make([]string, 0, len(r.Permissions))
+	for _, p := range r.Permissions {
+		perms = append(perms, string(p))
+	}
+	return packets.RoleResponse{
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: perms,
+	}
+}
+
+// requireMember parses :id and verifies the user belongs to that organization with a role
+// granting perms. The organization comes from the path rather than the active membership,
+// so these checks cannot be attached at route registration.
+func (o *OrganizationController) requireMember(ctx *gin.Context, user *model.User, perms ...model.Permission) (int, *api.APIError) {
+	orgID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return 0, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+	membership, err := o.store.GetOrganizationMembership(orgID, user.ID)
+	if err != nil {
+		log.Warn().Int("organization_id", orgID).Int("user_id", user.ID).
+			Msg("[organization] user is not a member")
+		return 0, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+	// api keys are bound to one organization and limited to their scopes
+	if _, isKey := middleware.GetCurrentAPIKey(ctx); isKey {
+		current, _ := middleware.GetCurrentMembership(ctx)
+		if current == nil || current.OrganizationID != orgID {
+			return 0, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+		}
+		membership = *current
+	}
+	if !membership.Can(perms...) {
+		log.Warn().Int("organization_id", orgID).Int("user_id", user.ID).Str("role", membership.Role).
+			Msg("[organization] insufficient permissions")
+		return 0, &api.APIError{Code: http.StatusForbidden, Message: "insufficient permissions"}
+	}
+	return orgID, nil
+}
+
+// isLastOwner reports whether targetID is the only owner left in the organization.
+func (o *OrganizationController) isLastOwner(orgID, targetID int) (bool, error) {
+	target, err := o.store.GetOrganizationMembership(orgID, targetID)
+	if err != nil {
+		return false, err
+	}
+	if target.Role != model.RoleOwner {
+		return false, nil
+	}
+	owners, err := o.store.CountOrganizationMembersWithRole(orgID, model.RoleOwner)
+	if err != nil {
+		return false, err
+	}
+	return owners <= 1, nil
+}
+
+// GET /api/admin/organizations
+func (o *OrganizationController) listOrganizations(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	orgs, err := o.store.ListOrganizationsForUser(user.ID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list organizations"}
+	}
+
+	out := make([]packets.OrganizationResponse, 0, len(orgs))
+	for _, org := range orgs {
+		out = append(out, mapOrganization(org))
+	}
+	return out, nil
+}
+
+// POST /api/admin/organizations
+func (o *OrganizationController) createOrganization(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	if _, isKey := middleware.GetCurrentAPIKey(ctx); isKey {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "not available to api keys"}
+	}
+
+	var request packets.CreateOrganizationRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	org, err := o.store.CreateOrganization(request.Name, user.ID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not create organization"}
+	}
+	recordAudit(ctx, o.store, org.ID, "organization.create", "organization", org.ID, nil, org)
+	return mapOrganization(org), nil
+}
+
+// PUT /api/admin/organizations/:id/settings
+func (o *OrganizationController) updateSettings(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	orgID, apiErr := o.requireMember(ctx, user, model.PermMembersManage)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	var request packets.UpdateOrganizationSettingsRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	before, _ := o.store.GetOrganizationByID(orgID)
+	if request.RequireTwoFactor != nil {
+		// otherwise the caller would lock themselves out of the organization
+		if *request.RequireTwoFactor && !user.TwoFactorEnabled() {
+			return nil, &api.APIError{Code: http.StatusConflict, Message: "enable two-factor authentication on your own account first"}
+		}
+		if err := o.store.SetOrganizationRequireTwoFactor(orgID, *request.RequireTwoFactor); err != nil {
+			return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not update settings"}
+		}
+	}
+
+	org, err := o.store.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not fetch organization"}
+	}
+	recordAudit(ctx, o.store, orgID, "organization.update_settings", "organization", orgID, before, org)
+	return mapOrganization(org), nil
+}
+
+// GET /api/admin/organizations/:id/members
+func (o *OrganizationController) listMembers(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	orgID, apiErr := o.requireMember(ctx, user)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	members, err := o.store.ListOrganizationMembers(orgID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list members"}
+	}
+
+	out := make([]packets.OrganizationMemberResponse, 0, len(members))
+	for _, m := range members {
+		out = append(out, mapOrganizationMember(m))
+	}
+	return out, nil
+}
+
+// POST /api/admin/organizations/:id/members
+func (o *OrganizationController) addMember(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	orgID, apiErr := o.requireMember(ctx, user, model.PermMembersManage)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	var request packets.AddOrganizationMemberRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	if request.Role == "" {
+		request.Role = model.RoleViewer
+	}
+	if _, err := o.store.GetRole(request.Role); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "unknown role"}
+	}
+
+	target, err := o.store.GetUserByEmail(request.Email)
+	if err != nil || target == nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "user not found"}
+	}
+
+	if err := o.store.AddOrganizationMember(orgID, target.ID, request.Role); err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not add member"}
+	}
+
+	member, err := o.store.GetOrganizationMembership(orgID, target.ID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not fetch member"}
+	}
+	recordAudit(ctx, o.store, orgID, "organization.add_member", "organization", orgID, nil, mapOrganizationMember(member))
+	return mapOrganizationMember(member), nil
+}
+
+// PUT /api/admin/organizations/:id/members/:user_id
+func (o *OrganizationController) updateMember(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	orgID, apiErr := o.requireMember(ctx, user, model.PermMembersManage)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	targetID, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid user id"}
+	}
+
+	var request packets.UpdateOrganizationMemberRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	if _, err := o.store.GetRole(request.Role); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "unknown role"}
+	}
+
+	lastOwner, err := o.isLastOwner(orgID, targetID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "member not found"}
+	}
+	if lastOwner && request.Role != model.RoleOwner {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "cannot demote the last owner of an organization"}
+	}
+
+	before, _ := o.store.GetOrganizationMembership(orgID, targetID)
+	if err := o.store.UpdateOrganizationMemberRole(orgID, targetID, request.Role); err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "member not found"}
+	}
+
+	member, err := o.store.GetOrganizationMembership(orgID, targetID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not fetch member"}
+	}
+	recordAudit(ctx, o.store, orgID, "organization.update_member", "organization", orgID,
+		mapOrganizationMember(before), mapOrganizationMember(member))
+	return mapOrganizationMember(member), nil
+}
+
+// DELETE /api/admin/organizations/:id/members/:user_id
+func (o *OrganizationController) removeMember(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	targetID, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid user id"}
+	}
+
+	// members may always leave; removing someone else requires members:manage
+	var perms []model.Permission
+	if targetID != user.ID {
+		perms = append(perms, model.PermMembersManage)
+	}
+	orgID, apiErr := o.requireMember(ctx, user, perms...)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	lastOwner, err := o.isLastOwner(orgID, targetID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "member not found"}
+	}
+	if lastOwner {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "cannot remove the last owner of an organization"}
+	}
+
+	before, _ := o.store.GetOrganizationMembership(orgID, targetID)
+	if err := o.store.RemoveOrganizationMember(orgID, targetID); err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "member not found"}
+	}
+	recordAudit(ctx, o.store, orgID, "organization.remove_member", "organization", orgID, mapOrganizationMember(before), nil)
+	return gin.H{"removed": true}, nil
+}
+
+// GET /api/admin/roles
+func (o *OrganizationController) listRoles(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	roles, err := o.store.ListRoles()
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list roles"}
+	}
+
+	out := make([]packets.RoleResponse, 0, len(roles))
+	for _, r := range roles {
+		out = append(out, mapRole(r))
+	}
+	return out, nil
+}
@@ -1,8 +1,8 @@
 package endpoints
 
 import (
-	"context"
-	"fmt"
+	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -14,7 +14,6 @@ import (
 	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/packets"
 	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/utils"
 	"github.com/Nixie-Tech-LLC/medusa/internal/model"
-	"github.com/Nixie-Tech-LLC/medusa/internal/redis"
 )
 
 
@@ -30,46 +29,53 @@ func newPlaylistController(store db.Store) *PlaylistController {
 func PlaylistModule(store db.Store) api.Module {
 	ctl := newPlaylistController(store)
 	return api.ModuleFunc(func(c *api.Controller) {
-		c.GET("/playlists", 		ctl.listPlaylists)
-		c.POST("/playlists", 		ctl.createPlaylist)
-		c.GET("/playlists/:id", 	ctl.getPlaylist)
-		c.PUT("/playlists/:id", 	ctl.updatePlaylist)
-		c.DELETE("/playlists/:id", 	ctl.deletePlaylist)
-
-		c.POST("/playlists/:id/items", 				ctl.addItem)
-		c.PUT("/playlists/:id/items/:item_id", 		ctl.updateItem)
-		c.DELETE("/playlists/:id/items/:item_id", 	ctl.removeItem)
-		c.GET("/playlists/:id/items",		 		ctl.listItems)
-		c.PUT("/playlists/:id/items", 				ctl.reorderItems)
-
-		c.POST("/playlists/:id/integrations", ctl.addIntegration)
+		c.GET("/playlists", 		ctl.listPlaylists, model.PermPlaylistsRead)
+		c.POST("/playlists", 		ctl.createPlaylist, model.PermPlaylistsWrite)
+		c.GET("/playlists/:id", 	ctl.getPlaylist, model.PermPlaylistsRead)
+		c.PUT("/playlists/:id", 	ctl.updatePlaylist, model.PermPlaylistsWrite)
+		c.DELETE("/playlists/:id", 	ctl.deletePlaylist, model.PermPlaylistsWrite)
+
+		c.POST("/playlists/:id/items", 				ctl.addItem, model.PermPlaylistsWrite)
+		c.PUT("/playlists/:id/items/:item_id", 		ctl.updateItem, model.PermPlaylistsWrite)
+		c.DELETE("/playlists/:id/items/:item_id", 	ctl.removeItem, model.PermPlaylistsWrite)
+		c.GET("/playlists/:id/items",		 		ctl.listItems, model.PermPlaylistsRead)
+		c.PUT("/playlists/:id/items", 				ctl.reorderItems, model.PermPlaylistsWrite)
+
+		c.POST("/playlists/:id/integrations", ctl.addIntegration, model.PermPlaylistsWrite)
 	})
 }
 
+// notifyScreensPlaylistUpdated invalidates the playlist's cached ETag and pushes a content
+// change to every device showing it, directly or through a schedule.
 func (p *PlaylistController) notifyScreensPlaylistUpdated(playlistID int) {
-	screens, err := p.store.GetScreensUsingPlaylist(playlistID)
+	invalidatePlaylistETag(playlistID)
+
+	deviceIDs, err := p.store.ListDeviceIDsForPlaylist(playlistID)
 	if err != nil {
 		log.Error().Err(err).Int("playlist_id", playlistID).
-			Msg("failed to get screens for playlist notification")
+			Msg("failed to get devices for playlist notification")
 		return
 	}
-
-	if len(screens) == 0 {
+	if len(deviceIDs) == 0 {
 		log.Debug().Int("playlist_id", playlistID).Msg("no screens assigned to playlist")
 		return
 	}
 
-	etagKey := fmt.Sprintf("playlist:%d:etag", playlistID)
-	if err := redis.Rdb.Del(context.Background(), etagKey).Err(); err != nil {
-		log.Warn().Err(err).Int("playlist_id", playlistID).Str("etag_key", etagKey).
-			Msg("failed to invalidate playlist ETag cache")
-	} else {
-		log.Debug().Int("playlist_id", playlistID).Str("etag_key", etagKey).
-			Msg("invalidated playlist ETag cache")
-	}
+	pushContentChanged(deviceIDs, model.ContentChangedPlaylistUpdated, &playlistID)
+}
 
-	log.Info().Int("playlist_id", playlistID).Int("affected_screens", len(screens)).
-		Msg("playlist updated - invalidated playlist ETag for all affected screens")
+// findItem returns the playlist's item with the given ID, or nil, for audit snapshots.
+func (p *PlaylistController) findItem(playlistID, itemID int) *model.PlaylistItem {
+	items, err := p.store.ListPlaylistItems(playlistID)
+	if err != nil {
+		return nil
+	}
+	for i := range items {
+		if items[i].ID == itemID {
+			return &items[i]
+		}
+	}
+	return nil
 }
 
 func mapPlaylist(pl model.Playlist) packets.PlaylistResponse {
@@ -109,7 +115,7 @@ func mapItem(it model.PlaylistItem) packets.PlaylistItemResponse {
 // ===== Handlers (AuthHandlerFunc signatures) =====
 
 func (p *PlaylistController) listPlaylists(ctx *gin.Context, user *model.User) (any, *api.APIError) {
-	all, err := p.store.ListPlaylists()
+	all, err := p.store.ListPlaylists(currentOrganizationID(ctx))
 	if err != nil {
 		log.Error().Err(err).Msg("[playlist] list: could not list playlists")
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list playlists"}
@@ -117,9 +123,6 @@ func (p *PlaylistController) listPlaylists(ctx *gin.Context, user *model.User) (
 
 	var out []packets.PlaylistResponse
 	for _, pl := range all {
-		if pl.CreatedBy != user.ID {
-			continue
-		}
 		out = append(out, mapPlaylist(pl))
 	}
 	return out, nil
@@ -132,13 +135,14 @@ func (p *PlaylistController) createPlaylist(ctx *gin.Context, user *model.User)
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
 	}
 
-	pl, err := p.store.CreatePlaylist(req.Name, req.Description, user.ID)
+	pl, err := p.store.CreatePlaylist(req.Name, req.Description, currentOrganizationID(ctx), user.ID)
 	if err != nil {
 		log.Error().Err(err).Msg("[playlist] create: could not create playlist")
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not create playlist"}
 	}
 
 	full, _ := p.store.GetPlaylistByID(pl.ID)
+	recordAudit(ctx, p.store, full.OrganizationID, "playlist.create", "playlist", pl.ID, nil, full)
 	return mapPlaylist(full), nil
 }
 
@@ -151,7 +155,7 @@ func (p *PlaylistController) getPlaylist(ctx *gin.Context, user *model.User) (an
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "not found"}
 	}
-	if pl.CreatedBy != user.ID {
+	if pl.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 	return mapPlaylist(pl), nil
@@ -164,7 +168,7 @@ func (p *PlaylistController) updatePlaylist(ctx *gin.Context, user *model.User)
 	}
 
 	existing, err := p.store.GetPlaylistByID(id)
-	if err != nil || existing.CreatedBy != user.ID {
+	if err != nil || existing.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -180,6 +184,7 @@ func (p *PlaylistController) updatePlaylist(ctx *gin.Context, user *model.User)
 	go p.notifyScreensPlaylistUpdated(id)
 
 	full, _ := p.store.GetPlaylistByID(id)
+	recordAudit(ctx, p.store, existing.OrganizationID, "playlist.update", "playlist", id, existing, full)
 	return mapPlaylist(full), nil
 }
 
@@ -190,16 +195,18 @@ func (p *PlaylistController) deletePlaylist(ctx *gin.Context, user *model.User)
 	}
 
 	pl, err := p.store.GetPlaylistByID(id)
-	if err != nil || pl.CreatedBy != user.ID {
+	if err != nil || pl.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
-	// Notify screens before deletion
-	go p.notifyScreensPlaylistUpdated(id)
-
+	// the playlist's screens are gone with it, so look them up first
+	deviceIDs, _ := p.store.ListDeviceIDsForPlaylist(id)
 	if err := p.store.DeletePlaylist(id); err != nil {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: err.Error()}
 	}
+	recordAudit(ctx, p.store, pl.OrganizationID, "playlist.delete", "playlist", id, pl, nil)
+	invalidatePlaylistETag(id)
+	go pushContentChanged(deviceIDs, model.ContentChangedPlaylistUpdated, &id)
 	return nil, nil
 }
 
@@ -210,7 +217,7 @@ func (p *PlaylistController) addItem(ctx *gin.Context, user *model.User) (any, *
 	}
 
 	pl, err := p.store.GetPlaylistByID(pid)
-	if err != nil || pl.CreatedBy != user.ID {
+	if err != nil || pl.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -219,6 +226,14 @@ func (p *PlaylistController) addItem(ctx *gin.Context, user *model.User) (any, *
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
 	}
 
+	content, err := p.store.GetContentByID(req.ContentID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "content not found"}
+	}
+	if content.OrganizationID != pl.OrganizationID {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+
 	existingItems, err := p.store.ListPlaylistItems(pid)
 	if err != nil {
 		log.Error().Err(err).Msg("[playlist] list items failed")
@@ -238,6 +253,7 @@ func (p *PlaylistController) addItem(ctx *gin.Context, user *model.User) (any, *
 	}
 
 	go p.notifyScreensPlaylistUpdated(pid)
+	recordAudit(ctx, p.store, pl.OrganizationID, "playlist.item.add", "playlist", pid, nil, item)
 	return mapItem(item), nil
 }
 
@@ -248,7 +264,7 @@ func (p *PlaylistController) updateItem(ctx *gin.Context, user *model.User) (any
 	}
 
 	pl, err := p.store.GetPlaylistByID(pid)
-	if err != nil || pl.CreatedBy != user.ID {
+	if err != nil || pl.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -262,11 +278,17 @@ func (p *PlaylistController) updateItem(ctx *gin.Context, user *model.User) (any
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
 	}
 
-	if err := p.store.UpdatePlaylistItem(id, req.Position, req.Duration); err != nil {
+	before := p.findItem(pid, id)
+	err = p.store.UpdatePlaylistItem(pid, id, req.Position, req.Duration)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "playlist item not found"}
+	}
+	if err != nil {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: err.Error()}
 	}
 
 	go p.notifyScreensPlaylistUpdated(pid)
+	recordAudit(ctx, p.store, pl.OrganizationID, "playlist.item.update", "playlist", pid, before, p.findItem(pid, id))
 	return nil, nil
 }
 
@@ -277,7 +299,7 @@ func (p *PlaylistController) removeItem(ctx *gin.Context, user *model.User) (any
 	}
 
 	pl, err := p.store.GetPlaylistByID(pid)
-	if err != nil || pl.CreatedBy != user.ID {
+	if err != nil || pl.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -286,11 +308,17 @@ func (p *PlaylistController) removeItem(ctx *gin.Context, user *model.User) (any
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid item id"}
 	}
 
-	if err := p.store.RemovePlaylistItem(iid); err != nil {
+	before := p.findItem(pid, iid)
+	err = p.store.RemovePlaylistItem(pid, iid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "playlist item not found"}
+	}
+	if err != nil {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: err.Error()}
 	}
 
 	go p.notifyScreensPlaylistUpdated(pid)
+	recordAudit(ctx, p.store, pl.OrganizationID, "playlist.item.remove", "playlist", pid, before, nil)
 	return nil, nil
 }
 
@@ -301,7 +329,7 @@ func (p *PlaylistController) listItems(ctx *gin.Context, user *model.User) (any,
 	}
 
 	pl, err := p.store.GetPlaylistByID(pid)
-	if err != nil || pl.CreatedBy != user.ID {
+	if err != nil || pl.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -325,7 +353,7 @@ func (p *PlaylistController) reorderItems(ctx *gin.Context, user *model.User) (a
 	}
 
 	pl, err := p.store.GetPlaylistByID(pid)
-	if err != nil || pl.CreatedBy != user.ID {
+	if err != nil || pl.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -335,12 +363,15 @@ func (p *PlaylistController) reorderItems(ctx *gin.Context, user *model.User) (a
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
 	}
 
+	before, _ := p.store.ListPlaylistItems(pid)
 	if err := p.store.ReorderPlaylistItems(pid, req.ItemIDs); err != nil {
 		log.Error().Err(err).Msg("[playlist] reorder failed")
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not reorder items"}
 	}
 
 	go p.notifyScreensPlaylistUpdated(pid)
+	after, _ := p.store.ListPlaylistItems(pid)
+	recordAudit(ctx, p.store, pl.OrganizationID, "playlist.item.reorder", "playlist", pid, before, after)
 	return p.listItems(ctx, user)
 }
 
@@ -351,7 +382,7 @@ func (p *PlaylistController) addIntegration(ctx *gin.Context, user *model.User)
 	}
 
 	pl, err := p.store.GetPlaylistByID(pid)
-	if err != nil || pl.CreatedBy != user.ID {
+	if err != nil || pl.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -399,7 +430,9 @@ func (p *PlaylistController) addIntegration(ctx *gin.Context, user *model.User)
 		url,                 // URL
 		1920,                // width
 		1080,                // height
+		pl.OrganizationID,
 		user.ID,
+		nil,
 	)
 	if err != nil {
 		log.Error().Err(err).Msg("create integration content failed")
@@ -422,7 +455,7 @@ func (p *PlaylistController) addIntegration(ctx *gin.Context, user *model.User)
 			for _, item := range items {
 				if item.Position >= pos {
 					newPos := pos + 1
-					if err := p.store.UpdatePlaylistItem(item.ID, &newPos, &item.Duration); err != nil {
+					if err := p.store.UpdatePlaylistItem(pid, item.ID, &newPos, &item.Duration); err != nil {
 						log.Error().Err(err).Msg("Failed to shift playlist item position")
 						return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not reorder items"}
 					}
@@ -445,6 +478,7 @@ func (p *PlaylistController) addIntegration(ctx *gin.Context, user *model.User)
 	}
 
 	go p.notifyScreensPlaylistUpdated(pid)
+	recordAudit(ctx, p.store, pl.OrganizationID, "playlist.integration.add", "playlist", pid, nil, item)
 	return mapItem(item), nil
 }
 
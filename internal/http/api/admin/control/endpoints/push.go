@@ -0,0 +1,67 @@
+package endpoints
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+	"github.com/Nixie-Tech-LLC/medusa/internal/redis"
+)
+
+// invalidatePlaylistETag drops the cached ETag of a playlist so the next /content request
+// recomputes it.
+func invalidatePlaylistETag(playlistID int) {
+	etagKey := fmt.Sprintf("playlist:%d:etag", playlistID)
+	if err := redis.Rdb.Del(context.Background(), etagKey).Err(); err != nil {
+		log.Warn().Err(err).Int("playlist_id", playlistID).Str("etag_key", etagKey).
+			Msg("failed to invalidate playlist ETag cache")
+	}
+}
+
+// pushContentChanged tells each device to refetch its content. Publishing waits on the
+// broker, so callers run it in its own goroutine once the change is committed; a device
+// that misses the event still picks the change up the next time it polls.
+func pushContentChanged(deviceIDs []string, reason string, playlistID *int) {
+	if len(deviceIDs) == 0 {
+		return
+	}
+
+	event := model.NewDeviceEvent(model.EventContentChanged, reason, playlistID)
+	failed := 0
+	var lastErr error
+	for _, deviceID := range deviceIDs {
+		if err := middleware.PublishEvent(deviceID, event); err != nil {
+			failed++
+			lastErr = err
+		}
+	}
+
+	if failed > 0 {
+		log.Warn().Err(lastErr).Str("reason", reason).Int("devices", len(deviceIDs)).Int("failed", failed).
+			Msg("content change not pushed to every device")
+		return
+	}
+	log.Info().Str("reason", reason).Int("devices", len(deviceIDs)).Msg("pushed content change to devices")
+}
+
+// notifyScheduleChanged pushes a content change to every device the schedule is assigned to.
+func notifyScheduleChanged(store db.Store, scheduleID int) {
+	deviceIDs, err := store.ListDeviceIDsForSchedule(scheduleID)
+	if err != nil {
+		return
+	}
+	pushContentChanged(deviceIDs, model.ContentChangedScheduleUpdated, nil)
+}
+
+// screenDevice returns the screen's device as a list for pushContentChanged, or nil when
+// the screen is not paired.
+func screenDevice(screen model.Screen) []string {
+	if !screen.Paired || screen.DeviceID == nil {
+		return nil
+	}
+	return []string{*screen.DeviceID}
+}
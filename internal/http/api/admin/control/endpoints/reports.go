@@ -0,0 +1,160 @@
+package endpoints
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+const (
+	defaultPlayReportPeriod = 7 * 24 * time.Hour
+	maxPlayReportPeriod     = 366 * 24 * time.Hour
+)
+
+type ReportController struct {
+	store db.Store
+}
+
+func newReportController(store db.Store) *ReportController {
+	return &ReportController{store: store}
+}
+
+// ReportModule mounts the authenticated /reports endpoints.
+func ReportModule(store db.Store) api.Module {
+	ctl := newReportController(store)
+	return api.ModuleFunc(func(c *api.Controller) {
+		c.GET("/reports/plays", ctl.playReport, model.PermScreensRead)
+	})
+}
+
+// GET /api/admin/reports/plays?group_by=content,day&from=&to=&screen_id=&content_id=&playlist_id=&format=csv
+// Aggregates proof-of-play events. The period defaults to the last 7 days.
+func (r *ReportController) playReport(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	var query packets.PlayReportQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	filter := db.PlayReportFilter{
+		OrganizationID: currentOrganizationID(ctx),
+		To:             time.Now(),
+		ScreenID:       query.ScreenID,
+		ContentID:      query.ContentID,
+		PlaylistID:     query.PlaylistID,
+	}
+	if query.To != nil {
+		filter.To = *query.To
+	}
+	filter.From = filter.To.Add(-defaultPlayReportPeriod)
+	if query.From != nil {
+		filter.From = *query.From
+	}
+	if !filter.To.After(filter.From) {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "to must be after from"}
+	}
+	if filter.To.Sub(filter.From) > maxPlayReportPeriod {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "report period is limited to 366 days"}
+	}
+
+	groupBy := query.GroupBy
+	if groupBy == "" {
+		groupBy = model.PlayByContent
+	}
+	seen := map[string]bool{}
+	for _, dim := range strings.Split(groupBy, ",") {
+		dim = strings.TrimSpace(dim)
+		switch dim {
+		case model.PlayByContent, model.PlayByPlaylist, model.PlayByScreen, model.PlayByDay:
+		default:
+			return nil, &api.APIError{Code: http.StatusBadRequest, Message: "group_by must list content, playlist, screen or day"}
+		}
+		if !seen[dim] {
+			seen[dim] = true
+			filter.GroupBy = append(filter.GroupBy, dim)
+		}
+	}
+
+	stats, err := r.store.PlayReport(filter)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not build play report"}
+	}
+
+	switch query.Format {
+	case "", "json":
+		if stats == nil {
+			stats = []model.PlayStat{}
+		}
+		return stats, nil
+	case "csv":
+		writePlayReportCSV(ctx, filter.GroupBy, stats)
+		return nil, nil
+	default:
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "format must be json or csv"}
+	}
+}
+
+// writePlayReportCSV writes one column per dimension (ID and name) followed by the totals.
+func writePlayReportCSV(ctx *gin.Context, groupBy []string, stats []model.PlayStat) {
+	header := []string{}
+	for _, dim := range groupBy {
+		if dim == model.PlayByDay {
+			header = append(header, "day")
+		} else {
+			header = append(header, dim+"_id", dim+"_name")
+		}
+	}
+	header = append(header, "plays", "total_seconds", "screens")
+
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="plays-%s.csv"`, time.Now().UTC().Format("20060102")))
+	ctx.Status(http.StatusOK)
+
+	w := csv.NewWriter(ctx.Writer)
+	_ = w.Write(header)
+	for _, s := range stats {
+		row := []string{}
+		for _, dim := range groupBy {
+			switch dim {
+			case model.PlayByContent:
+				row = append(row, csvInt(s.ContentID), csvString(s.ContentName))
+			case model.PlayByPlaylist:
+				row = append(row, csvInt(s.PlaylistID), csvString(s.PlaylistName))
+			case model.PlayByScreen:
+				row = append(row, csvInt(s.ScreenID), csvString(s.ScreenName))
+			case model.PlayByDay:
+				row = append(row, csvString(s.Day))
+			}
+		}
+		row = append(row, strconv.Itoa(s.Plays), strconv.FormatInt(s.TotalSeconds, 10), strconv.Itoa(s.Screens))
+		_ = w.Write(row)
+	}
+	w.Flush()
+}
+
+func csvInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+// csvString also defuses values a spreadsheet would evaluate as a formula.
+func csvString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	if s := *v; s != "" && strings.ContainsAny(s[:1], "=+-@") {
+		return "'" + s
+	}
+	return *v
+}
@@ -26,25 +26,25 @@ func ScheduleModule(store db.Store) api.Module {
 	ctl := NewScheduleController(store)
 	return api.ModuleFunc(func(c *api.Controller) {
 		// top-level schedules
-		c.GET("/schedules", ctl.listSchedules)
-		c.POST("/schedules", ctl.createSchedule)
-		c.DELETE("/schedules/:id", ctl.deleteSchedule)
+		c.GET("/schedules", ctl.listSchedules, model.PermSchedulesRead)
+		c.POST("/schedules", ctl.createSchedule, model.PermSchedulesWrite)
+		c.DELETE("/schedules/:id", ctl.deleteSchedule, model.PermSchedulesWrite)
 
 		// schedule <-> screen
-		c.POST("/schedules/:id/screens", ctl.assignScheduleToScreen)
-		c.DELETE("/schedules/:id/screens/:screen_id", ctl.unassignScheduleFromScreen)
+		c.POST("/schedules/:id/screens", ctl.assignScheduleToScreen, model.PermSchedulesWrite)
+		c.DELETE("/schedules/:id/screens/:screen_id", ctl.unassignScheduleFromScreen, model.PermSchedulesWrite)
 
 		// windows (playlist assignments)
-		c.POST("/schedules/:id/windows", ctl.createWindow)
-		c.DELETE("/schedules/windows/:window_id", ctl.deleteWindow)
+		c.POST("/schedules/:id/windows", ctl.createWindow, model.PermSchedulesWrite)
+		c.DELETE("/schedules/windows/:window_id", ctl.deleteWindow, model.PermSchedulesWrite)
 
 		// calendar feed for GUI (expand occurrences between [from,to))
-		c.GET("/schedules/:id/occurrences", ctl.listOccurrences)
+		c.GET("/schedules/:id/occurrences", ctl.listOccurrences, model.PermSchedulesRead)
 	})
 }
 
 func (s *ScheduleController) listSchedules(ctx *gin.Context, user *model.User) (any, *api.APIError) {
-	list, err := s.store.ListSchedules(user.ID)
+	list, err := s.store.ListSchedules(currentOrganizationID(ctx))
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "failed to list schedules"}
 	}
@@ -67,10 +67,11 @@ func (s *ScheduleController) createSchedule(ctx *gin.Context, user *model.User)
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
 	}
 
-	sc, err := s.store.CreateSchedule(request.Name, user.ID)
+	sc, err := s.store.CreateSchedule(request.Name, currentOrganizationID(ctx), user.ID)
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not create schedule"}
 	}
+	recordAudit(ctx, s.store, currentOrganizationID(ctx), "schedule.create", "schedule", sc.ID, nil, sc)
 
 	response := packets.ScheduleResponse{
 		ID:        sc.ID,
@@ -91,13 +92,17 @@ func (s *ScheduleController) deleteSchedule(ctx *gin.Context, user *model.User)
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "schedule not found"}
 	}
-	if owned.CreatedBy != user.ID {
+	if owned.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
+	// the schedule's screens are gone with it, so look them up first
+	deviceIDs, _ := s.store.ListDeviceIDsForSchedule(id)
 	if err := s.store.DeleteSchedule(id); err != nil {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not delete schedule"}
 	}
+	recordAudit(ctx, s.store, owned.OrganizationID, "schedule.delete", "schedule", id, owned, nil)
+	go pushContentChanged(deviceIDs, model.ContentChangedScheduleUpdated, nil)
 
 	response := gin.H{"message": "deleted"}
 	return response, nil
@@ -110,7 +115,7 @@ func (s *ScheduleController) assignScheduleToScreen(ctx *gin.Context, user *mode
 	}
 
 	schedule, err := s.store.GetScheduleByID(scheduleID)
-	if err != nil || schedule.CreatedBy != user.ID {
+	if err != nil || schedule.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -123,13 +128,16 @@ func (s *ScheduleController) assignScheduleToScreen(ctx *gin.Context, user *mode
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
 	}
-	if screen.CreatedBy != user.ID {
+	if screen.OrganizationID != schedule.OrganizationID {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
 	if err := s.store.AssignScheduleToScreen(scheduleID, request.ScreenID); err != nil {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not assign schedule to screen"}
 	}
+	recordAudit(ctx, s.store, schedule.OrganizationID, "schedule.assign_screen", "schedule", scheduleID,
+		nil, gin.H{"screen_id": request.ScreenID})
+	go pushContentChanged(screenDevice(screen), model.ContentChangedScheduleUpdated, nil)
 
 	response := gin.H{"message": "assigned"}
 	return response, nil
@@ -142,7 +150,7 @@ func (s *ScheduleController) unassignScheduleFromScreen(ctx *gin.Context, user *
 	}
 
 	schedule, err := s.store.GetScheduleByID(scheduleID)
-	if err != nil || schedule.CreatedBy != user.ID {
+	if err != nil || schedule.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -155,13 +163,16 @@ func (s *ScheduleController) unassignScheduleFromScreen(ctx *gin.Context, user *
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
 	}
-	if screen.CreatedBy != user.ID {
+	if screen.OrganizationID != schedule.OrganizationID {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
 	if err := s.store.UnassignScheduleFromScreen(scheduleID, screenID); err != nil {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not unassign"}
 	}
+	recordAudit(ctx, s.store, schedule.OrganizationID, "schedule.unassign_screen", "schedule", scheduleID,
+		gin.H{"screen_id": screenID}, nil)
+	go pushContentChanged(screenDevice(screen), model.ContentChangedScheduleUpdated, nil)
 
 	response := gin.H{"message": "unassigned"}
 	return response, nil
@@ -177,7 +188,7 @@ func (s *ScheduleController) createWindow(ctx *gin.Context, user *model.User) (a
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "schedule not found"}
 	}
-	if schedule.CreatedBy != user.ID {
+	if schedule.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -193,7 +204,7 @@ func (s *ScheduleController) createWindow(ctx *gin.Context, user *model.User) (a
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "playlist not found"}
 	}
-	if playlist.CreatedBy != user.ID {
+	if playlist.OrganizationID != schedule.OrganizationID {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -204,6 +215,8 @@ func (s *ScheduleController) createWindow(ctx *gin.Context, user *model.User) (a
 		// 409 with the detailed overlap message (e.g. "overlaps with window 42")
 		return nil, &api.APIError{Code: http.StatusConflict, Message: err.Error()}
 	}
+	recordAudit(ctx, s.store, schedule.OrganizationID, "schedule.window.create", "schedule", scheduleID, nil, window)
+	go notifyScheduleChanged(s.store, scheduleID)
 
 	return window, nil
 }
@@ -225,7 +238,7 @@ func (s *ScheduleController) deleteWindow(ctx *gin.Context, user *model.User) (a
 		log.Error().Err(err).Int("window_id", windowID).Msg("deleteWindow ownership check failed")
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "window not found"}
 	}
-	if ownedSchedule.CreatedBy != user.ID {
+	if ownedSchedule.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -242,6 +255,9 @@ func (s *ScheduleController) deleteWindow(ctx *gin.Context, user *model.User) (a
 		 return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not delete occurrence"}
 		}
 	}
+	recordAudit(ctx, s.store, ownedSchedule.OrganizationID, "schedule.window.delete", "schedule", ownedSchedule.ID,
+		gin.H{"window_id": windowID, "scope": request.Scope, "occur_start": request.OccurStart}, nil)
+	go notifyScheduleChanged(s.store, ownedSchedule.ID)
 
 	response := gin.H{"message": "deleted"}
 	return response, nil
@@ -257,7 +273,7 @@ func (s *ScheduleController) listOccurrences(ctx *gin.Context, user *model.User)
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "schedule not found"}
 	}
-	if schedule.CreatedBy != user.ID {
+	if schedule.OrganizationID != currentOrganizationID(ctx) {
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
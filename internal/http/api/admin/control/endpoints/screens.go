@@ -1,10 +1,12 @@
 package endpoints
 
 import (
+	"database/sql"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,71 +15,178 @@ import (
 	"github.com/Nixie-Tech-LLC/medusa/internal/db"
 	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
 	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
 	"github.com/Nixie-Tech-LLC/medusa/internal/model"
 	"github.com/Nixie-Tech-LLC/medusa/internal/redis"
+	"github.com/Nixie-Tech-LLC/medusa/internal/storage"
 )
 
 type TvController struct {
-	store db.Store
+	store    db.Store
+	storage  storage.Storage
+	presence model.PresenceThresholds
 }
 
-func newTvController(store db.Store) *TvController {
-	return &TvController{store: store}
+func newTvController(store db.Store, storage storage.Storage, presence model.PresenceThresholds) *TvController {
+	return &TvController{store: store, storage: storage, presence: presence}
 }
 
-// ScreenModule mounts all authenticated /screens endpoints.
-func ScreenModule(store db.Store) api.Module {
-	ctl := newTvController(store)
+// ScreenModule mounts all authenticated /screens endpoints. presence decides when a screen
+// that has stopped sending heartbeats is reported as stale or offline; storage holds the
+// screenshots devices upload.
+func ScreenModule(store db.Store, storage storage.Storage, presence model.PresenceThresholds) api.Module {
+	ctl := newTvController(store, storage, presence)
 	return api.ModuleFunc(func(c *api.Controller) {
-		// CRUD
+		// CRUD; listing and viewing are also open to users a screen is assigned to,
+		// so those handlers authorize per screen instead of at registration.
 		c.GET("/screens", ctl.listScreens)
-		c.POST("/screens", ctl.createScreen)
+		c.GET("/screens/status", ctl.fleetStatus, model.PermScreensRead)
+		c.POST("/screens", ctl.createScreen, model.PermScreensWrite)
 		c.GET("/screens/:id", ctl.getScreen)
-		c.PUT("/screens/:id", ctl.updateScreen)
-		c.DELETE("/screens/:id", ctl.deleteScreen)
+		c.PUT("/screens/:id", ctl.updateScreen, model.PermScreensWrite)
+		c.DELETE("/screens/:id", ctl.deleteScreen, model.PermScreensWrite)
 
 		// screen <-> playlist
 		c.GET("/screens/:id/playlist", ctl.getPlaylistForScreen)
 		c.POST("/screens/:id/playlist", ctl.assignPlaylistToScreen)
 
 		// pairing & assignment
-		c.POST("/screens/pair", ctl.pairScreen)
-		c.POST("/screens/:id/assign", ctl.assignScreenToUser)
-
+		c.POST("/screens/pair", ctl.pairScreen, model.PermScreensWrite)
+		c.POST("/screens/:id/assign", ctl.assignScreenToUser, model.PermScreensWrite)
+		c.GET("/screens/:id/assignments", ctl.listScreenAssignments, model.PermScreensWrite)
+		c.DELETE("/screens/:id/assignments/:user_id", ctl.unassignScreenFromUser, model.PermScreensWrite)
+
+		// device credentials
+		c.POST("/screens/:id/device_token", ctl.rotateDeviceToken, model.PermScreensWrite)
+		c.DELETE("/screens/:id/device_token", ctl.revokeDeviceToken, model.PermScreensWrite)
+
+		// device lifecycle; the screen keeps its name, groups, schedules and playlist
+		c.POST("/screens/:id/unpair", ctl.unpairScreen, model.PermScreensWrite)
+		c.POST("/screens/:id/replace-device", ctl.replaceDevice, model.PermScreensWrite)
+		c.GET("/screens/:id/devices", ctl.listScreenDevices, model.PermScreensWrite)
+
+		// screenshots; devices upload them through POST /api/tv/screenshots
+		c.POST("/screens/:id/screenshots", ctl.requestScreenshot, model.PermScreensWrite)
+		c.GET("/screens/:id/screenshots", ctl.listScreenshots, model.PermScreensRead)
+		c.GET("/screens/:id/screenshots/:screenshot_id/image", ctl.getScreenshotImage, model.PermScreensRead)
+		c.DELETE("/screens/:id/screenshots/:screenshot_id", ctl.deleteScreenshot, model.PermScreensWrite)
 	})
 }
 
-type PairingData struct {
-	DeviceID string `json:"device_id"`
-	IsPaired bool   `json:"is_paired"`
+// screenResponse flattens the screen for the API, deriving its status from the last heartbeat.
+func screenResponse(s model.Screen, presence model.PresenceThresholds, now time.Time) packets.ScreenResponse {
+	resp := packets.ScreenResponse{
+		ID:                s.ID,
+		DeviceID:          s.DeviceID,
+		ClientInformation: s.ClientInformation,
+		ClientWidth:       s.ClientWidth,
+		ClientHeight:      s.ClientHeight,
+		Name:              s.Name,
+		Location:          s.Location,
+		Paired:            s.Paired,
+		CreatedAt:         s.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:         s.UpdatedAt.Format(time.RFC3339),
+		Status:            presence.Status(s.LastSeenAt, now),
+		UptimeSeconds:     s.UptimeSeconds,
+		PlaylistETag:      s.PlaylistETag,
+		AppVersion:        s.AppVersion,
+		StorageSize:       s.StorageSize,
+		IPAddress:         s.IPAddress,
+	}
+	if s.LastSeenAt != nil {
+		lastSeen := s.LastSeenAt.Format(time.RFC3339)
+		resp.LastSeenAt = &lastSeen
+	}
+	return resp
+}
+
+// authorizeScreen checks that the screen belongs to the active organization and that the
+// caller either holds perm organization-wide or has had the screen assigned to them.
+func (t *TvController) authorizeScreen(ctx *gin.Context, user *model.User, screen model.Screen, perm model.Permission) *api.APIError {
+	membership, ok := middleware.GetCurrentMembership(ctx)
+	if !ok || screen.OrganizationID != membership.OrganizationID {
+		log.Warn().Int("user_id", user.ID).Int("screen_id", screen.ID).
+			Int("screen_org", screen.OrganizationID).Msg("screen belongs to another organization")
+		return &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+	if membership.Can(perm) {
+		return nil
+	}
+	// assignments are to the key's creator; a key gets only what its scopes grant
+	if _, isKey := middleware.GetCurrentAPIKey(ctx); isKey {
+		log.Warn().Int("user_id", user.ID).Int("screen_id", screen.ID).Msg("api key lacks screen permission")
+		return &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+
+	assigned, err := t.store.IsScreenAssignedToUser(screen.ID, user.ID)
+	if err != nil {
+		return &api.APIError{Code: http.StatusInternalServerError, Message: "could not check screen assignment"}
+	}
+	if !assigned {
+		log.Warn().Int("user_id", user.ID).Int("screen_id", screen.ID).Str("role", membership.Role).
+			Msg("screen is neither permitted by role nor assigned to user")
+		return &api.APIError{Code: http.StatusForbidden, Message: "insufficient permissions"}
+	}
+	return nil
 }
 
 // GET /api/admin/screens
 func (t *TvController) listScreens(ctx *gin.Context, user *model.User) (any, *api.APIError) {
-	all, err := t.store.ListScreens()
+	var (
+		all []model.Screen
+		err error
+	)
+	if membership, ok := middleware.GetCurrentMembership(ctx); ok && membership.Can(model.PermScreensRead) {
+		all, err = t.store.ListScreens(membership.OrganizationID)
+	} else if _, isKey := middleware.GetCurrentAPIKey(ctx); isKey {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	} else {
+		all, err = t.store.ListScreensAssignedToUser(currentOrganizationID(ctx), user.ID)
+	}
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: err.Error()}
 	}
 
+	now := time.Now()
 	out := make([]packets.ScreenResponse, 0, len(all))
 	for _, s := range all {
-		if s.CreatedBy != user.ID {
-			continue
-		}
-		out = append(out, packets.ScreenResponse{
-			ID:                s.ID,
-			DeviceID:          s.DeviceID,
-			ClientInformation: s.ClientInformation,
-			ClientWidth:       s.ClientWidth,
-			ClientHeight:      s.ClientHeight,
-			Name:              s.Name,
-			Location:          s.Location,
-			Paired:            s.Paired,
-			CreatedAt:         s.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:         s.UpdatedAt.Format(time.RFC3339),
-		})
+		out = append(out, screenResponse(s, t.presence, now))
+	}
+
+	return out, nil
+}
+
+// GET /api/admin/screens/status?status=offline
+// Summarizes the organization's fleet by heartbeat status; status narrows the screen list.
+func (t *TvController) fleetStatus(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	filter := ctx.Query("status")
+	switch filter {
+	case "", model.ScreenOnline, model.ScreenStale, model.ScreenOffline:
+	default:
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "status must be online, stale or offline"}
 	}
 
+	screens, err := t.store.ListScreens(currentOrganizationID(ctx))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list screens"}
+	}
+
+	now := time.Now()
+	out := packets.FleetStatusResponse{Screens: make([]packets.ScreenResponse, 0, len(screens))}
+	for _, s := range screens {
+		resp := screenResponse(s, t.presence, now)
+		switch resp.Status {
+		case model.ScreenOnline:
+			out.Online++
+		case model.ScreenStale:
+			out.Stale++
+		default:
+			out.Offline++
+		}
+		if filter == "" || resp.Status == filter {
+			out.Screens = append(out.Screens, resp)
+		}
+	}
 	return out, nil
 }
 
@@ -88,23 +197,13 @@ func (t *TvController) createScreen(ctx *gin.Context, user *model.User) (any, *a
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
 	}
 
-	screen, err := t.store.CreateScreen(request.Name, request.Location, user.ID)
+	screen, err := t.store.CreateScreen(request.Name, request.Location, currentOrganizationID(ctx), user.ID)
 	if err != nil {
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not create screen"}
 	}
+	recordAudit(ctx, t.store, currentOrganizationID(ctx), "screen.create", "screen", screen.ID, nil, screen)
 
-	return packets.ScreenResponse{
-		ID:                screen.ID,
-		DeviceID:          screen.DeviceID,
-		ClientInformation: screen.ClientInformation,
-		ClientWidth:       screen.ClientWidth,
-		ClientHeight:      screen.ClientHeight,
-		Name:              screen.Name,
-		Location:          screen.Location,
-		Paired:            screen.Paired,
-		CreatedAt:         screen.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:         screen.UpdatedAt.Format(time.RFC3339),
-	}, nil
+	return screenResponse(screen, t.presence, time.Now()), nil
 }
 
 // GET /api/admin/screens/:id
@@ -121,26 +220,11 @@ func (t *TvController) getScreen(ctx *gin.Context, user *model.User) (any, *api.
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
 	}
 
-	if screen.CreatedBy != user.ID {
-		log.Error().
-			Int("user_id", user.ID).
-			Int("screen_owner", screen.CreatedBy).
-			Msg("forbidden access to screen")
-		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	if apiErr := t.authorizeScreen(ctx, user, screen, model.PermScreensRead); apiErr != nil {
+		return nil, apiErr
 	}
 
-	return packets.ScreenResponse{
-		ID:                screen.ID,
-		DeviceID:          screen.DeviceID,
-		ClientInformation: screen.ClientInformation,
-		ClientWidth:       screen.ClientWidth,
-		ClientHeight:      screen.ClientHeight,
-		Name:              screen.Name,
-		Location:          screen.Location,
-		Paired:            screen.Paired,
-		CreatedAt:         screen.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:         screen.UpdatedAt.Format(time.RFC3339),
-	}, nil
+	return screenResponse(screen, t.presence, time.Now()), nil
 }
 
 // PUT /api/admin/screens/:id
@@ -156,9 +240,9 @@ func (t *TvController) updateScreen(ctx *gin.Context, user *model.User) (any, *a
 		log.Error().Err(err).Int("screen_id", id).Msg("could not retrieve screen by id")
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
 	}
-	if existing.CreatedBy != user.ID {
-		log.Error().Int("screen_id", id).Int("owner", existing.CreatedBy).Int("user_id", user.ID).
-			Msg("user does not own the screen")
+	if existing.OrganizationID != currentOrganizationID(ctx) {
+		log.Error().Int("screen_id", id).Int("screen_org", existing.OrganizationID).Int("user_id", user.ID).
+			Msg("screen belongs to another organization")
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -173,19 +257,9 @@ func (t *TvController) updateScreen(ctx *gin.Context, user *model.User) (any, *a
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not update screen"}
 	}
 	updated, _ := t.store.GetScreenByID(id)
+	recordAudit(ctx, t.store, existing.OrganizationID, "screen.update", "screen", id, existing, updated)
 
-	return packets.ScreenResponse{
-		ID:                updated.ID,
-		DeviceID:          updated.DeviceID,
-		ClientInformation: updated.ClientInformation,
-		ClientWidth:       updated.ClientWidth,
-		ClientHeight:      updated.ClientHeight,
-		Name:              updated.Name,
-		Location:          updated.Location,
-		Paired:            updated.Paired,
-		CreatedAt:         updated.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:         updated.UpdatedAt.Format(time.RFC3339),
-	}, nil
+	return screenResponse(updated, t.presence, time.Now()), nil
 }
 
 // DELETE /api/admin/screens/:id
@@ -201,19 +275,23 @@ func (t *TvController) deleteScreen(ctx *gin.Context, user *model.User) (any, *a
 		log.Error().Err(err).Int("screen_id", id).Msg("screen not found during DELETE")
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
 	}
-	if existing.CreatedBy != user.ID {
+	if existing.OrganizationID != currentOrganizationID(ctx) {
 		log.Error().
 			Int("user_id", user.ID).
-			Int("screen_created_by", existing.CreatedBy).
+			Int("screen_org", existing.OrganizationID).
 			Int("screen_id", existing.ID).
-			Msg("permission denied: user does not own the screen")
+			Msg("permission denied: screen belongs to another organization")
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
+	// the screenshot rows go with the screen, but their files have to be removed here
+	screenshots, _ := t.store.ListScreenshots(id)
 	if err := t.store.DeleteScreen(id); err != nil {
 		log.Error().Err(err).Int("screen_id", id).Msg("could not delete screen")
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not delete screen"}
 	}
+	recordAudit(ctx, t.store, existing.OrganizationID, "screen.delete", "screen", id, existing, nil)
+	go deleteScreenshotFiles(t.storage, screenshots)
 
 	return nil, nil
 }
@@ -231,9 +309,9 @@ func (t *TvController) assignScreenToUser(ctx *gin.Context, user *model.User) (a
 		log.Error().Err(err).Int("screen_id", screenID).Msg("screen not found during assign")
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
 	}
-	if existing.CreatedBy != user.ID {
+	if existing.OrganizationID != currentOrganizationID(ctx) {
 		log.Warn().Int("screen_id", screenID).Int("requesting_user_id", user.ID).
-			Int("screen_owner_id", existing.CreatedBy).Msg("unauthorized screen assignment attempt")
+			Int("screen_org", existing.OrganizationID).Msg("unauthorized screen assignment attempt")
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -243,15 +321,84 @@ func (t *TvController) assignScreenToUser(ctx *gin.Context, user *model.User) (a
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
 	}
 
-	if err := t.store.AssignScreenToUser(screenID, req.UserID); err != nil {
+	if _, err := t.store.GetOrganizationMembership(existing.OrganizationID, req.UserID); err != nil {
+		log.Warn().Int("screen_id", screenID).Int("target_user_id", req.UserID).
+			Msg("cannot assign screen to user outside its organization")
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "user is not a member of this organization"}
+	}
+
+	if err := t.store.AssignScreenToUser(screenID, req.UserID, user.ID); err != nil {
 		log.Error().Err(err).Int("screen_id", screenID).Int("target_user_id", req.UserID).
 			Msg("failed to assign screen to user")
 		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not assign screen"}
 	}
+	recordAudit(ctx, t.store, existing.OrganizationID, "screen.assign_user", "screen", screenID,
+		nil, gin.H{"user_id": req.UserID})
 
 	return nil, nil
 }
 
+// GET /api/admin/screens/:id/assignments
+func (t *TvController) listScreenAssignments(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screenID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+
+	screen, err := t.store.GetScreenByID(screenID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
+	}
+	if screen.OrganizationID != currentOrganizationID(ctx) {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+
+	assignments, err := t.store.ListScreenAssignments(screenID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list assignments"}
+	}
+
+	out := make([]packets.ScreenAssignmentResponse, 0, len(assignments))
+	for _, a := range assignments {
+		out = append(out, packets.ScreenAssignmentResponse{
+			ScreenID:   a.ScreenID,
+			UserID:     a.UserID,
+			Email:      a.Email,
+			Name:       a.Name,
+			AssignedBy: a.AssignedBy,
+			AssignedAt: a.AssignedAt.Format(time.RFC3339),
+		})
+	}
+	return out, nil
+}
+
+// DELETE /api/admin/screens/:id/assignments/:user_id
+func (t *TvController) unassignScreenFromUser(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screenID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+	targetID, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid user id"}
+	}
+
+	screen, err := t.store.GetScreenByID(screenID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
+	}
+	if screen.OrganizationID != currentOrganizationID(ctx) {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+
+	if err := t.store.UnassignScreenFromUser(screenID, targetID); err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "assignment not found"}
+	}
+	recordAudit(ctx, t.store, screen.OrganizationID, "screen.unassign_user", "screen", screenID,
+		gin.H{"user_id": targetID}, nil)
+	return gin.H{"unassigned": true}, nil
+}
+
 // GET /api/admin/screens/:id/playlist
 func (t *TvController) getPlaylistForScreen(ctx *gin.Context, user *model.User) (any, *api.APIError) {
 	screenID, err := strconv.Atoi(ctx.Param("id"))
@@ -267,10 +414,8 @@ func (t *TvController) getPlaylistForScreen(ctx *gin.Context, user *model.User)
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
 	}
 
-	if existingScreen.CreatedBy != user.ID {
-		log.Warn().Int("user_id", user.ID).Int("screen_id", screenID).
-			Msg("user attempted to access playlist for non-owned screen")
-		return nil, &api.APIError{Code: http.StatusUnauthorized, Message: "unauthorized"}
+	if apiErr := t.authorizeScreen(ctx, user, existingScreen, model.PermScreensRead); apiErr != nil {
+		return nil, apiErr
 	}
 
 	playlist, err := t.store.GetPlaylistForScreen(screenID)
@@ -301,11 +446,8 @@ func (t *TvController) assignPlaylistToScreen(ctx *gin.Context, user *model.User
 			Msg("screen not found during playlist assignment")
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
 	}
-	if existingScreen.CreatedBy != user.ID {
-		log.Warn().Int("screen_id", screenID).Int("requesting_user_id", user.ID).
-			Int("screen_owner_id", existingScreen.CreatedBy).Str("route", ctx.FullPath()).
-			Msg("unauthorized playlist assignment attempt")
-		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	if apiErr := t.authorizeScreen(ctx, user, existingScreen, model.PermScreensPublish); apiErr != nil {
+		return nil, apiErr
 	}
 
 	var request packets.AssignPlaylistToScreenRequest
@@ -320,10 +462,10 @@ func (t *TvController) assignPlaylistToScreen(ctx *gin.Context, user *model.User
 			Msg("playlist id not found during assignment")
 		return nil, &api.APIError{Code: http.StatusNotFound, Message: "playlist not found"}
 	}
-	if existingPlaylist.CreatedBy != user.ID {
-		log.Warn().Int("requesting_user_id", user.ID).Int("playlist_owner_id", existingPlaylist.CreatedBy).
+	if existingPlaylist.OrganizationID != existingScreen.OrganizationID {
+		log.Warn().Int("requesting_user_id", user.ID).Int("playlist_org", existingPlaylist.OrganizationID).
 			Int("playlist_id", existingPlaylist.ID).Str("route", ctx.FullPath()).
-			Msg("unauthorized attempt to assign playlist: not owner")
+			Msg("unauthorized attempt to assign playlist: belongs to another organization")
 		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
 	}
 
@@ -338,65 +480,317 @@ func (t *TvController) assignPlaylistToScreen(ctx *gin.Context, user *model.User
 
 	// Invalidate ETag cache for both old and new playlists since assignments changed
 	if oldErr == nil {
-		oldEtagKey := fmt.Sprintf("playlist:%d:etag", oldPlaylist.ID)
-		if err := redis.Rdb.Del(ctx, oldEtagKey).Err(); err != nil {
-			log.Warn().Err(err).Int("old_playlist_id", oldPlaylist.ID).Str("etag_key", oldEtagKey).
-				Msg("failed to invalidate old playlist ETag cache after assignment")
-		} else {
-			log.Debug().Int("old_playlist_id", oldPlaylist.ID).Str("etag_key", oldEtagKey).
-				Msg("invalidated old playlist ETag cache after assignment")
-		}
-	}
-
-	// Invalidate ETag for the new playlist
-	newEtagKey := fmt.Sprintf("playlist:%d:etag", request.PlaylistID)
-	if err := redis.Rdb.Del(ctx, newEtagKey).Err(); err != nil {
-		log.Warn().Err(err).Int("new_playlist_id", request.PlaylistID).Str("etag_key", newEtagKey).
-			Msg("failed to invalidate new playlist ETag cache after assignment")
-	} else {
-		log.Debug().Err(err).Int("new_playlist_id", request.PlaylistID).Str("etag_key", newEtagKey).
-			Msg("invalidated new playlist ETag cache after assignment")
+		invalidatePlaylistETag(oldPlaylist.ID)
 	}
+	invalidatePlaylistETag(request.PlaylistID)
+	go pushContentChanged(screenDevice(existingScreen), model.ContentChangedPlaylistAssigned, &request.PlaylistID)
 
 	log.Info().Int("screen_id", screenID).Int("playlist_id", request.PlaylistID).
 		Msg("successfully assigned playlist to screen")
 
+	var previous any
+	if oldErr == nil {
+		previous = gin.H{"playlist_id": oldPlaylist.ID}
+	}
+	recordAudit(ctx, t.store, existingScreen.OrganizationID, "screen.assign_playlist", "screen", screenID,
+		previous, gin.H{"playlist_id": request.PlaylistID})
+
 	return gin.H{"message": "playlist assigned successfully"}, nil
 }
 
 // POST /api/admin/screens/pair
-func (t *TvController) pairScreen(ctx *gin.Context, _ *model.User) (any, *api.APIError) {
+// Binds the device that registered the pairing code to one of the organization's screens.
+// Codes are single use: claiming one deletes it, whether or not pairing then succeeds.
+func (t *TvController) pairScreen(ctx *gin.Context, user *model.User) (any, *api.APIError) {
 	var request packets.PairScreenRequest
-	var pairingData PairingData
-
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		log.Error().Err(err).Str("route", ctx.FullPath()).Msg("invalid JSON in screen pairing request")
 		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
 	}
 
-	key := request.PairingCode
-	redis.GetUnmarshalledJSON(ctx, key, &pairingData)
-	deviceID := pairingData.DeviceID
+	if apiErr := t.checkPairingGuesses(ctx, user); apiErr != nil {
+		return nil, apiErr
+	}
 
-	pairingData.IsPaired = true
-	updatedPairingData, _ := json.Marshal(pairingData)
-	redis.Rdb.Set(ctx, key, updatedPairingData, 7*24*time.Hour)
+	before, err := t.store.GetScreenByID(request.ScreenID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
+	}
+	if before.OrganizationID != currentOrganizationID(ctx) {
+		log.Warn().Int("user_id", user.ID).Int("screen_id", before.ID).Int("screen_org", before.OrganizationID).
+			Msg("attempt to pair a screen of another organization")
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+	if before.Paired {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "screen is already paired"}
+	}
 
-	// Assign the deviceID to the screen in database
-	if err := t.store.AssignDeviceIDToScreen(request.ScreenID, &deviceID); err != nil {
-		log.Error().Err(err).Int("screen_id", request.ScreenID).Str("device_id", deviceID).
-			Str("route", ctx.FullPath()).Msg("failed to assign device ID to screen during pairing")
-		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not update screen device ID"}
+	deviceID, apiErr := t.claimPairingCode(ctx, user, request.PairingCode)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if paired, err := t.store.IsScreenPairedByDeviceID(&deviceID); err != nil || paired {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "device is already paired to another screen"}
 	}
 
-	if err := t.store.PairScreen(request.ScreenID); err != nil {
-		log.Error().Err(err).Int("screen_id", request.ScreenID).Str("route", ctx.FullPath()).
-			Msg("failed to mark screen as paired in database")
-		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not update screen"}
+	if apiErr := t.bindDevice(ctx, user, request.ScreenID, deviceID, request.PairingCode); apiErr != nil {
+		return nil, apiErr
 	}
 
 	log.Info().Str("device_id", deviceID).Int("screen_id", request.ScreenID).
-		Msg("successfully paired screen and stored device mapping in Redis")
+		Msg("successfully paired screen")
+
+	after, _ := t.store.GetScreenByID(request.ScreenID)
+	recordAudit(ctx, t.store, before.OrganizationID, "screen.pair", "screen", request.ScreenID, before, after)
 
 	return gin.H{"success": "screen paired successfully"}, nil
 }
+
+// checkPairingGuesses refuses callers that have guessed too many unknown pairing codes, so a
+// blocked caller learns nothing even from a valid one.
+func (t *TvController) checkPairingGuesses(ctx *gin.Context, user *model.User) *api.APIError {
+	if blocked, retryAfter := middleware.PairingGuessesExceeded(ctx, pairingCaller(user)); blocked {
+		ctx.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		return &api.APIError{Code: http.StatusTooManyRequests, Message: "too many unknown pairing codes, try again later"}
+	}
+	return nil
+}
+
+// claimPairingCode consumes the code and returns the device that registered it.
+func (t *TvController) claimPairingCode(ctx *gin.Context, user *model.User, code string) (string, *api.APIError) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	raw, err := redis.Rdb.GetDel(ctx, middleware.PairingKey(code)).Bytes()
+	var pairingData middleware.PairingData
+	if err != nil || json.Unmarshal(raw, &pairingData) != nil || pairingData.DeviceID == "" {
+		middleware.RecordPairingMiss(ctx, pairingCaller(user))
+		log.Warn().Int("user_id", user.ID).Str("route", ctx.FullPath()).Msg("unknown or expired pairing code")
+		return "", &api.APIError{Code: http.StatusNotFound, Message: "unknown or expired pairing code"}
+	}
+	return pairingData.DeviceID, nil
+}
+
+// bindDevice pairs the screen with the device, closing out any device bound before it, and
+// leaves a fresh token for the device to collect with its next ping.
+func (t *TvController) bindDevice(ctx *gin.Context, user *model.User, screenID int, deviceID, code string) *api.APIError {
+	token, hash, err := middleware.GenerateDeviceToken()
+	if err != nil {
+		return &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate device token"}
+	}
+	if err := t.store.BindDeviceToScreen(screenID, deviceID, hash, user.ID); err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Str("device_id", deviceID).
+			Str("route", ctx.FullPath()).Msg("failed to bind device to screen")
+		return &api.APIError{Code: http.StatusInternalServerError, Message: "could not update screen"}
+	}
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	delivery, _ := json.Marshal(middleware.DeviceTokenDelivery{DeviceID: deviceID, DeviceToken: token})
+	redis.Set(ctx, middleware.DeviceTokenDeliveryKey(code), delivery, middleware.PairingCodeTTL)
+	return nil
+}
+
+func pairingCaller(user *model.User) string {
+	return "user:" + strconv.Itoa(user.ID)
+}
+
+// issueDeviceToken stores a new device token for the screen, replacing any previous one.
+func (t *TvController) issueDeviceToken(screenID int) (string, *api.APIError) {
+	token, hash, err := middleware.GenerateDeviceToken()
+	if err != nil {
+		return "", &api.APIError{Code: http.StatusInternalServerError, Message: "could not generate device token"}
+	}
+	if err := t.store.SetScreenDeviceToken(screenID, &hash); err != nil {
+		log.Error().Err(err).Int("screen_id", screenID).Msg("failed to store device token")
+		return "", &api.APIError{Code: http.StatusInternalServerError, Message: "could not store device token"}
+	}
+	return token, nil
+}
+
+// POST /api/admin/screens/:id/device_token
+// Replaces the device's credential. The new token is returned once and must be installed on
+// the device; the old token stops working immediately.
+func (t *TvController) rotateDeviceToken(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screenID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+
+	screen, err := t.store.GetScreenByID(screenID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
+	}
+	if screen.OrganizationID != currentOrganizationID(ctx) {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+	if screen.DeviceID == nil || !screen.Paired {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "screen is not paired"}
+	}
+
+	token, apiErr := t.issueDeviceToken(screenID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	middleware.DisconnectTV(*screen.DeviceID)
+	recordAudit(ctx, t.store, screen.OrganizationID, "screen.rotate_device_token", "screen", screenID, nil, nil)
+
+	return packets.DeviceTokenResponse{ScreenID: screenID, DeviceToken: token}, nil
+}
+
+// DELETE /api/admin/screens/:id/device_token
+func (t *TvController) revokeDeviceToken(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screenID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+
+	screen, err := t.store.GetScreenByID(screenID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
+	}
+	if screen.OrganizationID != currentOrganizationID(ctx) {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+
+	if err := t.store.SetScreenDeviceToken(screenID, nil); err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not revoke device token"}
+	}
+	if screen.DeviceID != nil {
+		middleware.DisconnectTV(*screen.DeviceID)
+	}
+	recordAudit(ctx, t.store, screen.OrganizationID, "screen.revoke_device_token", "screen", screenID, nil, nil)
+
+	return gin.H{"revoked": true}, nil
+}
+
+// POST /api/admin/screens/:id/unpair
+// Releases the screen's device: its token is revoked, its MQTT session dropped, and the screen
+// waits to be paired again.
+func (t *TvController) unpairScreen(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screenID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+
+	before, err := t.store.GetScreenByID(screenID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
+	}
+	if before.OrganizationID != currentOrganizationID(ctx) {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+
+	if err := t.store.UnbindScreenDevice(screenID, user.ID, model.UnbindReasonUnpaired); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &api.APIError{Code: http.StatusConflict, Message: "screen is not paired"}
+		}
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not unpair screen"}
+	}
+	if before.DeviceID != nil {
+		middleware.DisconnectTV(*before.DeviceID)
+	}
+
+	log.Info().Int("screen_id", screenID).Int("user_id", user.ID).Msg("unpaired screen")
+
+	after, _ := t.store.GetScreenByID(screenID)
+	recordAudit(ctx, t.store, before.OrganizationID, "screen.unpair", "screen", screenID, before, after)
+
+	return gin.H{"unpaired": true}, nil
+}
+
+// POST /api/admin/screens/:id/replace-device
+// Swaps the device behind a paired screen for the one that registered the given pairing code.
+// The old device loses its token and MQTT session.
+func (t *TvController) replaceDevice(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screenID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+
+	var request packets.ReplaceDeviceRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		log.Error().Err(err).Str("route", ctx.FullPath()).Msg("invalid JSON in replace device request")
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	if apiErr := t.checkPairingGuesses(ctx, user); apiErr != nil {
+		return nil, apiErr
+	}
+
+	before, err := t.store.GetScreenByID(screenID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
+	}
+	if before.OrganizationID != currentOrganizationID(ctx) {
+		log.Warn().Int("user_id", user.ID).Int("screen_id", before.ID).Int("screen_org", before.OrganizationID).
+			Msg("attempt to replace the device of another organization's screen")
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+	if !before.Paired || before.DeviceID == nil {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "screen is not paired"}
+	}
+
+	deviceID, apiErr := t.claimPairingCode(ctx, user, request.PairingCode)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	// re-pairing the screen's own device (e.g. after a factory reset) is allowed
+	if deviceID != *before.DeviceID {
+		if paired, err := t.store.IsScreenPairedByDeviceID(&deviceID); err != nil || paired {
+			return nil, &api.APIError{Code: http.StatusConflict, Message: "device is already paired to another screen"}
+		}
+	}
+
+	if apiErr := t.bindDevice(ctx, user, screenID, deviceID, request.PairingCode); apiErr != nil {
+		return nil, apiErr
+	}
+	// a re-paired device is already using its new session, which a revocation would end
+	if deviceID != *before.DeviceID {
+		middleware.DisconnectTV(*before.DeviceID)
+	}
+
+	log.Info().Int("screen_id", screenID).Str("old_device_id", *before.DeviceID).Str("device_id", deviceID).
+		Msg("replaced screen device")
+
+	after, _ := t.store.GetScreenByID(screenID)
+	recordAudit(ctx, t.store, before.OrganizationID, "screen.replace_device", "screen", screenID, before, after)
+
+	return gin.H{"success": "screen device replaced successfully"}, nil
+}
+
+// GET /api/admin/screens/:id/devices
+// Lists every device that has been bound to the screen, most recent first.
+func (t *TvController) listScreenDevices(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screenID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+
+	screen, err := t.store.GetScreenByID(screenID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
+	}
+	if screen.OrganizationID != currentOrganizationID(ctx) {
+		return nil, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+
+	devices, err := t.store.ListScreenDevices(screenID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list screen devices"}
+	}
+
+	out := make([]packets.ScreenDeviceResponse, 0, len(devices))
+	for _, d := range devices {
+		resp := packets.ScreenDeviceResponse{
+			DeviceID:     d.DeviceID,
+			BoundBy:      d.BoundBy,
+			BoundAt:      d.BoundAt.Format(time.RFC3339),
+			UnboundBy:    d.UnboundBy,
+			UnbindReason: d.UnbindReason,
+		}
+		if d.UnboundAt != nil {
+			unboundAt := d.UnboundAt.Format(time.RFC3339)
+			resp.UnboundAt = &unboundAt
+		}
+		out = append(out, resp)
+	}
+	return out, nil
+}
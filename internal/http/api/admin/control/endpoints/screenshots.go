@@ -0,0 +1,155 @@
+package endpoints
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+	"github.com/Nixie-Tech-LLC/medusa/internal/storage"
+)
+
+func mapScreenshot(s model.Screenshot) packets.ScreenshotResponse {
+	return packets.ScreenshotResponse{
+		ID:          s.ID,
+		ScreenID:    s.ScreenID,
+		CommandID:   s.CommandID,
+		URL:         fmt.Sprintf("/api/admin/screens/%d/screenshots/%d/image", s.ScreenID, s.ID),
+		ContentType: s.ContentType,
+		SizeBytes:   s.SizeBytes,
+		CapturedAt:  s.CapturedAt.Format(time.RFC3339),
+	}
+}
+
+// deleteScreenshotFiles removes the stored images of screenshots whose rows are gone.
+func deleteScreenshotFiles(store storage.Storage, screenshots []model.Screenshot) {
+	for _, s := range screenshots {
+		if err := store.DeleteFile(s.URL); err != nil {
+			log.Warn().Err(err).Int("screenshot_id", s.ID).Msg("could not delete screenshot file")
+		}
+	}
+}
+
+// screenForScreenshots loads the screen named in the path and checks it belongs to the
+// current organization.
+func (t *TvController) screenForScreenshots(ctx *gin.Context) (model.Screen, *api.APIError) {
+	screenID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return model.Screen{}, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+
+	screen, err := t.store.GetScreenByID(screenID)
+	if err != nil {
+		return model.Screen{}, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
+	}
+	if screen.OrganizationID != currentOrganizationID(ctx) {
+		return model.Screen{}, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+	return screen, nil
+}
+
+// POST /api/admin/screens/:id/screenshots
+// Asks the screen's device to capture a screenshot by sending it a take_screenshot command.
+// The device uploads the image with the command's ID, which also acknowledges the command.
+func (t *TvController) requestScreenshot(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screen, apiErr := t.screenForScreenshots(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !screen.Paired || screen.DeviceID == nil {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "screen is not paired"}
+	}
+
+	command, apiErr := issueCommand(ctx, t.store, user, screen, model.CommandTakeScreenshot, nil)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return mapCommand(command, time.Now()), nil
+}
+
+// GET /api/admin/screens/:id/screenshots
+// Lists the screenshots kept for the screen, newest first.
+func (t *TvController) listScreenshots(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screen, apiErr := t.screenForScreenshots(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	screenshots, err := t.store.ListScreenshots(screen.ID)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list screenshots"}
+	}
+
+	out := make([]packets.ScreenshotResponse, 0, len(screenshots))
+	for _, s := range screenshots {
+		out = append(out, mapScreenshot(s))
+	}
+	return out, nil
+}
+
+// GET /api/admin/screens/:id/screenshots/:screenshot_id/image
+// Streams the image itself. Screenshots are stored privately, so this is the only way to
+// view one; it is the url in the screenshot listing.
+func (t *TvController) getScreenshotImage(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screen, apiErr := t.screenForScreenshots(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	screenshotID, err := strconv.Atoi(ctx.Param("screenshot_id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid screenshot id"}
+	}
+
+	shot, err := t.store.GetScreenshot(screen.ID, screenshotID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screenshot not found"}
+	}
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not get screenshot"}
+	}
+
+	image, err := t.storage.OpenFile(shot.URL)
+	if err != nil {
+		log.Error().Err(err).Int("screenshot_id", shot.ID).Msg("could not open screenshot file")
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screenshot image not found"}
+	}
+	defer image.Close()
+
+	// images of the screen's content must not outlive the session in shared caches
+	ctx.Header("Cache-Control", "private, max-age=300")
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.DataFromReader(http.StatusOK, shot.SizeBytes, shot.ContentType, image, nil)
+	return nil, nil
+}
+
+// DELETE /api/admin/screens/:id/screenshots/:screenshot_id
+func (t *TvController) deleteScreenshot(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screen, apiErr := t.screenForScreenshots(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	screenshotID, err := strconv.Atoi(ctx.Param("screenshot_id"))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "invalid screenshot id"}
+	}
+
+	deleted, err := t.store.DeleteScreenshot(screen.ID, screenshotID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screenshot not found"}
+	}
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not delete screenshot"}
+	}
+	deleteScreenshotFiles(t.storage, []model.Screenshot{deleted})
+	recordAudit(ctx, t.store, screen.OrganizationID, "screen.screenshot.delete", "screen", screen.ID, deleted, nil)
+
+	return nil, nil
+}
@@ -0,0 +1,189 @@
+package endpoints
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+const (
+	defaultTelemetryPeriod = 24 * time.Hour
+	maxTelemetryPeriod     = 90 * 24 * time.Hour
+
+	// a history is split into about this many buckets unless a bucket width is given
+	telemetryTargetBuckets = 288
+	minTelemetryBucket     = time.Minute
+	maxTelemetryBuckets    = 2000
+
+	// screens silent for longer are left out of the fleet's latest telemetry
+	latestTelemetryWindow = 7 * 24 * time.Hour
+)
+
+type TelemetryController struct {
+	store db.Store
+}
+
+func newTelemetryController(store db.Store) *TelemetryController {
+	return &TelemetryController{store: store}
+}
+
+// TelemetryModule mounts the endpoints that expose the telemetry devices send with
+// POST /api/tv/report.
+func TelemetryModule(store db.Store) api.Module {
+	ctl := newTelemetryController(store)
+	return api.ModuleFunc(func(c *api.Controller) {
+		c.GET("/screens/telemetry/latest", ctl.fleetTelemetry, model.PermScreensRead)
+		c.GET("/screens/:id/telemetry/latest", ctl.latestTelemetry, model.PermScreensRead)
+		c.GET("/screens/:id/telemetry", ctl.telemetryHistory, model.PermScreensRead)
+	})
+}
+
+func mapTelemetry(s model.TelemetrySample) packets.TelemetryResponse {
+	return packets.TelemetryResponse{
+		ScreenID:           s.ScreenID,
+		RecordedAt:         s.RecordedAt.Format(time.RFC3339),
+		IPAddress:          s.IPAddress,
+		CPUPercent:         s.CPUPercent,
+		MemoryUsedBytes:    s.MemoryUsedBytes,
+		MemoryTotalBytes:   s.MemoryTotalBytes,
+		TemperatureCelsius: s.TemperatureCelsius,
+		StorageUsedBytes:   s.StorageUsedBytes,
+		StorageTotalBytes:  s.StorageTotalBytes,
+		NetworkType:        s.NetworkType,
+		SignalDBM:          s.SignalDBM,
+		RxBytesPerSecond:   s.RxBytesPerSecond,
+		TxBytesPerSecond:   s.TxBytesPerSecond,
+	}
+}
+
+// screenForTelemetry loads the screen named in the path and checks it belongs to the
+// current organization.
+func (tc *TelemetryController) screenForTelemetry(ctx *gin.Context) (model.Screen, *api.APIError) {
+	screenID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return model.Screen{}, &api.APIError{Code: http.StatusBadRequest, Message: "invalid id"}
+	}
+
+	screen, err := tc.store.GetScreenByID(screenID)
+	if err != nil {
+		return model.Screen{}, &api.APIError{Code: http.StatusNotFound, Message: "screen not found"}
+	}
+	if screen.OrganizationID != currentOrganizationID(ctx) {
+		return model.Screen{}, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+	return screen, nil
+}
+
+// GET /api/admin/screens/telemetry/latest
+// Returns the latest sample of every screen that has reported in the last 7 days.
+func (tc *TelemetryController) fleetTelemetry(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	samples, err := tc.store.ListLatestTelemetry(currentOrganizationID(ctx), time.Now().Add(-latestTelemetryWindow))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not list telemetry"}
+	}
+
+	out := make([]packets.TelemetryResponse, 0, len(samples))
+	for _, s := range samples {
+		out = append(out, mapTelemetry(s))
+	}
+	return out, nil
+}
+
+// GET /api/admin/screens/:id/telemetry/latest
+func (tc *TelemetryController) latestTelemetry(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screen, apiErr := tc.screenForTelemetry(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	sample, err := tc.store.GetLatestScreenTelemetry(screen.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "screen has not reported telemetry"}
+	}
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not get telemetry"}
+	}
+	return mapTelemetry(sample), nil
+}
+
+// GET /api/admin/screens/:id/telemetry?from=&to=&bucket=
+// Aggregates the screen's telemetry into buckets of bucket seconds for charting. The period
+// defaults to the last 24 hours; without a bucket width it is split into about 288 buckets.
+func (tc *TelemetryController) telemetryHistory(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	screen, apiErr := tc.screenForTelemetry(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	var query packets.TelemetryHistoryQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	to := time.Now()
+	if query.To != nil {
+		to = *query.To
+	}
+	from := to.Add(-defaultTelemetryPeriod)
+	if query.From != nil {
+		from = *query.From
+	}
+	if !to.After(from) {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "to must be after from"}
+	}
+	if to.Sub(from) > maxTelemetryPeriod {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "telemetry period is limited to 90 days"}
+	}
+
+	bucket := (to.Sub(from) / telemetryTargetBuckets).Truncate(time.Minute)
+	if query.Bucket != nil {
+		bucket = time.Duration(*query.Bucket) * time.Second
+	}
+	if bucket < minTelemetryBucket {
+		bucket = minTelemetryBucket
+	}
+	if to.Sub(from)/bucket > maxTelemetryBuckets {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "bucket is too small for the period"}
+	}
+
+	buckets, err := tc.store.ScreenTelemetryHistory(screen.ID, from, to, bucket)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not load telemetry"}
+	}
+
+	resp := packets.TelemetryHistoryResponse{
+		ScreenID:      screen.ID,
+		From:          from.Format(time.RFC3339),
+		To:            to.Format(time.RFC3339),
+		BucketSeconds: int(bucket / time.Second),
+		Timestamps:    make([]string, 0, len(buckets)),
+		Samples:       make([]int, 0, len(buckets)),
+		Series:        map[string][]*float64{},
+	}
+	series := func(name string, v *float64) {
+		resp.Series[name] = append(resp.Series[name], v)
+	}
+	for _, b := range buckets {
+		resp.Timestamps = append(resp.Timestamps, b.Start.Format(time.RFC3339))
+		resp.Samples = append(resp.Samples, b.Samples)
+		series("cpu_percent", b.CPUPercent)
+		series("memory_used_bytes", b.MemoryUsedBytes)
+		series("memory_total_bytes", b.MemoryTotalBytes)
+		series("temperature_celsius", b.TemperatureCelsius)
+		series("storage_used_bytes", b.StorageUsedBytes)
+		series("storage_total_bytes", b.StorageTotalBytes)
+		series("signal_dbm", b.SignalDBM)
+		series("rx_bytes_per_second", b.RxBytesPerSecond)
+		series("tx_bytes_per_second", b.TxBytesPerSecond)
+	}
+	return resp, nil
+}
@@ -0,0 +1,328 @@
+package endpoints
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+	"github.com/Nixie-Tech-LLC/medusa/internal/storage"
+)
+
+const (
+	maxUploadBytes = 50 << 30
+	maxUploadChunk = 64 << 20
+	// S3 multipart uploads take at most this many parts
+	maxUploadParts = 10000
+	// an upload is abandoned once no chunk has arrived for this long
+	uploadExpiry = 24 * time.Hour
+	// a chunk still being written after this long was left behind by a request that died
+	uploadWriteTimeout = 10 * time.Minute
+)
+
+func mapUpload(u model.ContentUpload) packets.UploadResponse {
+	return packets.UploadResponse{
+		ID:            u.ID,
+		Offset:        u.ReceivedBytes,
+		SizeBytes:     u.SizeBytes,
+		MinChunkBytes: storage.MinUploadPart,
+		MaxChunkBytes: maxUploadChunk,
+		ExpiresAt:     u.ExpiresAt.Format(time.RFC3339),
+	}
+}
+
+// uploadHash resumes the running SHA-256 of an upload from its saved state.
+func uploadHash(u model.ContentUpload) (hash.Hash, error) {
+	h := sha256.New()
+	if u.HashState != nil {
+		if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(u.HashState); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// uploadForRequest loads the upload named in the path and checks it belongs to the current
+// organization.
+func (c *ContentController) uploadForRequest(ctx *gin.Context) (model.ContentUpload, *api.APIError) {
+	upload, err := c.store.GetContentUpload(ctx.Param("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ContentUpload{}, &api.APIError{Code: http.StatusNotFound, Message: "upload not found"}
+	}
+	if err != nil {
+		return model.ContentUpload{}, &api.APIError{Code: http.StatusInternalServerError, Message: "could not get upload"}
+	}
+	if upload.OrganizationID != currentOrganizationID(ctx) {
+		return model.ContentUpload{}, &api.APIError{Code: http.StatusForbidden, Message: "forbidden"}
+	}
+	return upload, nil
+}
+
+// POST /api/admin/content/uploads
+// Starts a resumable upload for a file too large to send in one request. The client then
+// PUTs the file in order, one chunk per request, and completes the upload to create the content.
+func (c *ContentController) beginUpload(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	var req packets.CreateUploadRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	if req.SizeBytes > maxUploadBytes {
+		return nil, &api.APIError{Code: http.StatusRequestEntityTooLarge, Message: "file is too large"}
+	}
+
+	orgID := currentOrganizationID(ctx)
+	id := uuid.NewString()
+	pending, err := c.storage.BeginUpload(orgID, id, req.Filename, req.ContentType)
+	if err != nil {
+		log.Error().Err(err).Msg("[content] beginUpload: storage failed")
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not start upload"}
+	}
+
+	upload := model.ContentUpload{
+		ID:             id,
+		OrganizationID: orgID,
+		CreatedBy:      user.ID,
+		Name:           req.Name,
+		Type:           req.Type,
+		Width:          req.Width,
+		Height:         req.Height,
+		Filename:       req.Filename,
+		SizeBytes:      req.SizeBytes,
+		StorageKey:     pending.Key,
+		ExpiresAt:      time.Now().Add(uploadExpiry),
+	}
+	if req.ContentType != "" {
+		upload.MimeType = &req.ContentType
+	}
+	if pending.UploadID != "" {
+		upload.StorageUploadID = &pending.UploadID
+	}
+	upload, err = c.store.CreateContentUpload(upload)
+	if err != nil {
+		_ = c.storage.AbortUpload(pending)
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not start upload"}
+	}
+	recordAuditKey(ctx, c.store, orgID, "content_upload.begin", "content_upload", upload.ID, nil, upload)
+	return mapUpload(upload), nil
+}
+
+// GET /api/admin/content/uploads/:id
+// Returns the offset to resume from after an interrupted chunk.
+func (c *ContentController) getUpload(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	upload, apiErr := c.uploadForRequest(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return mapUpload(upload), nil
+}
+
+// PUT /api/admin/content/uploads/:id
+// The body is the next chunk's raw bytes and the Upload-Offset header where it starts, which
+// must be the upload's offset. Chunks other than the last must be at least min_chunk_bytes.
+func (c *ContentController) uploadChunk(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	upload, apiErr := c.uploadForRequest(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	offset, err := strconv.ParseInt(ctx.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "Upload-Offset header is required"}
+	}
+	if upload.Status == model.UploadCompleting {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "upload is being completed"}
+	}
+	if offset != upload.ReceivedBytes {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: fmt.Sprintf("upload is at offset %d", upload.ReceivedBytes)}
+	}
+
+	chunk, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxUploadChunk+1))
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "could not read chunk"}
+	}
+	if len(chunk) > maxUploadChunk {
+		return nil, &api.APIError{Code: http.StatusRequestEntityTooLarge, Message: "chunk is too large"}
+	}
+	if len(chunk) == 0 {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "chunk is empty"}
+	}
+	end := offset + int64(len(chunk))
+	if end > upload.SizeBytes {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "chunk runs past the end of the file"}
+	}
+	last := end == upload.SizeBytes
+	if !last && len(chunk) < storage.MinUploadPart {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "only the last chunk may be smaller than min_chunk_bytes"}
+	}
+	part := upload.Parts + 1
+	if !last && part >= maxUploadParts {
+		return nil, &api.APIError{Code: http.StatusBadRequest, Message: "chunks are too small to send the file in 10000 parts"}
+	}
+
+	// claim the offset before writing, so a concurrent request with the same chunk cannot
+	// overwrite bytes that another request's hash already covers
+	lease := uuid.NewString()
+	upload, err = c.store.ClaimUploadChunk(upload.ID, offset, lease, uploadWriteTimeout)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "upload has moved on, fetch its offset and resume"}
+	}
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not save upload progress"}
+	}
+
+	h, err := uploadHash(upload)
+	if err != nil {
+		log.Error().Err(err).Str("upload_id", upload.ID).Msg("[content] uploadChunk: bad hash state")
+		_ = c.store.ReleaseUploadChunk(upload.ID, lease)
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not resume upload"}
+	}
+	h.Write(chunk)
+	state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		_ = c.store.ReleaseUploadChunk(upload.ID, lease)
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not save upload progress"}
+	}
+	// trust the bytes over the declared type when they are recognised
+	var mimeType *string
+	if offset == 0 {
+		if sniffed := http.DetectContentType(chunk); sniffed != "application/octet-stream" {
+			mimeType = &sniffed
+		}
+	}
+
+	if err := c.storage.WriteUploadPart(storage.PendingUploadFor(upload), part, offset, bytes.NewReader(chunk)); err != nil {
+		log.Error().Err(err).Str("upload_id", upload.ID).Msg("[content] uploadChunk: storage failed")
+		_ = c.store.ReleaseUploadChunk(upload.ID, lease)
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not store chunk"}
+	}
+
+	upload, err = c.store.RecordUploadChunk(upload.ID, lease, end, state, mimeType, time.Now().Add(uploadExpiry))
+	if errors.Is(err, sql.ErrNoRows) {
+		// the write outlived its claim and another request took the chunk over
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "upload has moved on, fetch its offset and resume"}
+	}
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not save upload progress"}
+	}
+	return mapUpload(upload), nil
+}
+
+// POST /api/admin/content/uploads/:id/complete
+// Assembles the uploaded chunks and creates the content, as POST /content does for a
+// single-request upload.
+func (c *ContentController) completeUpload(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	upload, apiErr := c.uploadForRequest(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if upload.ReceivedBytes != upload.SizeBytes {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: fmt.Sprintf("upload is missing %d bytes", upload.SizeBytes-upload.ReceivedBytes)}
+	}
+
+	upload, err := c.store.SetContentUploadStatus(upload.ID, model.UploadUploading, model.UploadCompleting)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "upload is already being completed"}
+	}
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not complete upload"}
+	}
+
+	h, err := uploadHash(upload)
+	if err != nil {
+		_, _ = c.store.SetContentUploadStatus(upload.ID, model.UploadCompleting, model.UploadUploading)
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not complete upload"}
+	}
+	asset := model.ContentAsset{
+		SHA256:    hex.EncodeToString(h.Sum(nil)),
+		SizeBytes: upload.SizeBytes,
+		MimeType:  "application/octet-stream",
+	}
+	if upload.MimeType != nil {
+		asset.MimeType = *upload.MimeType
+	}
+
+	// new content must not pick up a shared copy that is being released as unused
+	unlock, err := c.store.LockContentFile(upload.OrganizationID, asset.SHA256)
+	if err != nil {
+		_, _ = c.store.SetContentUploadStatus(upload.ID, model.UploadCompleting, model.UploadUploading)
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not complete upload"}
+	}
+	defer unlock()
+
+	location, err := c.storage.CompleteUpload(storage.PendingUploadFor(upload), upload.OrganizationID, upload.Filename, asset)
+	if err != nil {
+		log.Error().Err(err).Str("upload_id", upload.ID).Msg("[content] completeUpload: storage failed")
+		// the parts are still there, so the client may retry
+		_, _ = c.store.SetContentUploadStatus(upload.ID, model.UploadCompleting, model.UploadUploading)
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not assemble upload"}
+	}
+
+	// identical files are stored once per organization
+	if existing, err := c.store.FindContentFileBySHA256(upload.OrganizationID, asset.SHA256); err == nil && existing != location {
+		c.deleteUnusedContentFile(model.Content{OrganizationID: upload.OrganizationID, SHA256: &asset.SHA256, URL: location})
+		location = existing
+	}
+
+	content, err := c.store.CreateContent(
+		upload.Name,
+		upload.Type,
+		location,
+		upload.Width,
+		upload.Height,
+		upload.OrganizationID,
+		user.ID,
+		&asset,
+	)
+	// the chunks are gone from storage either way
+	_, _ = c.store.DeleteContentUpload(upload.ID)
+	if err != nil {
+		log.Error().Err(err).Str("upload_id", upload.ID).Msg("[content] completeUpload: db create failed")
+		c.deleteUnusedContentFile(model.Content{OrganizationID: upload.OrganizationID, SHA256: &asset.SHA256, URL: location})
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not create content"}
+	}
+	recordAudit(ctx, c.store, content.OrganizationID, "content.create", "content", content.ID, nil, content)
+
+	return mapContent(content), nil
+}
+
+// DELETE /api/admin/content/uploads/:id
+// Abandons the upload and discards the chunks received so far.
+func (c *ContentController) abortUpload(ctx *gin.Context, user *model.User) (any, *api.APIError) {
+	upload, apiErr := c.uploadForRequest(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if upload.Status == model.UploadCompleting {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "upload is being completed"}
+	}
+	if upload.Status == model.UploadWriting {
+		return nil, &api.APIError{Code: http.StatusConflict, Message: "a chunk is being written"}
+	}
+
+	upload, err := c.store.DeleteContentUpload(upload.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &api.APIError{Code: http.StatusNotFound, Message: "upload not found"}
+	}
+	if err != nil {
+		return nil, &api.APIError{Code: http.StatusInternalServerError, Message: "could not abort upload"}
+	}
+	if err := c.storage.AbortUpload(storage.PendingUploadFor(upload)); err != nil {
+		log.Warn().Err(err).Str("upload_id", upload.ID).Msg("[content] abortUpload: could not discard chunks")
+	}
+	recordAuditKey(ctx, c.store, upload.OrganizationID, "content_upload.abort", "content_upload", upload.ID, upload, nil)
+	return nil, nil
+}
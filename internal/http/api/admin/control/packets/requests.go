@@ -13,6 +13,18 @@ type CreateContentRequest struct {
 	ScreenID *int   `json:"screen_id"`
 }
 
+// CreateUploadRequest starts a chunked upload; the content is created from these fields
+// once the last chunk is in.
+type CreateUploadRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Type        string `json:"type" binding:"required,oneof=image video html"`
+	Width       int    `json:"width" binding:"required,min=1"`
+	Height      int    `json:"height" binding:"required,min=1"`
+	Filename    string `json:"filename" binding:"required"`
+	SizeBytes   int64  `json:"size_bytes" binding:"required,min=1"`
+	ContentType string `json:"content_type"`
+}
+
 type CreateScreenRequest struct {
 	Name     string  `json:"name" binding:"required"`
 	Location *string `json:"location"`
@@ -36,6 +48,10 @@ type PairScreenRequest struct {
 	ScreenID    int    `json:"screen_id" binding:"required"`
 }
 
+type ReplaceDeviceRequest struct {
+	PairingCode string `json:"code" binding:"required"`
+}
+
 type UpdateContentRequest struct {
 	Name   *string `json:"name"`
 	Type   *string `json:"type"`
@@ -121,3 +137,71 @@ type RenameScreenGroupRequest struct {
 type ModifyGroupMembershipRequest struct {
     ScreenID int `json:"screen_id" binding:"required"`
 }
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type UpdateOrganizationSettingsRequest struct {
+	RequireTwoFactor *bool `json:"require_two_factor"`
+}
+
+type AddOrganizationMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role"` // defaults to viewer
+}
+
+type UpdateOrganizationMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// filters for GET /audit; every field is optional
+type ListAuditEventsQuery struct {
+	ActorUserID *int       `form:"actor_user_id"`
+	Action      string     `form:"action"` // "playlist." matches every playlist action
+	EntityType  string     `form:"entity_type"`
+	EntityID    string     `form:"entity_id"`
+	From        *time.Time `form:"from"`
+	To          *time.Time `form:"to"`
+	Limit       int        `form:"limit" binding:"omitempty,min=0"`
+	Offset      int        `form:"offset" binding:"omitempty,min=0"`
+}
+
+type PlayReportQuery struct {
+	GroupBy    string     `form:"group_by"` // comma separated: content, playlist, screen, day
+	From       *time.Time `form:"from"`
+	To         *time.Time `form:"to"`
+	ScreenID   *int       `form:"screen_id"`
+	ContentID  *int       `form:"content_id"`
+	PlaylistID *int       `form:"playlist_id"`
+	Format     string     `form:"format"` // json (default) or csv
+}
+
+type TelemetryHistoryQuery struct {
+	From   *time.Time `form:"from"`
+	To     *time.Time `form:"to"`
+	Bucket *int       `form:"bucket"` // seconds; chosen from the period when omitted
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role"` // defaults to viewer
+}
+
+// exactly one of screen_id and group_id must be set; target is a URL for webhook and
+// slack channels, an address for email
+type AlertRuleRequest struct {
+	Name                string `json:"name" binding:"required"`
+	ScreenID            *int   `json:"screen_id"`
+	GroupID             *int   `json:"group_id"`
+	OfflineAfterSeconds int    `json:"offline_after_seconds"`
+	Channel             string `json:"channel" binding:"required"`
+	Target              string `json:"target" binding:"required"`
+	Enabled             *bool  `json:"enabled"`
+}
+
+// params depend on the type; set_volume takes {"level": 0-100}
+type IssueCommandRequest struct {
+	Type   string          `json:"type" binding:"required"`
+	Params json.RawMessage `json:"params"`
+}
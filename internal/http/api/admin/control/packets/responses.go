@@ -2,17 +2,33 @@ package packets
 
 // RESPONSES FOR /api/tv/screens/*
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Response mirrors model.Content but flattens time.
 type ContentResponse struct {
-	ID        int    `json:"id"`
-	Name      string `json:"name"`
-	Type      string `json:"type"`
-	URL       string `json:"url"`
-	Width     int    `json:"width"`
-	Height    int    `json:"height"`
-	CreatedAt string `json:"created_at"`
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	URL       string  `json:"url"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	SHA256    *string `json:"sha256"`
+	SizeBytes *int64  `json:"size_bytes"`
+	MimeType  *string `json:"mime_type"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// UploadResponse is where a chunked upload stands; the next chunk goes at offset
+type UploadResponse struct {
+	ID            string `json:"id"`
+	Offset        int64  `json:"offset"`
+	SizeBytes     int64  `json:"size_bytes"`
+	MinChunkBytes int64  `json:"min_chunk_bytes"` // except the last chunk
+	MaxChunkBytes int64  `json:"max_chunk_bytes"`
+	ExpiresAt     string `json:"expires_at"`
 }
 
 // screenResponse mirrors model.Screen but flattens times to RFC3339
@@ -27,6 +43,17 @@ type ScreenResponse struct {
 	Paired            bool    `json:"paired"`
 	CreatedAt         string  `json:"created_at"`
 	UpdatedAt         string  `json:"updated_at"`
+
+	// derived from the latest heartbeat: online, stale or offline
+	Status        string  `json:"status"`
+	LastSeenAt    *string `json:"last_seen_at"`
+	UptimeSeconds *int64  `json:"uptime_seconds"`
+	PlaylistETag  *string `json:"playlist_etag"`
+	AppVersion    *string `json:"app_version"`
+
+	// from the latest /report
+	StorageSize *int64  `json:"storage_size"`
+	IPAddress   *string `json:"ip_address"`
 }
 
 type PlaylistItemResponse struct {
@@ -47,16 +74,21 @@ type PlaylistResponse struct {
 	Items       []PlaylistItemResponse `json:"items"`
 }
 
-// Simple response for TV clients - just URLs and durations
+// Simple response for TV clients - just URLs and durations, plus the IDs players
+// report back in proof-of-play events
 type TVPlaylistResponse struct {
+	PlaylistID   int             `json:"playlist_id"`
 	PlaylistName string          `json:"playlist_name"`
 	ContentList  []TVContentItem `json:"content_list"`
 }
 
 type TVContentItem struct {
-	URL      string `json:"url"`
-	Duration int    `json:"duration"`
-	Type     string `json:"type"`
+	ContentID int     `json:"content_id"`
+	URL       string  `json:"url"`
+	Duration  int     `json:"duration"`
+	Type      string  `json:"type"`
+	SHA256    *string `json:"sha256"`
+	SizeBytes *int64  `json:"size_bytes"`
 }
 
 type ScheduleResponse struct {
@@ -74,3 +106,164 @@ type ScreenGroupResponse struct {
     UpdatedAt   string  `json:"updated_at"`
 }
 
+type ScreenAssignmentResponse struct {
+	ScreenID   int     `json:"screen_id"`
+	UserID     int     `json:"user_id"`
+	Email      string  `json:"email"`
+	Name       *string `json:"name"`
+	AssignedBy *int    `json:"assigned_by"`
+	AssignedAt string  `json:"assigned_at"`
+}
+
+type OrganizationResponse struct {
+	ID               int    `json:"id"`
+	Name             string `json:"name"`
+	CreatedBy        int    `json:"created_by"`
+	RequireTwoFactor bool   `json:"require_two_factor"`
+	CreatedAt        string `json:"created_at"`
+	UpdatedAt        string `json:"updated_at"`
+}
+
+type OrganizationMemberResponse struct {
+	UserID   int     `json:"user_id"`
+	Email    string  `json:"email"`
+	Name     *string `json:"name"`
+	Role     string  `json:"role"`
+	JoinedAt string  `json:"joined_at"`
+}
+
+type RoleResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type AuditEventResponse struct {
+	ID            int             `json:"id"`
+	ActorUserID   *int            `json:"actor_user_id"`
+	ActorAPIKeyID *int            `json:"actor_api_key_id"`
+	Action        string          `json:"action"`
+	EntityType    string          `json:"entity_type"`
+	EntityID      *string         `json:"entity_id"`
+	Before        json.RawMessage `json:"before"`
+	After         json.RawMessage `json:"after"`
+	Metadata      json.RawMessage `json:"metadata"`
+	IP            *string         `json:"ip"`
+	CreatedAt     string          `json:"created_at"`
+}
+
+type AuditEventPageResponse struct {
+	Events []AuditEventResponse `json:"events"`
+	Total  int                  `json:"total"`
+	Limit  int                  `json:"limit"`
+	Offset int                  `json:"offset"`
+}
+
+type InvitationResponse struct {
+	ID        int    `json:"id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	InvitedBy *int   `json:"invited_by"`
+	ExpiresAt string `json:"expires_at"`
+	CreatedAt string `json:"created_at"`
+}
+
+// the plaintext token is only ever returned here
+type DeviceTokenResponse struct {
+	ScreenID    int    `json:"screen_id"`
+	DeviceToken string `json:"device_token"`
+}
+
+type ScreenDeviceResponse struct {
+	DeviceID     string  `json:"device_id"`
+	BoundBy      *int    `json:"bound_by"`
+	BoundAt      string  `json:"bound_at"`
+	UnboundBy    *int    `json:"unbound_by"`
+	UnboundAt    *string `json:"unbound_at"`
+	UnbindReason *string `json:"unbind_reason"`
+}
+
+type FleetStatusResponse struct {
+	Online  int              `json:"online"`
+	Stale   int              `json:"stale"`
+	Offline int              `json:"offline"`
+	Screens []ScreenResponse `json:"screens"`
+}
+
+type AlertRuleResponse struct {
+	ID                  int    `json:"id"`
+	Name                string `json:"name"`
+	ScreenID            *int   `json:"screen_id"`
+	GroupID             *int   `json:"group_id"`
+	OfflineAfterSeconds int    `json:"offline_after_seconds"`
+	Channel             string `json:"channel"`
+	Target              string `json:"target"`
+	Enabled             bool   `json:"enabled"`
+	CreatedBy           *int   `json:"created_by"`
+	CreatedAt           string `json:"created_at"`
+	UpdatedAt           string `json:"updated_at"`
+}
+
+type AlertResponse struct {
+	RuleID     int    `json:"rule_id"`
+	RuleName   string `json:"rule_name"`
+	ScreenID   int    `json:"screen_id"`
+	ScreenName string `json:"screen_name"`
+	State      string `json:"state"`
+	FiredAt    string `json:"fired_at"`
+}
+
+type CommandResponse struct {
+	ID        string          `json:"id"`
+	ScreenID  int             `json:"screen_id"`
+	Type      string          `json:"type"`
+	Params    json.RawMessage `json:"params"`
+	Status    string          `json:"status"` // pending, sent, succeeded, failed or expired
+	Message   *string         `json:"message"`
+	Result    json.RawMessage `json:"result"`
+	IssuedBy  *int            `json:"issued_by"`
+	CreatedAt string          `json:"created_at"`
+	ExpiresAt string          `json:"expires_at"`
+	SentAt    *string         `json:"sent_at"`
+	AckedAt   *string         `json:"acked_at"`
+}
+
+type ScreenshotResponse struct {
+	ID          int     `json:"id"`
+	ScreenID    int     `json:"screen_id"`
+	CommandID   *string `json:"command_id"`
+	URL         string  `json:"url"`
+	ContentType string  `json:"content_type"`
+	SizeBytes   int64   `json:"size_bytes"`
+	CapturedAt  string  `json:"captured_at"`
+}
+
+// TelemetryResponse is one telemetry sample; metrics the device did not report are null.
+type TelemetryResponse struct {
+	ScreenID           int      `json:"screen_id"`
+	RecordedAt         string   `json:"recorded_at"`
+	IPAddress          *string  `json:"ip_address"`
+	CPUPercent         *float64 `json:"cpu_percent"`
+	MemoryUsedBytes    *int64   `json:"memory_used_bytes"`
+	MemoryTotalBytes   *int64   `json:"memory_total_bytes"`
+	TemperatureCelsius *float64 `json:"temperature_celsius"`
+	StorageUsedBytes   *int64   `json:"storage_used_bytes"`
+	StorageTotalBytes  *int64   `json:"storage_total_bytes"`
+	NetworkType        *string  `json:"network_type"`
+	SignalDBM          *int     `json:"signal_dbm"`
+	RxBytesPerSecond   *int64   `json:"rx_bytes_per_second"`
+	TxBytesPerSecond   *int64   `json:"tx_bytes_per_second"`
+}
+
+// TelemetryHistoryResponse is laid out for charting: Timestamps holds the start of each
+// bucket, and every series in Series has one value per timestamp, null where no sample in
+// the bucket reported that metric.
+type TelemetryHistoryResponse struct {
+	ScreenID      int                   `json:"screen_id"`
+	From          string                `json:"from"`
+	To            string                `json:"to"`
+	BucketSeconds int                   `json:"bucket_seconds"`
+	Timestamps    []string              `json:"timestamps"`
+	Samples       []int                 `json:"samples"`
+	Series        map[string][]*float64 `json:"series"`
+}
@@ -38,6 +38,10 @@ func sendResponse(ctx *gin.Context, result any, apiErr *APIError) {
 		ctx.JSON(apiErr.Code, gin.H{"error": apiErr.Message})
 		return
 	}
+	// handlers that write their own body, such as CSV exports, return a nil result
+	if result == nil && ctx.Writer.Written() {
+		return
+	}
 	ctx.JSON(http.StatusOK, result)
 }
 
@@ -49,8 +53,9 @@ func Public(h HandlerFunc) gin.HandlerFunc {
 	}
 }
 
-// Private wraps an AuthHandlerFunc, enforcing authentication.
-func Private(h AuthHandlerFunc) gin.HandlerFunc {
+// Private wraps an AuthHandlerFunc, enforcing authentication and, when perms are given,
+// that the caller's role in the active organization grants all of them.
+func Private(h AuthHandlerFunc, perms ...model.Permission) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		user, ok := middleware.GetCurrentUser(ctx)
 		if !ok {
@@ -58,6 +63,18 @@ func Private(h AuthHandlerFunc) gin.HandlerFunc {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 			return
 		}
+		if len(perms) > 0 {
+			membership, ok := middleware.GetCurrentMembership(ctx)
+			if !ok || !membership.Can(perms...) {
+				evt := log.Warn().Str("path", ctx.FullPath()).Int("user_id", user.ID)
+				if ok {
+					evt = evt.Int("organization_id", membership.OrganizationID).Str("role", membership.Role)
+				}
+				evt.Msg("insufficient permissions")
+				ctx.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+				return
+			}
+		}
 		res, err := h(ctx, user)
 		sendResponse(ctx, res, err)
 	}
@@ -87,24 +104,24 @@ func NewController(parent gin.IRoutes, prefix string) *Controller {
    return &Controller{Group: grp}
 }
 
-// GET registers an authenticated GET endpoint.
-func (c *Controller) GET(path string, h AuthHandlerFunc) {
-	c.Group.GET(path, Private(h))
+// GET registers an authenticated GET endpoint, optionally restricted to roles granting perms.
+func (c *Controller) GET(path string, h AuthHandlerFunc, perms ...model.Permission) {
+	c.Group.GET(path, Private(h, perms...))
 }
 
-// POST registers an authenticated POST endpoint.
-func (c *Controller) POST(path string, h AuthHandlerFunc) {
-	c.Group.POST(path, Private(h))
+// POST registers an authenticated POST endpoint, optionally restricted to roles granting perms.
+func (c *Controller) POST(path string, h AuthHandlerFunc, perms ...model.Permission) {
+	c.Group.POST(path, Private(h, perms...))
 }
 
-// PUT registers an authenticated PUT endpoint.
-func (c *Controller) PUT(path string, h AuthHandlerFunc) {
-	c.Group.PUT(path, Private(h))
+// PUT registers an authenticated PUT endpoint, optionally restricted to roles granting perms.
+func (c *Controller) PUT(path string, h AuthHandlerFunc, perms ...model.Permission) {
+	c.Group.PUT(path, Private(h, perms...))
 }
 
-// DELETE registers an authenticated DELETE endpoint.
-func (c *Controller) DELETE(path string, h AuthHandlerFunc) {
-	c.Group.DELETE(path, Private(h))
+// DELETE registers an authenticated DELETE endpoint, optionally restricted to roles granting perms.
+func (c *Controller) DELETE(path string, h AuthHandlerFunc, perms ...model.Permission) {
+	c.Group.DELETE(path, Private(h, perms...))
 }
 
 func (c *Controller) PUBLIC_GET(path string, h HandlerFunc) {
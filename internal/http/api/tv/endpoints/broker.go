@@ -0,0 +1,145 @@
+package endpoints
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/tv/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/redis"
+)
+
+// broker access levels, as sent by the auth plugin
+const (
+	brokerRead      = 1
+	brokerWrite     = 2
+	brokerSubscribe = 4
+)
+
+// Wrong device tokens are limited per device ID, like wrong pairing codes, so the broker
+// login cannot be used to guess tokens. The plugin calls from the broker's address, so
+// the client's IP is not known here.
+const (
+	brokerLoginMissLimit  = 10
+	brokerLoginMissWindow = 15 * time.Minute
+)
+
+func brokerLoginMissKey(deviceID string) string { return "tv:mqtt:misses:" + deviceID }
+
+// BrokerController answers the MQTT broker's authentication and ACL checks, so devices
+// reach the broker with the same device token they use over HTTP.
+type BrokerController struct {
+	store db.Store
+}
+
+// BrokerAuthModule mounts /mqtt/user, /mqtt/superuser and /mqtt/acl for the broker's HTTP
+// auth plugin (mosquitto-go-auth). A device connects with its device_id as username and
+// its device token as password, and may only read its own command topic and publish to its
+// own heartbeat topic; the server connects with MQTT_USERNAME and MQTT_PASSWORD as the one
+// superuser.
+func BrokerAuthModule(store db.Store) api.Module {
+	ctl := &BrokerController{store: store}
+	return api.ModuleFunc(func(c *api.Controller) {
+		c.Group.POST("/mqtt/user", ctl.authenticate)
+		c.Group.POST("/mqtt/superuser", ctl.superuser)
+		c.Group.POST("/mqtt/acl", ctl.authorize)
+	})
+}
+
+// isServer reports whether the credentials are the server's own broker login.
+func isServer(username, password string) bool {
+	if middleware.BrokerUser == "" || middleware.BrokerPass == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(username), []byte(middleware.BrokerUser)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(password), []byte(middleware.BrokerPass)) == 1
+}
+
+// POST /api/tv/mqtt/user
+func (b *BrokerController) authenticate(ctx *gin.Context) {
+	var request packets.BrokerUserRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		return
+	}
+	if request.Username == middleware.BrokerUser {
+		// the server's name is never a device login, whatever device_id a screen holds
+		if !isServer(request.Username, request.Password) {
+			log.Warn().Str("client_id", request.ClientID).Msg("[mqtt] rejected server login")
+			ctx.Status(http.StatusForbidden)
+			return
+		}
+		ctx.Status(http.StatusOK)
+		return
+	}
+	if !middleware.IsDeviceIDFormat(request.Username) {
+		ctx.Status(http.StatusForbidden)
+		return
+	}
+
+	missKey := brokerLoginMissKey(request.Username)
+	if blocked, _ := redis.Exceeded(ctx, missKey, brokerLoginMissLimit, brokerLoginMissWindow); blocked {
+		log.Warn().Str("username", request.Username).Msg("[mqtt] too many failed broker logins")
+		ctx.Status(http.StatusForbidden)
+		return
+	}
+
+	screen, err := b.store.GetScreenByDeviceTokenHash(middleware.HashToken(request.Password))
+	if err != nil || screen.DeviceID == nil || *screen.DeviceID != request.Username {
+		log.Warn().Str("username", request.Username).Str("client_id", request.ClientID).
+			Msg("[mqtt] rejected broker login")
+		_, _ = redis.IncrWithin(ctx, missKey, brokerLoginMissWindow)
+		ctx.Status(http.StatusForbidden)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// POST /api/tv/mqtt/superuser
+func (b *BrokerController) superuser(ctx *gin.Context) {
+	var request packets.BrokerUserRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		return
+	}
+	// the plugin sends no password here; it only asks after a successful login, and only the
+	// server can log in under its name
+	if middleware.BrokerUser == "" || request.Username != middleware.BrokerUser {
+		ctx.Status(http.StatusForbidden)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// POST /api/tv/mqtt/acl
+func (b *BrokerController) authorize(ctx *gin.Context) {
+	var request packets.BrokerACLRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Status(http.StatusBadRequest)
+		return
+	}
+
+	// devices listen on their command topic and only publish heartbeats, on their own topic
+	listens := request.Topic == middleware.CommandTopic(request.Username) &&
+		request.Acc&brokerWrite == 0 && request.Acc&(brokerRead|brokerSubscribe) != 0
+	reports := request.Topic == middleware.HeartbeatTopic(request.Username) && request.Acc == brokerWrite
+	if !listens && !reports {
+		log.Warn().Str("username", request.Username).Str("topic", request.Topic).Int("acc", request.Acc).
+			Msg("[mqtt] denied topic access")
+		ctx.Status(http.StatusForbidden)
+		return
+	}
+
+	// a screen unpaired since the login loses its topic
+	if paired, err := b.store.IsScreenPairedByDeviceID(&request.Username); err != nil || !paired {
+		ctx.Status(http.StatusForbidden)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
@@ -0,0 +1,85 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+// how often an idle event stream sends a comment line, so proxies do not time it out
+const eventStreamKeepAlive = 25 * time.Second
+
+// GET /api/tv/events
+// Streams the device's commands and events as Server-Sent Events, for players that cannot
+// reach the MQTT broker. Each message's data is the JSON that would be published to
+// tv/{device_id}/commands. Unacknowledged commands are replayed when the stream opens, so
+// a command may arrive twice; devices should ignore IDs they have already handled.
+func (t *TvController) streamEvents(ctx *gin.Context) {
+	screen, _ := middleware.GetCurrentScreen(ctx)
+	deviceID := *screen.DeviceID
+
+	messages, closeSub, err := middleware.SubscribeDevice(ctx.Request.Context(), deviceID)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screen.ID).Msg("could not subscribe to device events")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not open event stream"})
+		return
+	}
+	defer closeSub()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	commands, err := t.store.ListPendingDeviceCommands(screen.ID)
+	if err == nil {
+		for _, c := range commands {
+			envelope, _ := json.Marshal(c.Envelope())
+			writeEvent(ctx, string(envelope))
+			if c.Status == model.CommandPending {
+				_ = t.store.MarkDeviceCommandSent(c.ID)
+			}
+		}
+	}
+	ctx.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			writeEvent(ctx, msg.Payload)
+			ctx.Writer.Flush()
+
+			// the device's token is no longer valid, so neither is this stream
+			var event struct {
+				Type string `json:"type"`
+			}
+			if json.Unmarshal([]byte(msg.Payload), &event) == nil && event.Type == model.EventSessionRevoked {
+				return
+			}
+		case <-keepAlive.C:
+			fmt.Fprint(ctx.Writer, ": keep-alive\n\n")
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+// writeEvent writes one SSE message. Payloads are single-line JSON, so one data field holds it.
+func writeEvent(ctx *gin.Context, payload string) {
+	fmt.Fprintf(ctx.Writer, "data: %s\n\n", payload)
+}
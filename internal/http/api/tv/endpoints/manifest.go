@@ -0,0 +1,163 @@
+package endpoints
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/tv/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+)
+
+// how far ahead a manifest looks unless the device asks for another horizon
+const defaultManifestHorizon = 24 * time.Hour
+
+// GET /api/tv/manifest?hours=
+// Lists every playlist and asset the screen will play from now until the horizon (24 hours
+// by default, at most 168): the current playlist, the directly assigned one and those of
+// upcoming schedule windows. Assets carry their SHA-256 and size so devices can check their
+// cache and pre-download. The version is sent as the ETag; a matching If-None-Match gets 304.
+func (t *TvController) getManifest(ctx *gin.Context) {
+	screen, _ := middleware.GetCurrentScreen(ctx)
+	_ = t.store.MarkScreenSeen(screen.ID)
+
+	var query packets.ManifestQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	horizon := defaultManifestHorizon
+	if query.Hours != nil {
+		horizon = time.Duration(*query.Hours) * time.Hour
+	}
+
+	now := time.Now().UTC()
+	until := now.Add(horizon)
+	manifest := packets.ManifestResponse{
+		Windows:   []packets.ManifestWindow{},
+		Playlists: []packets.ManifestPlaylist{},
+		Assets:    []packets.ManifestAsset{},
+	}
+	playlistIDs := map[int]bool{}
+
+	current, _, _, err := t.store.GetEffectivePlaylistForScreen(screen.ID, now)
+	if err == nil {
+		manifest.CurrentPlaylistID = &current.ID
+		playlistIDs[current.ID] = true
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not resolve the current playlist"})
+		return
+	}
+
+	direct, err := t.store.GetPlaylistForScreen(screen.ID)
+	if err == nil {
+		manifest.DefaultPlaylistID = &direct.ID
+		playlistIDs[direct.ID] = true
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not load the screen's playlist"})
+		return
+	}
+
+	scheduleIDs, err := t.store.ListScheduleIDsForScreen(screen.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not load the screen's schedules"})
+		return
+	}
+	for _, scheduleID := range scheduleIDs {
+		occurrences, err := t.store.ListScheduleOccurrences(scheduleID, now, until)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not load schedule windows"})
+			return
+		}
+		for _, o := range occurrences {
+			manifest.Windows = append(manifest.Windows, packets.ManifestWindow{
+				PlaylistID: o.Playlist,
+				StartsAt:   o.Start.UTC().Format(time.RFC3339),
+				EndsAt:     o.End.UTC().Format(time.RFC3339),
+				Priority:   o.Priority,
+			})
+			playlistIDs[o.Playlist] = true
+		}
+	}
+	// windows from several schedules interleave; order them the way a player walks them
+	sort.SliceStable(manifest.Windows, func(i, j int) bool {
+		a, b := manifest.Windows[i], manifest.Windows[j]
+		if a.StartsAt != b.StartsAt {
+			return a.StartsAt < b.StartsAt
+		}
+		return a.Priority > b.Priority
+	})
+
+	ids := make([]int, 0, len(playlistIDs))
+	for id := range playlistIDs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	seen := map[int]bool{}
+	for _, id := range ids {
+		name, items, err := t.store.GetPlaylistContentByPlaylistID(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			// deleted since the window was read
+			continue
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not load playlist content"})
+			return
+		}
+
+		playlist := packets.ManifestPlaylist{ID: id, Name: name, Items: make([]packets.ManifestItem, 0, len(items))}
+		for _, item := range items {
+			playlist.Items = append(playlist.Items, packets.ManifestItem{ContentID: item.ContentID, Duration: item.Duration})
+			if seen[item.ContentID] {
+				continue
+			}
+			seen[item.ContentID] = true
+			manifest.Assets = append(manifest.Assets, packets.ManifestAsset{
+				ContentID: item.ContentID,
+				URL:       item.URL,
+				Type:      item.Type,
+				SHA256:    item.SHA256,
+				SizeBytes: item.SizeBytes,
+				MimeType:  item.MimeType,
+			})
+			if item.SizeBytes != nil {
+				manifest.TotalBytes += *item.SizeBytes
+			}
+		}
+		manifest.Playlists = append(manifest.Playlists, playlist)
+	}
+
+	// the version covers everything but the timestamps, so it only moves when the plan does
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screen.ID).Msg("failed to encode manifest")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not build manifest"})
+		return
+	}
+	sum := sha256.Sum256(body)
+	manifest.Version = hex.EncodeToString(sum[:])[:24]
+	manifest.GeneratedAt = now.Format(time.RFC3339)
+	manifest.ValidUntil = until.Format(time.RFC3339)
+
+	ifNoneMatch := ctx.GetHeader("If-None-Match")
+	if v := ctx.GetHeader("X-If-None-Match"); v != "" {
+		ifNoneMatch = v
+	}
+	ctx.Header("ETag", `"`+manifest.Version+`"`)
+	ctx.Header("Cache-Control", "no-cache")
+	if strings.Trim(ifNoneMatch, `"`) == manifest.Version {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.JSON(http.StatusOK, manifest)
+}
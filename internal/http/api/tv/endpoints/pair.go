@@ -2,11 +2,14 @@ package endpoints
 
 import (
 	"crypto/sha256"
+	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,52 +20,92 @@ import (
 	"github.com/Nixie-Tech-LLC/medusa/internal/http/api"
 	adminpackets "github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/packets"
 	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/tv/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
 	"github.com/Nixie-Tech-LLC/medusa/internal/redis"
+	"github.com/Nixie-Tech-LLC/medusa/internal/storage"
 )
 
 type TvController struct {
-	store db.Store
+	store     db.Store
+	storage   storage.Storage
+	retention model.ScreenshotRetention
 }
 
-func newTvController(store db.Store) *TvController {
-	return &TvController{store: store}
+func newTvController(store db.Store, storage storage.Storage, retention model.ScreenshotRetention) *TvController {
+	return &TvController{store: store, storage: storage, retention: retention}
 }
 
-// PairingModule mounts public TV endpoints: /register, /ping, /content
-func PairingModule(store db.Store) api.Module {
-	ctl := newTvController(store)
+// PairingModule mounts public TV endpoints: /register, /ping, /heartbeat, /content, /manifest,
+// /plays, /commands, /events and /screenshots.
+// Everything after pairing requires the device token handed out by /ping.
+func PairingModule(store db.Store, storage storage.Storage, retention model.ScreenshotRetention) api.Module {
+	ctl := newTvController(store, storage, retention)
 	return api.ModuleFunc(func(c *api.Controller) {
 		c.Group.POST("/register", ctl.registerPairingCode)
 
 		// support both HEAD/GET for ping
 		c.Group.HEAD("/ping", ctl.pingServer)
 		c.Group.GET("/ping", ctl.pingServer)
-		c.Group.POST("/report", ctl.reportInfo)
+		c.Group.POST("/report", middleware.DeviceMiddleware(), ctl.reportInfo)
+		c.Group.POST("/heartbeat", middleware.DeviceMiddleware(), ctl.heartbeat)
 
-		c.Group.GET("/content", ctl.getContent)
+		c.Group.GET("/content", middleware.DeviceMiddleware(), ctl.getContent)
+		c.Group.GET("/manifest", middleware.DeviceMiddleware(), ctl.getManifest)
+		c.Group.POST("/plays", middleware.DeviceMiddleware(), ctl.reportPlays)
+
+		// remote commands, for players that are not listening on MQTT, and their acknowledgements
+		c.Group.GET("/commands", middleware.DeviceMiddleware(), ctl.pendingCommands)
+		c.Group.POST("/commands/:id/ack", middleware.DeviceMiddleware(), ctl.ackCommand)
+
+		// the MQTT topic's commands and events as Server-Sent Events, for players behind proxies
+		c.Group.GET("/events", middleware.DeviceMiddleware(), ctl.streamEvents)
+
+		c.Group.POST("/screenshots", middleware.DeviceMiddleware(), ctl.uploadScreenshot)
 	})
 }
 
-type PairingData struct {
-	DeviceID string `json:"device_id"`
-	IsPaired bool   `json:"is_paired"`
-}
+// how far play event timestamps may stray from the server clock; players buffer events
+// while offline, so old events are accepted for a while
+const (
+	playClockSkew  = 5 * time.Minute
+	playMaxBacklog = 30 * 24 * time.Hour
+)
+
+// registrations allowed per IP, so one client cannot flood the pairing code space
+const (
+	registrationsPerIP = 20
+	registrationWindow = time.Hour
+)
 
 type DeviceInfo struct {
-	DeviceID          string `json:"device_id"`
-	ClientInformation string `json:"client_information"`
-	ClientWidth       int    `json:"width"`
-	ClientHeight      int    `json:"height"`
+	DeviceID          string                   `json:"device_id"`
+	ClientInformation string                   `json:"client_information"`
+	ClientWidth       int                      `json:"width"`
+	ClientHeight      int                      `json:"height"`
+	Telemetry         *packets.TelemetryReport `json:"telemetry"`
 }
 
 // POST /api/tv/register
+// The server picks the pairing code; the TV displays it until an admin claims it.
 func (t *TvController) registerPairingCode(ctx *gin.Context) {
-	var request packets.TVRequest
+	if ok, retryAfter := redis.Allow(ctx, "tv:register:ip:"+ctx.ClientIP(), registrationsPerIP, registrationWindow); !ok {
+		ctx.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "too many pairing requests, try again later"})
+		return
+	}
+
+	var request packets.RegisterRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		log.Error().Err(err).Msg("failed to bind JSON")
 		return
 	}
+	// device IDs name the device's MQTT login and topics
+	if !middleware.ValidDeviceID(request.DeviceID) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "device_id must be a UUID"})
+		return
+	}
 
 	isPaired, err := t.store.IsScreenPairedByDeviceID(&request.DeviceID)
 	if err != nil {
@@ -76,11 +119,59 @@ func (t *TvController) registerPairingCode(ctx *gin.Context) {
 		return
 	}
 
-	pairingData := PairingData{DeviceID: request.DeviceID, IsPaired: false}
-	marshalledPairingData, _ := json.Marshal(pairingData)
-	redis.Set(ctx, request.PairingCode, marshalledPairingData, 7*24*time.Hour)
+	pairingData, _ := json.Marshal(middleware.PairingData{DeviceID: request.DeviceID})
 
-	ctx.JSON(http.StatusOK, packets.TVRequest{DeviceID: request.DeviceID})
+	// SETNX guards against handing out a code that is already waiting to be claimed
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := middleware.GeneratePairingCode()
+		if err != nil {
+			break
+		}
+		created, err := redis.Rdb.SetNX(ctx, middleware.PairingKey(code), pairingData, middleware.PairingCodeTTL).Result()
+		if err != nil {
+			log.Error().Err(err).Msg("failed to store pairing code")
+			break
+		}
+		if created {
+			ctx.JSON(http.StatusOK, packets.RegisterResponse{
+				DeviceID:  request.DeviceID,
+				Code:      code,
+				ExpiresIn: int(middleware.PairingCodeTTL.Seconds()),
+			})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "could not allocate a pairing code"})
+}
+
+// POST /api/tv/heartbeat
+// Players call this periodically; the admin API derives online status from the last call.
+func (t *TvController) heartbeat(ctx *gin.Context) {
+	var request packets.HeartbeatRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	screen, _ := middleware.GetCurrentScreen(ctx)
+	if request.DeviceID != "" && request.DeviceID != *screen.DeviceID {
+		log.Warn().Str("device_id", request.DeviceID).Int("screen_id", screen.ID).
+			Msg("heartbeat for another device")
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid device token"})
+		return
+	}
+
+	if err := t.store.RecordScreenHeartbeat(screen.ID, model.Heartbeat{
+		UptimeSeconds: request.UptimeSeconds,
+		PlaylistETag:  request.PlaylistETag,
+		AppVersion:    request.AppVersion,
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not record heartbeat"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, packets.HeartbeatResponse{ServerTime: time.Now().UTC().Format(time.RFC3339)})
 }
 
 func (t *TvController) reportInfo(ctx *gin.Context) {
@@ -91,30 +182,25 @@ func (t *TvController) reportInfo(ctx *gin.Context) {
 		return
 	}
 
-	isPaired, err := t.store.IsScreenPairedByDeviceID(&request.DeviceID)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		log.Error().Err(err).Str("deviceID", request.DeviceID).Msg("failed to check if screen is paired by device")
+	screen, _ := middleware.GetCurrentScreen(ctx)
+	if request.DeviceID != "" && request.DeviceID != *screen.DeviceID {
+		log.Error().Str("deviceID", request.DeviceID).Int("screenID", screen.ID).
+			Msg("Attempt to report info for another device")
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid device token"})
 		return
 	}
-	if !isPaired {
+	if !screen.Paired {
 		log.Error().Msg("Attempt to report info for an unpaired device")
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Screen is not paired"})
 		return
 	}
 
-	screen, err := t.store.GetScreenByDeviceID(&request.DeviceID)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
-		log.Error().Err(err).Str("deviceID", request.DeviceID).Msg("Failed to find screen by device ID")
-		return
-	}
-
 	screenID := screen.ID
+	_ = t.store.MarkScreenSeen(screenID)
 
 	clientIP := getClientIP(ctx)
 
-	err = t.store.UpdateClientInformation(screenID, &request.ClientInformation)
+	err := t.store.UpdateClientInformation(screenID, &request.ClientInformation)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
 		log.Error().Err(err).Int("screenID", screenID).Msg("Failed to update client information for screen")
@@ -133,40 +219,78 @@ func (t *TvController) reportInfo(ctx *gin.Context) {
 		return
 	}
 
+	if report := request.Telemetry; report != nil {
+		// each report is appended to the screen's history rather than overwriting the last
+		if err := t.store.RecordScreenTelemetry(screenID, &clientIP, model.Telemetry{
+			CPUPercent:         report.CPUPercent,
+			MemoryUsedBytes:    report.MemoryUsedBytes,
+			MemoryTotalBytes:   report.MemoryTotalBytes,
+			TemperatureCelsius: report.TemperatureCelsius,
+			StorageUsedBytes:   report.StorageUsedBytes,
+			StorageTotalBytes:  report.StorageTotalBytes,
+			NetworkType:        report.NetworkType,
+			SignalDBM:          report.SignalDBM,
+			RxBytesPerSecond:   report.RxBytesPerSecond,
+			TxBytesPerSecond:   report.TxBytesPerSecond,
+		}); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not record telemetry"})
+			return
+		}
+		// the screen list shows the capacity of the device's storage
+		if report.StorageTotalBytes != nil {
+			if err := t.store.UpdateClientStorageSize(screenID, *report.StorageTotalBytes); err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not record telemetry"})
+				return
+			}
+		}
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{})
 }
 
-// HEAD/GET /api/tv/ping?code=XXXX
+// HEAD/GET /api/tv/ping?code=XXXX&device_id=UUID
+// 200 while the code waits to be claimed, 201 once the screen is paired (with the device
+// token on the first GET), 404 once the code is unknown or expired.
 func (t *TvController) pingServer(ctx *gin.Context) {
-	pairingCode := ctx.Query("code")
-	var pairingData PairingData
-	redis.GetUnmarshalledJSON(ctx, pairingCode, &pairingData)
-
-	if pairingData.IsPaired {
-		log.Info().Str("pairingCode", pairingCode).Bool("value", pairingData.IsPaired).Msg("paired")
-		ctx.JSON(http.StatusCreated, gin.H{})
+	pairingCode := strings.ToUpper(strings.TrimSpace(ctx.Query("code")))
+	caller := "ip:" + ctx.ClientIP()
+	if blocked, retryAfter := middleware.PairingGuessesExceeded(ctx, caller); blocked {
+		ctx.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "too many unknown pairing codes"})
 		return
 	}
-	log.Info().Str("pairingCode", pairingCode).Bool("value", pairingData.IsPaired).Msg("not paired")
-	ctx.JSON(http.StatusOK, gin.H{})
-}
-
-// GET /api/tv/content?device_id=UUID
 
-func (t *TvController) getContent(ctx *gin.Context) {
-	deviceID := ctx.Query("device_id")
-	if deviceID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "device_id is required"})
+	deliveryKey := middleware.DeviceTokenDeliveryKey(pairingCode)
+	var delivery middleware.DeviceTokenDelivery
+	if raw, err := redis.Rdb.Get(ctx, deliveryKey).Bytes(); err == nil && json.Unmarshal(raw, &delivery) == nil {
+		// only the device that registered the code may collect its token
+		if ctx.Query("device_id") != delivery.DeviceID || ctx.Request.Method == http.MethodHead {
+			ctx.JSON(http.StatusCreated, gin.H{})
+			return
+		}
+		if _, err := redis.Rdb.GetDel(ctx, deliveryKey).Result(); err != nil {
+			ctx.JSON(http.StatusCreated, gin.H{})
+			return
+		}
+		log.Info().Str("pairingCode", pairingCode).Str("deviceID", delivery.DeviceID).Msg("device token collected")
+		ctx.JSON(http.StatusCreated, packets.PairedResponse{DeviceID: delivery.DeviceID, DeviceToken: delivery.DeviceToken})
 		return
 	}
 
-	screen, dbErr := t.store.GetScreenByDeviceID(&deviceID)
-	if dbErr != nil {
-		log.Error().Err(dbErr).Str("deviceID", deviceID).Msg("Device ID not found")
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "device not found"})
+	if n, err := redis.Rdb.Exists(ctx, middleware.PairingKey(pairingCode)).Result(); err == nil && n > 0 {
+		ctx.JSON(http.StatusOK, gin.H{})
 		return
 	}
+
+	middleware.RecordPairingMiss(ctx, caller)
+	ctx.JSON(http.StatusNotFound, gin.H{"error": "unknown or expired pairing code"})
+}
+
+// GET /api/tv/content (Authorization: Bearer <device token>)
+func (t *TvController) getContent(ctx *gin.Context) {
+	screen, _ := middleware.GetCurrentScreen(ctx)
 	screenID := screen.ID
+	_ = t.store.MarkScreenSeen(screenID)
 
 	now := time.Now().UTC()
 	playlist, contentItems, source, err := t.store.GetEffectivePlaylistForScreen(screenID, now)
@@ -202,12 +326,16 @@ func (t *TvController) getContent(ctx *gin.Context) {
 	contentList := make([]adminpackets.TVContentItem, len(contentItems))
 	for i, item := range contentItems {
 		contentList[i] = adminpackets.TVContentItem{
-			URL:      item.URL,
-			Duration: item.Duration,
-			Type:     item.Type,
+			ContentID: item.ContentID,
+			URL:       item.URL,
+			Duration:  item.Duration,
+			Type:      item.Type,
+			SHA256:    item.SHA256,
+			SizeBytes: item.SizeBytes,
 		}
 	}
 	response := adminpackets.TVPlaylistResponse{
+		PlaylistID:   playlist.ID,
 		PlaylistName: playlist.Name,
 		ContentList:  contentList,
 	}
@@ -219,6 +347,88 @@ func (t *TvController) getContent(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, response)
 }
 
+// POST /api/tv/plays
+// Records a batch of proof-of-play events. Events already recorded are ignored, so players
+// may resend a batch until they get a response.
+func (t *TvController) reportPlays(ctx *gin.Context) {
+	var request packets.PlayEventsRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	screen, _ := middleware.GetCurrentScreen(ctx)
+	now := time.Now()
+	events := make([]model.PlayEvent, 0, len(request.Events))
+	for _, e := range request.Events {
+		if e.EndedAt.Before(e.StartedAt) || e.StartedAt.After(now.Add(playClockSkew)) ||
+			e.StartedAt.Before(now.Add(-playMaxBacklog)) {
+			continue
+		}
+		events = append(events, model.PlayEvent{
+			ContentID:  e.ContentID,
+			PlaylistID: e.PlaylistID,
+			StartedAt:  e.StartedAt,
+			EndedAt:    e.EndedAt,
+		})
+	}
+
+	accepted := 0
+	if len(events) > 0 {
+		var err error
+		accepted, err = t.store.RecordPlayEvents(screen.OrganizationID, screen.ID, events)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not record play events"})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, packets.PlayEventsResponse{Received: len(request.Events), Accepted: accepted})
+}
+
+// GET /api/tv/commands
+// Returns the screen's unacknowledged commands in the same envelope used over MQTT.
+func (t *TvController) pendingCommands(ctx *gin.Context) {
+	screen, _ := middleware.GetCurrentScreen(ctx)
+
+	commands, err := t.store.ListPendingDeviceCommands(screen.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not list commands"})
+		return
+	}
+
+	envelopes := make([]model.CommandEnvelope, 0, len(commands))
+	for _, c := range commands {
+		if c.Status == model.CommandPending {
+			_ = t.store.MarkDeviceCommandSent(c.ID)
+		}
+		envelopes = append(envelopes, c.Envelope())
+	}
+	ctx.JSON(http.StatusOK, envelopes)
+}
+
+// POST /api/tv/commands/:id/ack
+func (t *TvController) ackCommand(ctx *gin.Context) {
+	var request packets.CommandAckRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	screen, _ := middleware.GetCurrentScreen(ctx)
+	_, err := t.store.AckDeviceCommand(screen.ID, ctx.Param("id"), request.Status, request.Message, request.Result)
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "unknown or already acknowledged command"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not acknowledge command"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"acknowledged": true})
+}
+
 func generatePlaylistETag(playlistID int, updatedAt time.Time, contentItems []db.ContentItem) string {
 	h := sha256.New()
 	var buf []byte
@@ -228,7 +438,7 @@ func generatePlaylistETag(playlistID int, updatedAt time.Time, contentItems []db
 
 	for _, it := range contentItems {
 		buf = buf[:0]
-		buf = fmt.Appendf(buf, "%s:%d;", it.URL, it.Duration)
+		buf = fmt.Appendf(buf, "%d:%s:%d;", it.ContentID, it.URL, it.Duration)
 		h.Write(buf)
 	}
 	sum := hex.EncodeToString(h.Sum(nil))
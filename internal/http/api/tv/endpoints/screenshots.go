@@ -0,0 +1,111 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/tv/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/middleware"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+// largest screenshot a device may upload
+const maxScreenshotBytes = 10 << 20
+
+// image types accepted as screenshots, by sniffed content type, with the extension to store them under
+var screenshotTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+}
+
+// POST /api/tv/screenshots
+// Multipart form: "screenshot" holds the PNG, JPEG or WebP image, and "command_id" the
+// take_screenshot command it answers, if any, which the upload acknowledges. Saving a
+// screenshot prunes the screen's history down to the retention limits.
+func (t *TvController) uploadScreenshot(ctx *gin.Context) {
+	screen, _ := middleware.GetCurrentScreen(ctx)
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxScreenshotBytes+1<<20)
+	fileHeader, err := ctx.FormFile("screenshot")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "screenshot file is required"})
+		return
+	}
+	if fileHeader.Size > maxScreenshotBytes {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "screenshot is too large"})
+		return
+	}
+
+	// trust the bytes rather than the declared type
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "could not read screenshot"})
+		return
+	}
+	head := make([]byte, 512)
+	n, _ := file.Read(head)
+	_ = file.Close()
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := screenshotTypes[contentType]
+	if !ok {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "screenshot must be a PNG, JPEG or WebP image"})
+		return
+	}
+
+	filename := fmt.Sprintf("screenshot_%d_%s%s", screen.ID, uuid.NewString()[:8], ext)
+	// screenshots show whatever is on the screen, so they are kept out of the public uploads
+	location, err := t.storage.SavePrivateFile(fileHeader, filename)
+	if err != nil {
+		log.Error().Err(err).Int("screen_id", screen.ID).Msg("could not save screenshot")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not save screenshot"})
+		return
+	}
+
+	shot := model.Screenshot{
+		OrganizationID: screen.OrganizationID,
+		ScreenID:       screen.ID,
+		URL:            location,
+		ContentType:    contentType,
+		SizeBytes:      fileHeader.Size,
+	}
+	if commandID := ctx.PostForm("command_id"); commandID != "" {
+		shot.CommandID = &commandID
+	}
+	shot, err = t.store.CreateScreenshot(shot)
+	if err != nil {
+		_ = t.storage.DeleteFile(location)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not record screenshot"})
+		return
+	}
+
+	if shot.CommandID != nil {
+		result, _ := json.Marshal(gin.H{"screenshot_id": shot.ID})
+		// the device may have acknowledged the command itself already
+		_, _ = t.store.AckDeviceCommand(screen.ID, *shot.CommandID, model.CommandSucceeded, nil, result)
+	}
+
+	go t.pruneScreenshots(screen.ID)
+
+	ctx.JSON(http.StatusCreated, packets.ScreenshotResponse{ID: shot.ID, CommandID: shot.CommandID})
+}
+
+// pruneScreenshots applies the retention limits after an upload, deleting the files of the
+// screenshots it drops.
+func (t *TvController) pruneScreenshots(screenID int) {
+	pruned, err := t.store.PruneScreenshots(screenID, t.retention.Keep, time.Now().Add(-t.retention.MaxAge))
+	if err != nil {
+		return
+	}
+	for _, s := range pruned {
+		if err := t.storage.DeleteFile(s.URL); err != nil {
+			log.Warn().Err(err).Int("screenshot_id", s.ID).Msg("could not delete pruned screenshot file")
+		}
+	}
+}
@@ -1,8 +1,81 @@
 package packets
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // REQUESTS FOR /api/tv/pair
 type TVRequest struct {
 	PairingCode string `json:"code" binding:"required"`
 	DeviceID    string `json:"device_id" binding:"required"`
 	Type 		string `json:"type"`
 }
+
+// REQUESTS FOR /api/tv/register
+type RegisterRequest struct {
+	DeviceID string `json:"device_id" binding:"required"`
+	Type     string `json:"type"`
+}
+
+// REQUESTS FOR /api/tv/heartbeat
+type HeartbeatRequest struct {
+	DeviceID      string `json:"device_id"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	PlaylistETag  string `json:"playlist_etag"`
+	AppVersion    string `json:"app_version"`
+}
+
+// REQUESTS FOR /api/tv/mqtt/user and /api/tv/mqtt/superuser, sent by the broker's auth plugin
+type BrokerUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	ClientID string `json:"clientid"`
+}
+
+// REQUESTS FOR /api/tv/mqtt/acl; acc is 1 to read, 2 to write, 4 to subscribe
+type BrokerACLRequest struct {
+	Username string `json:"username"`
+	Topic    string `json:"topic"`
+	ClientID string `json:"clientid"`
+	Acc      int    `json:"acc"`
+}
+
+// REQUESTS FOR /api/tv/plays
+type PlayEventsRequest struct {
+	Events []PlayEvent `json:"events" binding:"required,max=500,dive"`
+}
+
+// content_id and playlist_id are the ones served by /api/tv/content
+type PlayEvent struct {
+	ContentID  int       `json:"content_id" binding:"required"`
+	PlaylistID int       `json:"playlist_id"`
+	StartedAt  time.Time `json:"started_at" binding:"required"`
+	EndedAt    time.Time `json:"ended_at" binding:"required"`
+}
+
+// REQUESTS FOR /api/tv/commands/:id/ack
+type CommandAckRequest struct {
+	Status  string          `json:"status" binding:"required,oneof=succeeded failed"`
+	Message *string         `json:"message"`
+	Result  json.RawMessage `json:"result"` // e.g. the screenshot URL for take_screenshot
+}
+
+// sent as "telemetry" with /api/tv/report; leave out whatever the platform cannot measure
+type TelemetryReport struct {
+	CPUPercent         *float64 `json:"cpu_percent" binding:"omitempty,min=0,max=100"`
+	MemoryUsedBytes    *int64   `json:"memory_used_bytes" binding:"omitempty,min=0"`
+	MemoryTotalBytes   *int64   `json:"memory_total_bytes" binding:"omitempty,min=0"`
+	TemperatureCelsius *float64 `json:"temperature_celsius" binding:"omitempty,min=-50,max=150"`
+	StorageUsedBytes   *int64   `json:"storage_used_bytes" binding:"omitempty,min=0"`
+	StorageTotalBytes  *int64   `json:"storage_total_bytes" binding:"omitempty,min=0"`
+	NetworkType        *string  `json:"network_type" binding:"omitempty,oneof=ethernet wifi cellular"`
+	SignalDBM          *int     `json:"signal_dbm" binding:"omitempty,min=-150,max=0"`
+	RxBytesPerSecond   *int64   `json:"rx_bytes_per_second" binding:"omitempty,min=0"`
+	TxBytesPerSecond   *int64   `json:"tx_bytes_per_second" binding:"omitempty,min=0"`
+}
+
+// REQUESTS FOR /api/tv/manifest
+type ManifestQuery struct {
+	Hours *int `form:"hours" binding:"omitempty,min=1,max=168"`
+}
@@ -22,3 +22,72 @@ type ContentResponse struct {
 	UpdatedAt string `json:"updated_at"`
 	CreatedAt string `json:"created_at"`
 }
+
+// returned by /ping once the screen is paired; the token is only ever sent once
+type PairedResponse struct {
+	DeviceID    string `json:"device_id"`
+	DeviceToken string `json:"device_token"`
+}
+
+// the code the TV displays for an admin to enter in the dashboard
+type RegisterResponse struct {
+	DeviceID  string `json:"device_id"`
+	Code      string `json:"code"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+type HeartbeatResponse struct {
+	ServerTime string `json:"server_time"`
+}
+
+type PlayEventsResponse struct {
+	Received int `json:"received"`
+	Accepted int `json:"accepted"`
+}
+
+type ScreenshotResponse struct {
+	ID        int     `json:"id"`
+	CommandID *string `json:"command_id"` // set when the upload acknowledged a take_screenshot command
+}
+
+// everything a screen needs to keep playing offline until valid_until; version changes
+// whenever anything but the timestamps does
+type ManifestResponse struct {
+	Version           string             `json:"version"`
+	GeneratedAt       string             `json:"generated_at"`
+	ValidUntil        string             `json:"valid_until"`
+	CurrentPlaylistID *int               `json:"current_playlist_id"`
+	DefaultPlaylistID *int               `json:"default_playlist_id"` // plays outside schedule windows
+	Windows           []ManifestWindow   `json:"windows"`
+	Playlists         []ManifestPlaylist `json:"playlists"`
+	Assets            []ManifestAsset    `json:"assets"`
+	TotalBytes        int64              `json:"total_bytes"` // of the assets whose size is known
+}
+
+type ManifestWindow struct {
+	PlaylistID int    `json:"playlist_id"`
+	StartsAt   string `json:"starts_at"`
+	EndsAt     string `json:"ends_at"`
+	Priority   int    `json:"priority"`
+}
+
+type ManifestPlaylist struct {
+	ID    int            `json:"id"`
+	Name  string         `json:"name"`
+	Items []ManifestItem `json:"items"`
+}
+
+type ManifestItem struct {
+	ContentID int `json:"content_id"`
+	Duration  int `json:"duration"`
+}
+
+// sha256, size_bytes and mime_type are null for content that was not uploaded, e.g. web pages
+type ManifestAsset struct {
+	ContentID int     `json:"content_id"`
+	URL       string  `json:"url"`
+	Type      string  `json:"type"`
+	SHA256    *string `json:"sha256"`
+	SizeBytes *int64  `json:"size_bytes"`
+	MimeType  *string `json:"mime_type"`
+}
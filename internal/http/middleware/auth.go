@@ -32,3 +32,33 @@ func GetCurrentUser(c *gin.Context) (*model.User, bool) {
 	user, ok := u.(*model.User)
 	return user, ok
 }
+
+// retrieves the verified access token claims from Gin context (after JWTMiddleware has run).
+func GetCurrentToken(c *gin.Context) (*TokenClaims, bool) {
+	t, exists := c.Get("currentToken")
+	if !exists {
+		return nil, false
+	}
+	claims, ok := t.(*TokenClaims)
+	return claims, ok
+}
+
+// retrieves the *model.APIKey the request authenticated with, if it used one.
+func GetCurrentAPIKey(c *gin.Context) (*model.APIKey, bool) {
+	k, exists := c.Get("currentAPIKey")
+	if !exists {
+		return nil, false
+	}
+	key, ok := k.(*model.APIKey)
+	return key, ok
+}
+
+// retrieves the active *model.OrganizationMember from Gin context (after JWTMiddleware has run).
+func GetCurrentMembership(c *gin.Context) (*model.OrganizationMember, bool) {
+	m, exists := c.Get("currentMembership")
+	if !exists {
+		return nil, false
+	}
+	membership, ok := m.(*model.OrganizationMember)
+	return membership, ok
+}
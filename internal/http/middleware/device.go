@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+// DeviceTokenPrefix marks a bearer credential as a device token issued to a paired screen.
+const DeviceTokenPrefix = "mdt_"
+
+// DeviceTokenHeader is accepted as an alternative to “Authorization: Bearer” for
+// players that cannot set the Authorization header.
+const DeviceTokenHeader = "X-Device-Token"
+
+// IsDeviceIDFormat reports whether id has the form of a device ID: a UUID in its canonical
+// 36-character form, which cannot contain the MQTT wildcards or topic separator.
+func IsDeviceIDFormat(id string) bool {
+	if len(id) != 36 {
+		return false
+	}
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+// ValidDeviceID reports whether a device may register as id. The server's own broker login
+// is never a device ID, since the broker would take a device using it for the server.
+func ValidDeviceID(id string) bool {
+	return IsDeviceIDFormat(id) && id != BrokerUser
+}
+
+// generates a new device token and the hash stored on the screen row.
+func GenerateDeviceToken() (token, hash string, err error) {
+	secret, _, err := GenerateSecretToken()
+	if err != nil {
+		return "", "", err
+	}
+	token = DeviceTokenPrefix + secret
+	return token, HashToken(token), nil
+}
+
+// extracts the device token from the request, or "" if none was sent.
+func deviceTokenFromRequest(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		if token := strings.TrimPrefix(header, "Bearer "); strings.HasPrefix(token, DeviceTokenPrefix) {
+			return token
+		}
+	}
+	return c.GetHeader(DeviceTokenHeader)
+}
+
+// authenticates the screen a request comes from by its device token.
+func authenticateDevice(c *gin.Context) (*model.Screen, bool) {
+	token := deviceTokenFromRequest(c)
+	if token == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "device token required"})
+		return nil, false
+	}
+
+	screen, err := db.GetScreenByDeviceTokenHash(HashToken(token))
+	if err != nil || screen.DeviceID == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid device token"})
+		return nil, false
+	}
+
+	// older players still send their device_id; it must match the token's screen
+	if claimed := c.Query("device_id"); claimed != "" && claimed != *screen.DeviceID {
+		log.Warn().Int("screen_id", screen.ID).Str("claimed_device_id", claimed).
+			Msg("device token used with another device's id")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid device token"})
+		return nil, false
+	}
+	return &screen, true
+}
+
+// DeviceMiddleware requires a valid device token and stores the authenticated screen in the
+// context as "currentScreen".
+func DeviceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		screen, ok := authenticateDevice(c)
+		if !ok {
+			return
+		}
+		c.Set("currentScreen", screen)
+		c.Next()
+	}
+}
+
+// retrieves the *model.Screen authenticated by DeviceMiddleware.
+func GetCurrentScreen(c *gin.Context) (*model.Screen, bool) {
+	s, exists := c.Get("currentScreen")
+	if !exists {
+		return nil, false
+	}
+	screen, ok := s.(*model.Screen)
+	return screen, ok
+}
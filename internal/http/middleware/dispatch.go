@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+	"github.com/Nixie-Tech-LLC/medusa/internal/redis"
+)
+
+// deviceChannel is the Redis channel a device's messages are fanned out on, so that a
+// device streaming /api/tv/events from one server instance hears what another publishes.
+func deviceChannel(deviceID string) string {
+	return fmt.Sprintf("tv:%s:events", deviceID)
+}
+
+// Dispatch delivers payload to the device over every transport it may be listening on:
+// its MQTT topic and any open /api/tv/events stream. It fails only when neither took the
+// message, in which case the device catches up the next time it polls.
+func Dispatch(deviceID string, payload []byte) error {
+	mqttErr := publishMQTT(deviceID, payload, false)
+
+	listeners, err := redis.Rdb.Publish(context.Background(), deviceChannel(deviceID), payload).Result()
+	if mqttErr == nil || (err == nil && listeners > 0) {
+		return nil
+	}
+	if err != nil {
+		return errors.Join(mqttErr, err)
+	}
+	return mqttErr
+}
+
+// PublishCommand sends a command envelope to the device. Commands are not retained: a
+// device that is offline collects them from GET /api/tv/commands instead of replaying
+// stale ones when it reconnects.
+func PublishCommand(deviceID string, envelope []byte) error {
+	return Dispatch(deviceID, envelope)
+}
+
+// PublishEvent sends an event to the device. Events are not retained either; a device
+// that missed one catches up the next time it polls.
+func PublishEvent(deviceID string, event model.DeviceEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return Dispatch(deviceID, payload)
+}
+
+// SubscribeDevice returns the messages dispatched to the device from now on, until ctx is
+// done or the returned close function is called.
+func SubscribeDevice(ctx context.Context, deviceID string) (<-chan *goredis.Message, func() error, error) {
+	sub := redis.Rdb.Subscribe(ctx, deviceChannel(deviceID))
+	// wait for the subscription to be confirmed, so nothing dispatched after we return is lost
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, nil, err
+	}
+	return sub.Channel(), sub.Close, nil
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"encoding/json"
+	"strings"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/tv/packets"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+// heartbeatTopics matches every device's heartbeat topic, tv/{device_id}/heartbeat.
+const heartbeatTopics = "tv/+/heartbeat"
+
+// SubscribeHeartbeats records heartbeats published over MQTT, the equivalent of
+// POST /api/tv/heartbeat for players that stay connected to the broker.
+func SubscribeHeartbeats() {
+	Subscribe(heartbeatTopics, handleHeartbeat)
+}
+
+func handleHeartbeat(_ mqtt.Client, msg mqtt.Message) {
+	parts := strings.Split(msg.Topic(), "/")
+	if len(parts) != 3 {
+		return
+	}
+	// the broker only lets a device publish to its own topic, so the topic names the sender;
+	// nothing in the payload can point the heartbeat at another screen
+	deviceID := parts[1]
+
+	var hb packets.HeartbeatRequest
+	if err := json.Unmarshal(msg.Payload(), &hb); err != nil {
+		log.Warn().Str("topic", msg.Topic()).Msg("malformed heartbeat")
+		return
+	}
+
+	screen, err := db.GetScreenByDeviceID(&deviceID)
+	if err != nil || !screen.Paired {
+		log.Warn().Str("device_id", deviceID).Msg("heartbeat from an unpaired device")
+		return
+	}
+
+	_ = db.RecordScreenHeartbeat(screen.ID, model.Heartbeat{
+		UptimeSeconds: hb.UptimeSeconds,
+		PlaylistETag:  hb.PlaylistETag,
+		AppVersion:    hb.AppVersion,
+	})
+}
@@ -2,26 +2,56 @@ package middleware
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Nixie-Tech-LLC/medusa/internal/db"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// signs a token embedding userID in the “sub” claim.
-func GenerateJWT(userID int, secret string) (string, error) {
+// OrganizationHeader selects which of the user's organizations a request acts on.
+const OrganizationHeader = "X-Organization-ID"
+
+// routes a member of an organization that requires 2FA can still reach before enrolling:
+// their own account endpoints (where enrollment lives) and switching organization.
+var twoFactorExemptPrefixes = []string{"/api/admin/auth/"}
+var twoFactorExemptPaths = []string{"/api/admin/organizations"}
+
+// access tokens are short-lived; sessions are extended through rotating refresh tokens.
+const AccessTokenTTL = 15 * time.Minute
+
+// TokenClaims are the claims of a verified access token.
+type TokenClaims struct {
+	UserID    int
+	TokenID   string // “jti”, used to revoke this token alone
+	SessionID string // “sid”, the refresh token family the token was issued for
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// signs a token embedding userID in the “sub” claim and the login session in “sid”.
+func GenerateJWT(userID int, sessionID, secret string) (string, error) {
+	now := time.Now()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"sub": userID,
-		"exp": time.Now().Add(72 * time.Hour).Unix(),
+		"sid": sessionID,
+		"jti": uuid.NewString(),
+		// fractional seconds, so a token issued just after a "log out all sessions" in the
+		// same second is told apart from the ones it revoked
+		"iat": float64(now.UnixMilli()) / 1000,
+		"exp": now.Add(AccessTokenTTL).Unix(),
 	})
 	return token.SignedString([]byte(secret))
 }
 
-// verifies the JWT and returns the user ID (unexported, only used internally).
-func parseToken(tokenString, secret string) (int, error) {
+// verifies the JWT and returns its claims (unexported, only used internally).
+func parseToken(tokenString, secret string) (*TokenClaims, error) {
 	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
@@ -29,20 +59,35 @@ func parseToken(tokenString, secret string) (int, error) {
 		return []byte(secret), nil
 	})
 	if err != nil || !token.Valid {
-		return 0, errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return 0, errors.New("invalid claims")
+		return nil, errors.New("invalid claims")
 	}
 	sub, ok := claims["sub"].(float64)
 	if !ok {
-		return 0, errors.New("invalid sub claim")
+		return nil, errors.New("invalid sub claim")
 	}
-	return int(sub), nil
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return nil, errors.New("invalid jti claim")
+	}
+	iat, _ := claims["iat"].(float64)
+	exp, _ := claims["exp"].(float64)
+	sid, _ := claims["sid"].(string)
+
+	return &TokenClaims{
+		UserID:    int(sub),
+		TokenID:   jti,
+		SessionID: sid,
+		IssuedAt:  time.UnixMilli(int64(math.Round(iat * 1000))),
+		ExpiresAt: time.Unix(int64(exp), 0),
+	}, nil
 }
 
 // checks “Authorization: Bearer <token>”, verifies it, loads user, and sets “currentUser” in context.
+// Bearer values starting with APIKeyPrefix are authenticated as API keys instead.
 func JWTMiddleware(secret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		header := c.GetHeader("Authorization")
@@ -57,18 +102,94 @@ func JWTMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
-		userID, err := parseToken(parts[1], secret)
+		if strings.HasPrefix(parts[1], APIKeyPrefix) {
+			authenticateAPIKey(c, parts[1])
+			return
+		}
+
+		claims, err := parseToken(parts[1], secret)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 
-		user, err := db.GetUserByID(userID)
+		revoked, err := IsAccessTokenRevoked(c, claims)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "could not verify session"})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+			return
+		}
+
+		user, err := db.GetUserByID(claims.UserID)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
 			return
 		}
+
+		membership, err := resolveMembership(c, user.ID)
+		if err != nil {
+			// a user who belongs to no organization can still manage the account and start one
+			if c.GetHeader(OrganizationHeader) != "" || !isTwoFactorExempt(c.FullPath()) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			c.Set("currentUser", user)
+			c.Set("currentToken", claims)
+			c.Next()
+			return
+		}
+
+		if membership.RequireTwoFactor && !user.TwoFactorEnabled() && !isTwoFactorExempt(c.FullPath()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "two-factor authentication is required by this organization",
+				"code":  "two_factor_enrollment_required",
+			})
+			return
+		}
+
 		c.Set("currentUser", user)
+		c.Set("currentToken", claims)
+		c.Set("currentMembership", membership)
 		c.Next()
 	}
 }
+
+// picks the organization the request acts on: the one named in “X-Organization-ID”,
+// or the user's oldest membership when the header is absent.
+func resolveMembership(c *gin.Context, userID int) (*model.OrganizationMember, error) {
+	var (
+		membership model.OrganizationMember
+		err        error
+	)
+
+	if raw := c.GetHeader(OrganizationHeader); raw != "" {
+		orgID, convErr := strconv.Atoi(raw)
+		if convErr != nil {
+			return nil, errors.New("invalid organization id")
+		}
+		membership, err = db.GetOrganizationMembership(orgID, userID)
+	} else {
+		membership, err = db.GetDefaultOrganizationMembership(userID)
+	}
+	if err != nil {
+		return nil, errors.New("not a member of this organization")
+	}
+	return &membership, nil
+}
+
+func isTwoFactorExempt(path string) bool {
+	for _, p := range twoFactorExemptPaths {
+		if path == p {
+			return true
+		}
+	}
+	for _, p := range twoFactorExemptPrefixes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,28 +1,23 @@
 package middleware
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
-	"github.com/Nixie-Tech-LLC/medusa/internal/db"
-	adminpackets "github.com/Nixie-Tech-LLC/medusa/internal/http/api/admin/control/packets"
-	"github.com/Nixie-Tech-LLC/medusa/internal/http/api/tv/packets"
-	"github.com/Nixie-Tech-LLC/medusa/internal/redis"
-	"github.com/gin-gonic/gin"
-	"net/http"
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+	"errors"
 	"sync"
+	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/rs/zerolog/log"
 )
 
+// MqttClient is the server's single connection to the broker. Every message to or from a
+// device goes through it; devices hold their own connections.
 var (
-	TvClients   = make(map[string]mqtt.Client)
-	ClientMutex sync.RWMutex
-	MqttClient  mqtt.Client
-	BrokerURL   = "ws://localhost:9001" // Default MQTT broker URL
-	BrokerUser  = ""
-	BrokerPass  = ""
+	MqttClient mqtt.Client
+	BrokerURL  = "ws://localhost:9001" // Default MQTT broker URL
+	BrokerUser = ""
+	BrokerPass = ""
 )
 
 // MQTT message handler for TV devices
@@ -30,9 +25,34 @@ var messagePubHandler mqtt.MessageHandler = func(client mqtt.Client, msg mqtt.Me
 	log.Info().Str("topic", msg.Topic()).Msg("Received message")
 }
 
+// ServerClientID is the client ID of the server's MQTT connection.
+const ServerClientID = "medusa-server"
+
+var (
+	subscriptions   = make(map[string]mqtt.MessageHandler)
+	subscriptionsMu sync.Mutex
+)
+
 // MQTT connection handler
 var connectHandler mqtt.OnConnectHandler = func(client mqtt.Client) {
 	log.Info().Msg("Client connected to MQTT broker")
+
+	// a clean session starts without subscriptions, so renew them on every (re)connect
+	subscriptionsMu.Lock()
+	defer subscriptionsMu.Unlock()
+	for topic, handler := range subscriptions {
+		if token := client.Subscribe(topic, 1, handler); token.Wait() && token.Error() != nil {
+			log.Error().Err(token.Error()).Str("topic", topic).Msg("Failed to subscribe to topic")
+		}
+	}
+}
+
+// Subscribe registers handler for topic on the server's MQTT connection. Register before
+// connecting; subscriptions are renewed whenever the connection is re-established.
+func Subscribe(topic string, handler mqtt.MessageHandler) {
+	subscriptionsMu.Lock()
+	defer subscriptionsMu.Unlock()
+	subscriptions[topic] = handler
 }
 
 // MQTT connection lost handler
@@ -78,188 +98,50 @@ func CreateMQTTClient(clientName string) (mqtt.Client, error) {
 	return MqttClient, nil
 }
 
-// SendMessageToScreen sends a message to a specific TV screen via MQTT
-func SendMessageToScreen(deviceID string, message []byte) error {
-	ClientMutex.RLock()
-	client, exists := TvClients[deviceID]
-	ClientMutex.RUnlock()
-	if !exists {
-		return fmt.Errorf("TV device %s not connected", deviceID)
-	}
-	topic := fmt.Sprintf("tv/%s/commands", deviceID)
-	token := client.Publish(topic, 1, true, message)
-	token.Wait()
-
-	if token.Error() != nil {
-		return fmt.Errorf("failed to send message to TV device %s: %v", deviceID, token.Error())
-	}
-
-	redis.Set(context.Background(), topic, message, 0)
-
-	log.Printf("Message sent to TV device %s via MQTT", deviceID)
-	return nil
+// CommandTopic is the topic a device subscribes to for its commands and events.
+func CommandTopic(deviceID string) string {
+	return fmt.Sprintf("tv/%s/commands", deviceID)
 }
 
-// SendMessageToAllScreens sends a message to all connected TV screens
-func SendMessageToAllScreens(message []byte) error {
-	ClientMutex.RLock()
-	defer ClientMutex.RUnlock()
-
-	var errors []string
-	for deviceID, client := range TvClients {
-		topic := fmt.Sprintf("tv/%s/commands", deviceID)
-		token := client.Publish(topic, 1, false, message)
-		token.Wait()
+// HeartbeatTopic is the topic a device publishes its heartbeats to.
+func HeartbeatTopic(deviceID string) string {
+	return fmt.Sprintf("tv/%s/heartbeat", deviceID)
+}
 
-		if token.Error() != nil {
-			errors = append(errors, fmt.Sprintf("device %s: %v", deviceID, token.Error()))
-		}
+// publishMQTT sends payload to the device's command topic over the server's MQTT connection.
+func publishMQTT(deviceID string, payload []byte, retained bool) error {
+	if MqttClient == nil || !MqttClient.IsConnectionOpen() {
+		return errors.New("not connected to MQTT broker")
 	}
-
-	if len(errors) > 0 {
-		return fmt.Errorf("failed to send messages to some devices: %v", errors)
+	topic := CommandTopic(deviceID)
+	token := MqttClient.Publish(topic, 1, retained, payload)
+	if !token.WaitTimeout(5 * time.Second) {
+		return fmt.Errorf("timed out publishing to TV device %s", deviceID)
 	}
-
-	log.Printf("Message sent to all %d connected TV devices via MQTT", len(TvClients))
-	return nil
+	return token.Error()
 }
 
-// DisconnectTV disconnects a specific TV device
+// DisconnectTV ends a device's session after its token is revoked or it is unpaired.
+// Devices hold their own broker connections, so the server cannot drop them; instead it
+// tells the device its session is over, which also closes its /api/tv/events stream, and
+// clears anything retained on its topic.
 func DisconnectTV(deviceID string) {
-	ClientMutex.Lock()
-	defer ClientMutex.Unlock()
-
-	if client, exists := TvClients[deviceID]; exists {
-		client.Disconnect(250)
-		delete(TvClients, deviceID)
-		log.Printf("TV device %s disconnected from MQTT", deviceID)
+	if err := PublishEvent(deviceID, model.NewDeviceEvent(model.EventSessionRevoked, "", nil)); err != nil {
+		log.Warn().Err(err).Str("deviceID", deviceID).Msg("Failed to notify device of revoked session")
 	}
-}
-
-// GetConnectedTVs returns a list of connected TV device IDs
-func GetConnectedTVs() []string {
-	ClientMutex.RLock()
-	defer ClientMutex.RUnlock()
-
-	devices := make([]string, 0, len(TvClients))
-	for deviceID := range TvClients {
-		devices = append(devices, deviceID)
+	// an empty retained message removes the one the broker holds, if any
+	if MqttClient == nil {
+		return
+	}
+	if err := publishMQTT(deviceID, nil, true); err != nil {
+		log.Warn().Err(err).Str("deviceID", deviceID).Msg("Failed to clear retained message for device")
 	}
-	return devices
 }
 
-// CleanupMQTT disconnects all clients and the main MQTT client
+// CleanupMQTT disconnects the server's MQTT connection
 func CleanupMQTT() {
-	ClientMutex.Lock()
-	defer ClientMutex.Unlock()
-
-	// Disconnect all TV clients
-	for deviceID, client := range TvClients {
-		client.Disconnect(250)
-		log.Printf("Disconnected TV device %s", deviceID)
-	}
-	TvClients = make(map[string]mqtt.Client)
-
-	// Disconnect main MQTT client
 	if MqttClient != nil {
 		MqttClient.Disconnect(250)
 		log.Info().Msg("MQTT client disconnected")
 	}
 }
-
-// tvWebSocket is an MQTT-based handler for TV device connections
-func tvWebSocket(ctx *gin.Context) {
-	var request packets.TVRequest
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		log.Error().Err(err).Msg("Error parsing request")
-		ctx.JSON(400, gin.H{"error": err.Error()})
-		return
-	}
-
-	screen, err := db.GetScreenByDeviceID(&request.DeviceID)
-	if err != nil {
-		log.Error().Err(err).Str("deviceID", request.DeviceID).Msg("Device ID not found")
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized device"})
-		return
-	}
-
-	// Check if screen has a device ID assigned
-	if screen.DeviceID == nil {
-		log.Error().Str("deviceID", request.DeviceID).Msg("Screen found but device ID is nil")
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "screen not properly paired"})
-		return
-	}
-
-	deviceID := *screen.DeviceID
-
-	// Check if client already exists and disconnect it
-	DisconnectTV(deviceID)
-
-	// Create MQTT client for this TV device
-	client, err := CreateMQTTClient(fmt.Sprintf("tv-%s", deviceID))
-	if err != nil {
-		log.Error().Err(err).Str("deviceID", deviceID).Msg("Failed to connect TV to MQTT")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to connect to MQTT"})
-		return
-	}
-
-	// Subscribe to device-specific topic
-	topic := fmt.Sprintf("tv/%s/commands", deviceID)
-	if token := client.Subscribe(topic, 1, nil); token.Wait() && token.Error() != nil {
-		log.Error().Err(err).Str("deviceID", deviceID).Str("topic", topic).Msg("Failed to subscribe to topic")
-		client.Disconnect(250)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to subscribe to MQTT topic"})
-		return
-	}
-
-	// Store the client in the global map
-	ClientMutex.Lock()
-	TvClients[deviceID] = client
-	ClientMutex.Unlock()
-
-	log.Info().Str("deviceID", deviceID).Msg("Connected device to MQTT")
-
-	// Check for pending playlist assignments and send them
-	go func() {
-		// Get playlist content if one is assigned to this screen
-		playlistName, contentItems, err := db.GetPlaylistContentForScreen(screen.ID)
-		if err == nil && len(contentItems) > 0 {
-			log.Info().Str("deviceID", deviceID).Str("playlist_name", playlistName).
-				Msg("Sending pending playlist to newly connected device")
-
-			// Create response for TV client
-			contentList := make([]adminpackets.TVContentItem, len(contentItems))
-			for i, item := range contentItems {
-				contentList[i] = adminpackets.TVContentItem{
-					URL:      item.URL,
-					Duration: item.Duration,
-					Type: 	  item.Type,
-				}
-			}
-
-			response, err := json.Marshal(adminpackets.TVPlaylistResponse{
-				PlaylistName: playlistName,
-				ContentList:  contentList,
-			})
-			if err != nil {
-				log.Error().Err(err).Str("deviceID", deviceID).
-					Msg("Failed to marshal pending playlist response")
-				return
-			}
-
-			// Send the playlist to the newly connected device
-			if err := SendMessageToScreen(deviceID, response); err != nil {
-				log.Error().Err(err).Str("deviceID", deviceID).
-					Msg("Failed to send pending playlist to device")
-			} else {
-				log.Info().Str("deviceID", deviceID).Str("playlist_name", playlistName).
-					Msg("Successfully sent pending playlist to device")
-			}
-		}
-	}()
-
-	redis.Rdb.Del(ctx, request.PairingCode)
-	ctx.JSON(http.StatusOK, gin.H{"success": "device connected successfully"})
-
-	return
-}
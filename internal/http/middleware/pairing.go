@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"time"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/redis"
+)
+
+// Pairing codes are shown on the TV and typed into the dashboard, so they are short and
+// avoid look-alike characters (0/O, 1/I/L). 31^6 is close to a billion codes.
+const (
+	pairingCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	pairingCodeLength   = 6
+
+	// how long a code shown on a TV stays valid, and how long its device token waits for collection
+	PairingCodeTTL = 10 * time.Minute
+)
+
+// PairingData is kept in Redis under PairingKey while a code waits to be claimed.
+type PairingData struct {
+	DeviceID string `json:"device_id"`
+}
+
+// DeviceTokenDelivery is kept in Redis under DeviceTokenDeliveryKey until the paired
+// device collects its token.
+type DeviceTokenDelivery struct {
+	DeviceID    string `json:"device_id"`
+	DeviceToken string `json:"device_token"`
+}
+
+func PairingKey(code string) string { return "tv:pairing:" + code }
+
+// DeviceTokenDeliveryKey holds a freshly issued device token until the device collects
+// it by pinging with its pairing code.
+func DeviceTokenDeliveryKey(code string) string { return "tv:device_token:" + code }
+
+// generates a random pairing code from pairingCodeAlphabet.
+func GeneratePairingCode() (string, error) {
+	max := big.NewInt(int64(len(pairingCodeAlphabet)))
+	code := make([]byte, pairingCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = pairingCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// Wrong pairing codes are limited per caller so the code space cannot be enumerated.
+const (
+	pairingMissLimit  = 10
+	pairingMissWindow = 15 * time.Minute
+)
+
+func pairingMissKey(caller string) string { return "tv:pairing:misses:" + caller }
+
+// PairingGuessesExceeded reports whether caller has used up its wrong pairing codes, and
+// how long until they reset. It must be checked before looking the code up.
+func PairingGuessesExceeded(ctx context.Context, caller string) (bool, time.Duration) {
+	return redis.Exceeded(ctx, pairingMissKey(caller), pairingMissLimit, pairingMissWindow)
+}
+
+// RecordPairingMiss counts one wrong pairing code against caller.
+func RecordPairingMiss(ctx context.Context, caller string) {
+	_, _ = redis.IncrWithin(ctx, pairingMissKey(caller), pairingMissWindow)
+}
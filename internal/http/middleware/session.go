@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/redis"
+)
+
+// refresh tokens outlive access tokens and are rotated on every use.
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+func revokedTokenKey(tokenID string) string {
+	return fmt.Sprintf("auth:revoked:%s", tokenID)
+}
+
+func tokensValidAfterKey(userID int) string {
+	return fmt.Sprintf("auth:user:%d:valid_after_ms", userID)
+}
+
+// generates an opaque single-purpose token (refresh, password reset, ...) and the hash
+// under which it is stored.
+func GenerateSecretToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashToken(token), nil
+}
+
+// hashes a high-entropy secret token for storage; unlike passwords these need no slow hash.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// deny-lists a single access token until it would have expired anyway.
+func RevokeAccessToken(ctx context.Context, claims *TokenClaims) error {
+	ttl := time.Until(claims.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	if err := redis.Rdb.Set(ctx, revokedTokenKey(claims.TokenID), 1, ttl).Err(); err != nil {
+		log.Error().Err(err).Str("jti", claims.TokenID).Msg("failed to revoke access token")
+		return err
+	}
+	return nil
+}
+
+// invalidates every access token issued to the user up to now. The marker only needs to
+// live as long as the longest-lived access token it could reject.
+func RevokeAllAccessTokens(ctx context.Context, userID int) error {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	if err := redis.Rdb.Set(ctx, tokensValidAfterKey(userID), now, AccessTokenTTL).Err(); err != nil {
+		log.Error().Err(err).Int("user_id", userID).Msg("failed to revoke access tokens for user")
+		return err
+	}
+	return nil
+}
+
+// reports whether the token was revoked individually or by a “log out all sessions”.
+func IsAccessTokenRevoked(ctx context.Context, claims *TokenClaims) (bool, error) {
+	n, err := redis.Rdb.Exists(ctx, revokedTokenKey(claims.TokenID)).Result()
+	if err != nil {
+		log.Error().Err(err).Str("jti", claims.TokenID).Msg("failed to check access token revocation")
+		return false, err
+	}
+	if n > 0 {
+		return true, nil
+	}
+
+	raw, err := redis.Rdb.Get(ctx, tokensValidAfterKey(claims.UserID)).Result()
+	if errors.Is(err, goredis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Int("user_id", claims.UserID).Msg("failed to check user token cutoff")
+		return false, err
+	}
+	validAfter, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return false, nil
+	}
+	// tokens issued in the same millisecond as the cutoff, after it, are the new session's
+	return claims.IssuedAt.UnixMilli() < validAfter, nil
+}
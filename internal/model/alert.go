@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// Channels an alert rule can notify.
+const (
+	AlertChannelWebhook = "webhook"
+	AlertChannelSlack   = "slack"
+	AlertChannelEmail   = "email"
+)
+
+// Alert states.
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// AlertRule notifies Target through Channel when a screen it watches has made no contact
+// for OfflineAfterSeconds. It watches either a single screen or every screen in a group.
+type AlertRule struct {
+	ID                  int       `db:"id"                    json:"id"`
+	OrganizationID      int       `db:"organization_id"       json:"organization_id"`
+	Name                string    `db:"name"                  json:"name"`
+	ScreenID            *int      `db:"screen_id"             json:"screen_id"`
+	GroupID             *int      `db:"group_id"              json:"group_id"`
+	OfflineAfterSeconds int       `db:"offline_after_seconds" json:"offline_after_seconds"`
+	Channel             string    `db:"channel"               json:"channel"`
+	Target              string    `db:"target"                json:"target"`
+	Enabled             bool      `db:"enabled"               json:"enabled"`
+	CreatedBy           *int      `db:"created_by"            json:"created_by"`
+	CreatedAt           time.Time `db:"created_at"            json:"created_at"`
+	UpdatedAt           time.Time `db:"updated_at"            json:"updated_at"`
+}
+
+// AlertState is a rule's current state for one of its screens.
+type AlertState struct {
+	RuleID     int        `db:"rule_id"     json:"rule_id"`
+	RuleName   string     `db:"rule_name"   json:"rule_name"`
+	ScreenID   int        `db:"screen_id"   json:"screen_id"`
+	ScreenName string     `db:"screen_name" json:"screen_name"`
+	State      string     `db:"state"       json:"state"`
+	FiredAt    time.Time  `db:"fired_at"    json:"fired_at"`
+	ResolvedAt *time.Time `db:"resolved_at" json:"resolved_at"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// APIKey lets a machine client act for its creator within one organization.
+// Only the SHA-256 of the key is stored; Prefix is kept so keys can be told apart.
+type APIKey struct {
+	ID             int          `db:"id"              json:"id"`
+	OrganizationID int          `db:"organization_id" json:"organization_id"`
+	CreatedBy      int          `db:"created_by"      json:"created_by"`
+	Name           string       `db:"name"            json:"name"`
+	Prefix         string       `db:"prefix"          json:"prefix"`
+	KeyHash        string       `db:"key_hash"        json:"-"`
+	ExpiresAt      *time.Time   `db:"expires_at"      json:"expires_at"`
+	LastUsedAt     *time.Time   `db:"last_used_at"    json:"last_used_at"`
+	RevokedAt      *time.Time   `db:"revoked_at"      json:"revoked_at"`
+	CreatedAt      time.Time    `db:"created_at"      json:"created_at"`
+	Scopes         []Permission `db:"-"               json:"scopes"`
+}
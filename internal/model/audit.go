@@ -0,0 +1,23 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent records a security-relevant action. Before/After hold the entity's state
+// around a change; Metadata carries anything else worth keeping.
+type AuditEvent struct {
+	ID             int             `db:"id"               json:"id"`
+	OrganizationID *int            `db:"organization_id"  json:"organization_id"`
+	ActorUserID    *int            `db:"actor_user_id"    json:"actor_user_id"`
+	ActorAPIKeyID  *int            `db:"actor_api_key_id" json:"actor_api_key_id"`
+	Action         string          `db:"action"           json:"action"`
+	EntityType     string          `db:"entity_type"      json:"entity_type"`
+	EntityID       *string         `db:"entity_id"        json:"entity_id"`
+	Before         json.RawMessage `db:"before"           json:"before"`
+	After          json.RawMessage `db:"after"            json:"after"`
+	Metadata       json.RawMessage `db:"metadata"         json:"metadata"`
+	IP             *string         `db:"ip"               json:"ip"`
+	CreatedAt      time.Time       `db:"created_at"       json:"created_at"`
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CommandEnvelopeVersion is the version of the envelope commands are sent to devices in.
+// Devices should ignore envelopes with a version they do not know.
+const CommandEnvelopeVersion = 1
+
+// Command types a device understands.
+const (
+	CommandReloadContent  = "reload_content"
+	CommandReboot         = "reboot"
+	CommandClearCache     = "clear_cache"
+	CommandSetVolume      = "set_volume"
+	CommandTakeScreenshot = "take_screenshot"
+)
+
+// CommandTypes lists every command type that may be issued.
+var CommandTypes = []string{
+	CommandReloadContent, CommandReboot, CommandClearCache, CommandSetVolume, CommandTakeScreenshot,
+}
+
+// Command statuses. A command that is neither acknowledged nor sent before it expires is
+// reported as expired.
+const (
+	CommandPending   = "pending"
+	CommandSent      = "sent"
+	CommandSucceeded = "succeeded"
+	CommandFailed    = "failed"
+	CommandExpired   = "expired"
+)
+
+// DeviceCommand is a command issued to a screen's device and its acknowledgement.
+type DeviceCommand struct {
+	ID             string          `db:"id"              json:"id"`
+	OrganizationID int             `db:"organization_id" json:"organization_id"`
+	ScreenID       int             `db:"screen_id"       json:"screen_id"`
+	Type           string          `db:"type"            json:"type"`
+	Params         json.RawMessage `db:"params"          json:"params"`
+	Status         string          `db:"status"          json:"status"`
+	Message        *string         `db:"message"         json:"message"`
+	Result         json.RawMessage `db:"result"          json:"result"`
+	IssuedBy       *int            `db:"issued_by"       json:"issued_by"`
+	CreatedAt      time.Time       `db:"created_at"      json:"created_at"`
+	ExpiresAt      time.Time       `db:"expires_at"      json:"expires_at"`
+	SentAt         *time.Time      `db:"sent_at"         json:"sent_at"`
+	AckedAt        *time.Time      `db:"acked_at"        json:"acked_at"`
+}
+
+// StatusAt reports the command's status, treating unacknowledged commands past their
+// expiry as expired.
+func (c *DeviceCommand) StatusAt(now time.Time) string {
+	if c.AckedAt == nil && now.After(c.ExpiresAt) {
+		return CommandExpired
+	}
+	return c.Status
+}
+
+// Envelope is the message published to the device.
+func (c *DeviceCommand) Envelope() CommandEnvelope {
+	return CommandEnvelope{
+		Version:   CommandEnvelopeVersion,
+		ID:        c.ID,
+		Type:      c.Type,
+		Params:    c.Params,
+		IssuedAt:  c.CreatedAt.UTC(),
+		ExpiresAt: c.ExpiresAt.UTC(),
+	}
+}
+
+// CommandEnvelope is the versioned wire format of a command, published to
+// tv/{device_id}/commands and returned by GET /api/tv/commands.
+type CommandEnvelope struct {
+	Version   int             `json:"v"`
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	IssuedAt  time.Time       `json:"issued_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
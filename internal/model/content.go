@@ -9,7 +9,19 @@ type Content struct {
 	URL       string    `db:"url"          json:"url"`
 	Width     int       `db:"resolution_width"        json:"width"`
 	Height    int       `db:"resolution_height"       json:"height"`
+	OrganizationID int  `db:"organization_id" json:"organization_id"`
 	CreatedAt time.Time `db:"created_at"   json:"created_at"`
 	CreatedBy int       `db:"created_by"   json:"created_by"`
 	UpdatedAt time.Time `db:"updated_at"   json:"updated_at"`
+	// set for uploaded files only
+	SHA256    *string   `db:"sha256"       json:"sha256"`
+	SizeBytes *int64    `db:"size_bytes"   json:"size_bytes"`
+	MimeType  *string   `db:"mime_type"    json:"mime_type"`
+}
+
+// ContentAsset describes an uploaded file: its hex SHA-256, size and MIME type.
+type ContentAsset struct {
+	SHA256    string
+	SizeBytes int64
+	MimeType  string
 }
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// Event types pushed to devices alongside commands. Unlike commands, events are not
+// stored and are never acknowledged.
+const (
+	// EventContentChanged tells the device to refetch GET /api/tv/content.
+	EventContentChanged = "content_changed"
+	// EventSessionRevoked tells the device its token is no longer valid.
+	EventSessionRevoked = "session_revoked"
+)
+
+// Reasons carried by content_changed events.
+const (
+	ContentChangedPlaylistUpdated  = "playlist_updated"
+	ContentChangedPlaylistAssigned = "playlist_assigned"
+	ContentChangedScheduleUpdated  = "schedule_updated"
+)
+
+// DeviceEvent is the wire format of an event published to tv/{device_id}/commands. It
+// shares the command envelope's version and "type" field, so devices can tell the two
+// apart by type.
+type DeviceEvent struct {
+	Version    int       `json:"v"`
+	Type       string    `json:"type"`
+	Reason     string    `json:"reason,omitempty"`
+	PlaylistID *int      `json:"playlist_id,omitempty"`
+	IssuedAt   time.Time `json:"issued_at"`
+}
+
+// NewDeviceEvent returns an event of the given type, stamped with the current time.
+func NewDeviceEvent(eventType, reason string, playlistID *int) DeviceEvent {
+	return DeviceEvent{
+		Version:    CommandEnvelopeVersion,
+		Type:       eventType,
+		Reason:     reason,
+		PlaylistID: playlistID,
+		IssuedAt:   time.Now().UTC(),
+	}
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Invitation offers an email address membership of an organization with a given role.
+// It is pending until accepted, revoked or expired.
+type Invitation struct {
+	ID             int        `db:"id"              json:"id"`
+	OrganizationID int        `db:"organization_id" json:"organization_id"`
+	Email          string     `db:"email"           json:"email"`
+	Role           string     `db:"role"            json:"role"`
+	InvitedBy      *int       `db:"invited_by"      json:"invited_by"`
+	ExpiresAt      time.Time  `db:"expires_at"      json:"expires_at"`
+	AcceptedAt     *time.Time `db:"accepted_at"     json:"accepted_at"`
+	AcceptedBy     *int       `db:"accepted_by"     json:"accepted_by"`
+	RevokedAt      *time.Time `db:"revoked_at"      json:"revoked_at"`
+	CreatedAt      time.Time  `db:"created_at"      json:"created_at"`
+}
+
+// IsPending reports whether the invitation can still be accepted.
+func (i *Invitation) IsPending() bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && time.Now().Before(i.ExpiresAt)
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// Organization is a workspace that owns screens, content, playlists, schedules and screen groups.
+type Organization struct {
+	ID               int       `db:"id"           json:"id"`
+	Name             string    `db:"name"         json:"name"`
+	CreatedBy        int       `db:"created_by"   json:"created_by"`
+	RequireTwoFactor bool      `db:"require_two_factor" json:"require_two_factor"`
+	CreatedAt        time.Time `db:"created_at"   json:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"   json:"updated_at"`
+}
+
+// OrganizationMember links a user to an organization with the role they hold there.
+type OrganizationMember struct {
+	OrganizationID int          `db:"organization_id" json:"organization_id"`
+	UserID         int          `db:"user_id"         json:"user_id"`
+	Email          string       `db:"email"           json:"email"`
+	Name           *string      `db:"name"            json:"name"`
+	Role           string       `db:"role"            json:"role"`
+	JoinedAt       time.Time    `db:"joined_at"       json:"joined_at"`
+	Permissions    []Permission `db:"-"               json:"permissions"`
+
+	// set on single-membership lookups from the organization's settings
+	RequireTwoFactor bool `db:"require_two_factor" json:"require_two_factor"`
+}
+
+// Can reports whether the member's role grants every one of perms.
+func (m *OrganizationMember) Can(perms ...Permission) bool {
+	for _, want := range perms {
+		granted := false
+		for _, have := range m.Permissions {
+			if have == want {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			return false
+		}
+	}
+	return true
+}
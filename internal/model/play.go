@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// PlayEvent is a player's report that it showed a content item, for proof-of-play.
+type PlayEvent struct {
+	ContentID  int       `json:"content_id"`
+	PlaylistID int       `json:"playlist_id"` // 0 when played outside a playlist
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+}
+
+// Dimensions play statistics can be grouped by.
+const (
+	PlayByContent  = "content"
+	PlayByPlaylist = "playlist"
+	PlayByScreen   = "screen"
+	PlayByDay      = "day"
+)
+
+// PlayStat aggregates play events over the requested dimensions; dimensions that were not
+// requested are left nil.
+type PlayStat struct {
+	ContentID    *int    `db:"content_id"    json:"content_id,omitempty"`
+	ContentName  *string `db:"content_name"  json:"content_name,omitempty"`
+	PlaylistID   *int    `db:"playlist_id"   json:"playlist_id,omitempty"`
+	PlaylistName *string `db:"playlist_name" json:"playlist_name,omitempty"`
+	ScreenID     *int    `db:"screen_id"     json:"screen_id,omitempty"`
+	ScreenName   *string `db:"screen_name"   json:"screen_name,omitempty"`
+	Day          *string `db:"day"           json:"day,omitempty"` // YYYY-MM-DD, UTC
+	Plays        int     `db:"plays"         json:"plays"`
+	TotalSeconds int64   `db:"total_seconds" json:"total_seconds"`
+	Screens      int     `db:"screens"       json:"screens"`
+}
@@ -6,6 +6,7 @@ type Playlist struct {
 	ID          int            `db:"id"           json:"id"`
 	Name        string         `db:"name"         json:"name"`
 	Description *string        `db:"description"  json:"description,omitempty"`
+	OrganizationID int         `db:"organization_id" json:"organization_id"`
 	CreatedAt   time.Time      `db:"created_at"   json:"created_at"`
 	UpdatedAt   time.Time      `db:"updated_at"   json:"updated_at"`
 	CreatedBy   int            `db:"created_by"   json:"created_by"`
@@ -0,0 +1,53 @@
+package model
+
+// Permission is a single capability granted to a role, e.g. "playlists:write".
+type Permission string
+
+const (
+	PermScreensRead    Permission = "screens:read"
+	PermScreensWrite   Permission = "screens:write"
+	PermScreensPublish Permission = "screens:publish" // assign playlists to screens
+	PermContentRead    Permission = "content:read"
+	PermContentWrite   Permission = "content:write"
+	PermPlaylistsRead  Permission = "playlists:read"
+	PermPlaylistsWrite Permission = "playlists:write"
+	PermSchedulesRead  Permission = "schedules:read"
+	PermSchedulesWrite Permission = "schedules:write"
+	PermMembersManage  Permission = "members:manage"
+	PermAuditRead      Permission = "audit:read"
+)
+
+// AllPermissions lists every permission a role or API key scope may grant.
+var AllPermissions = []Permission{
+	PermScreensRead, PermScreensWrite, PermScreensPublish,
+	PermContentRead, PermContentWrite,
+	PermPlaylistsRead, PermPlaylistsWrite,
+	PermSchedulesRead, PermSchedulesWrite,
+	PermMembersManage, PermAuditRead,
+}
+
+// IsValid reports whether p is a known permission.
+func (p Permission) IsValid() bool {
+	for _, known := range AllPermissions {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Built-in roles seeded by the roles migration.
+const (
+	RoleOwner     = "owner"
+	RoleEditor    = "editor"
+	RolePublisher = "publisher"
+	RoleViewer    = "viewer"
+	RoleOperator  = "operator" // only reaches screens through screen assignments
+)
+
+// Role is a named set of permissions a member holds within an organization.
+type Role struct {
+	Name        string       `db:"name"        json:"name"`
+	Description string       `db:"description" json:"description"`
+	Permissions []Permission `db:"-"           json:"permissions"`
+}
@@ -5,6 +5,7 @@ import "time"
 type Schedule struct {
 	ID        int       `db:"id" json:"id"`
 	Name      string    `db:"name" json:"name"`
+	OrganizationID int  `db:"organization_id" json:"organization_id"`
 	CreatedBy int       `db:"created_by" json:"created_by"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
@@ -12,17 +12,95 @@ type Screen struct {
 	Name              string    `db:"name"         json:"name"`
 	Location          *string   `db:"location"     json:"location"`
 	Paired            bool      `db:"paired"       json:"paired"`
+	OrganizationID    int       `db:"organization_id" json:"organization_id"`
 	CreatedAt         time.Time `db:"created_at"   json:"created_at"`
 	CreatedBy         int       `db:"created_by"   json:"created_by"`
 	UpdatedAt         time.Time `db:"updated_at"   json:"updated_at"`
+
+	// latest heartbeat
+	LastSeenAt    *time.Time `db:"last_seen_at"   json:"last_seen_at"`
+	UptimeSeconds *int64     `db:"uptime_seconds" json:"uptime_seconds"`
+	PlaylistETag  *string    `db:"playlist_etag"  json:"playlist_etag"`
+	AppVersion    *string    `db:"app_version"    json:"app_version"`
+
+	// latest report: the capacity of the device's storage and the address it came from
+	StorageSize *int64  `db:"storage_size" json:"storage_size"`
+	IPAddress   *string `db:"ip_address"   json:"ip_address"`
+}
+
+// ScreenAssignment delegates a single screen to a user, who may then view it and
+// publish playlists to it without holding organization-wide screen permissions.
+type ScreenAssignment struct {
+	ScreenID   int       `db:"screen_id"   json:"screen_id"`
+	UserID     int       `db:"user_id"     json:"user_id"`
+	Email      string    `db:"email"       json:"email"`
+	Name       *string   `db:"name"        json:"name"`
+	AssignedBy *int      `db:"assigned_by" json:"assigned_by"`
+	AssignedAt time.Time `db:"assigned_at" json:"assigned_at"`
 }
 
 type ScreenGroup struct {
     ID          int       `db:"id"`
     Name        string    `db:"name"`
     Description *string   `db:"description"`
+    OrganizationID int    `db:"organization_id"`
     CreatedBy   int       `db:"created_by"`
     CreatedAt   time.Time `db:"created_at"`
     UpdatedAt   time.Time `db:"updated_at"`
 }
 
+
+// ScreenDevice records one physical device's time bound to a screen.
+type ScreenDevice struct {
+	ID           int        `db:"id"            json:"id"`
+	ScreenID     int        `db:"screen_id"     json:"screen_id"`
+	DeviceID     string     `db:"device_id"     json:"device_id"`
+	BoundBy      *int       `db:"bound_by"      json:"bound_by"`
+	BoundAt      time.Time  `db:"bound_at"      json:"bound_at"`
+	UnboundBy    *int       `db:"unbound_by"    json:"unbound_by"`
+	UnboundAt    *time.Time `db:"unbound_at"    json:"unbound_at"`
+	UnbindReason *string    `db:"unbind_reason" json:"unbind_reason"`
+}
+
+// Reasons a device stops being bound to a screen.
+const (
+	UnbindReasonUnpaired = "unpaired"
+	UnbindReasonReplaced = "replaced"
+)
+
+// Heartbeat is what a device reports each time it checks in.
+type Heartbeat struct {
+	UptimeSeconds int64
+	PlaylistETag  string
+	AppVersion    string
+}
+
+// Screen statuses derived from the time since the last heartbeat.
+const (
+	ScreenOnline  = "online"
+	ScreenStale   = "stale"
+	ScreenOffline = "offline"
+)
+
+// PresenceThresholds decide how long a screen may go without a heartbeat before it is
+// considered stale, then offline.
+type PresenceThresholds struct {
+	StaleAfter   time.Duration
+	OfflineAfter time.Duration
+}
+
+// Status returns the screen status at now for a device last seen at lastSeen.
+// A screen that has never sent a heartbeat is offline.
+func (p PresenceThresholds) Status(lastSeen *time.Time, now time.Time) string {
+	if lastSeen == nil {
+		return ScreenOffline
+	}
+	switch since := now.Sub(*lastSeen); {
+	case since < p.StaleAfter:
+		return ScreenOnline
+	case since < p.OfflineAfter:
+		return ScreenStale
+	default:
+		return ScreenOffline
+	}
+}
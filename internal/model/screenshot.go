@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Screenshot is an image of what a screen was showing, uploaded by its device. URL is the
+// image's private storage location; it is only served through the admin API.
+type Screenshot struct {
+	ID             int       `db:"id"              json:"id"`
+	OrganizationID int       `db:"organization_id" json:"organization_id"`
+	ScreenID       int       `db:"screen_id"       json:"screen_id"`
+	CommandID      *string   `db:"command_id"      json:"command_id"`
+	URL            string    `db:"url"             json:"url"`
+	ContentType    string    `db:"content_type"    json:"content_type"`
+	SizeBytes      int64     `db:"size_bytes"      json:"size_bytes"`
+	CapturedAt     time.Time `db:"captured_at"     json:"captured_at"`
+}
+
+// ScreenshotRetention bounds how many screenshots are kept: at most Keep per screen, and
+// none older than MaxAge.
+type ScreenshotRetention struct {
+	Keep   int
+	MaxAge time.Duration
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// RefreshToken is one link in a login session's rotation chain. Only the SHA-256 of the
+// token is stored; FamilyID identifies the session across rotations.
+type RefreshToken struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	FamilyID  string     `db:"family_id"`
+	TokenHash string     `db:"token_hash"`
+	UserAgent *string    `db:"user_agent"`
+	IP        *string    `db:"ip"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// Network types a device may report.
+const (
+	NetworkEthernet = "ethernet"
+	NetworkWifi     = "wifi"
+	NetworkCellular = "cellular"
+)
+
+// Telemetry is a device's health at one moment. Every metric is optional, since players
+// report only what their platform exposes.
+type Telemetry struct {
+	CPUPercent         *float64 `db:"cpu_percent"         json:"cpu_percent"`
+	MemoryUsedBytes    *int64   `db:"memory_used_bytes"   json:"memory_used_bytes"`
+	MemoryTotalBytes   *int64   `db:"memory_total_bytes"  json:"memory_total_bytes"`
+	TemperatureCelsius *float64 `db:"temperature_celsius" json:"temperature_celsius"`
+	StorageUsedBytes   *int64   `db:"storage_used_bytes"  json:"storage_used_bytes"`
+	StorageTotalBytes  *int64   `db:"storage_total_bytes" json:"storage_total_bytes"`
+	NetworkType        *string  `db:"network_type"        json:"network_type"`
+	SignalDBM          *int     `db:"signal_dbm"          json:"signal_dbm"`
+	RxBytesPerSecond   *int64   `db:"rx_bytes_per_second" json:"rx_bytes_per_second"`
+	TxBytesPerSecond   *int64   `db:"tx_bytes_per_second" json:"tx_bytes_per_second"`
+}
+
+// TelemetrySample is a screen's telemetry as recorded at a point in time.
+type TelemetrySample struct {
+	ScreenID   int       `db:"screen_id"   json:"screen_id"`
+	RecordedAt time.Time `db:"recorded_at" json:"recorded_at"`
+	IPAddress  *string   `db:"ip_address"  json:"ip_address"` // where the report came from
+	Telemetry
+}
+
+// TelemetryBucket aggregates the samples recorded within one bucket of a history: gauges
+// are averaged, capacities take the bucket's maximum. Metrics no sample reported are nil.
+type TelemetryBucket struct {
+	Start              time.Time `db:"bucket_start"`
+	Samples            int       `db:"samples"`
+	CPUPercent         *float64  `db:"cpu_percent"`
+	MemoryUsedBytes    *float64  `db:"memory_used_bytes"`
+	MemoryTotalBytes   *float64  `db:"memory_total_bytes"`
+	TemperatureCelsius *float64  `db:"temperature_celsius"`
+	StorageUsedBytes   *float64  `db:"storage_used_bytes"`
+	StorageTotalBytes  *float64  `db:"storage_total_bytes"`
+	SignalDBM          *float64  `db:"signal_dbm"`
+	RxBytesPerSecond   *float64  `db:"rx_bytes_per_second"`
+	TxBytesPerSecond   *float64  `db:"tx_bytes_per_second"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Content upload states: chunks are accepted while uploading; writing means one chunk has
+// claimed the offset and is being stored; completing means the file is being assembled into
+// content.
+const (
+	UploadUploading  = "uploading"
+	UploadWriting    = "writing"
+	UploadCompleting = "completing"
+)
+
+// ContentUpload is a file being uploaded in chunks. Its content is created once all
+// SizeBytes have been received.
+type ContentUpload struct {
+	ID              string    `db:"id"                json:"id"`
+	OrganizationID  int       `db:"organization_id"   json:"organization_id"`
+	CreatedBy       int       `db:"created_by"        json:"created_by"`
+	Name            string    `db:"name"              json:"name"`
+	Type            string    `db:"type"              json:"type"`
+	Width           int       `db:"width"             json:"width"`
+	Height          int       `db:"height"            json:"height"`
+	Filename        string    `db:"filename"          json:"filename"`
+	SizeBytes       int64     `db:"size_bytes"        json:"size_bytes"`
+	ReceivedBytes   int64     `db:"received_bytes"    json:"received_bytes"`
+	Parts           int       `db:"parts"             json:"parts"`
+	MimeType        *string   `db:"mime_type"         json:"mime_type"`
+	HashState       []byte    `db:"hash_state"        json:"-"`
+	StorageKey      string    `db:"storage_key"       json:"-"`
+	StorageUploadID *string   `db:"storage_upload_id" json:"-"`
+	Status          string    `db:"status"            json:"status"`
+	CreatedAt       time.Time `db:"created_at"        json:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at"        json:"updated_at"`
+	ExpiresAt       time.Time `db:"expires_at"        json:"expires_at"`
+}
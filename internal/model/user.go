@@ -3,10 +3,17 @@ package model
 import "time"
 
 type User struct {
-	ID             int       `db:"id"`
-	Email          string    `db:"email"`
-	HashedPassword string    `db:"hashed_password"`
-	Name           *string   `db:"name"`
-	CreatedAt      time.Time `db:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at"`
+	ID             int        `db:"id"`
+	Email          string     `db:"email"`
+	HashedPassword string     `db:"hashed_password"`
+	Name           *string    `db:"name"`
+	TOTPSecret     *string    `db:"totp_secret"`
+	TOTPEnabledAt  *time.Time `db:"totp_enabled_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+}
+
+// TwoFactorEnabled reports whether logins must be confirmed with a TOTP code.
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != nil
 }
@@ -0,0 +1,350 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	testClientID     = "medusa"
+	testClientSecret = "s3cret"
+	testRedirectURL  = "https://medusa.example/sso/callback"
+	testKeyID        = "key-1"
+)
+
+// mockProvider is an OpenID provider serving discovery, a JWKS and a token endpoint that
+// enforces PKCE. Each authorization code is bound to the challenge it was issued for and
+// redeemed for an ID token carrying the claims set for it.
+type mockProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]mockGrant
+	issuer string // served in discovery; the server URL unless overridden
+}
+
+type mockGrant struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockProvider{t: t, key: key, codes: map[string]mockGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/jwks", m.jwks)
+	mux.HandleFunc("/token", m.token)
+	m.server = httptest.NewServer(mux)
+	m.issuer = m.server.URL
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockProvider) provider() *Provider {
+	return NewProvider(Config{
+		Issuer:       m.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+	})
+}
+
+// grant issues code for the PKCE challenge, to be redeemed for an ID token with claims.
+func (m *mockProvider) grant(code, challenge string, claims jwt.MapClaims) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.codes[code] = mockGrant{challenge: challenge, claims: claims}
+}
+
+// claims returns valid ID token claims for the nonce, which tests then alter.
+func (m *mockProvider) claims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            m.server.URL,
+		"sub":            "user-123",
+		"aud":            testClientID,
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          nonce,
+		"email":          "ada@example.com",
+		"email_verified": true,
+		"name":           "Ada",
+	}
+}
+
+func (m *mockProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 m.issuer,
+		"authorization_endpoint": m.server.URL + "/authorize",
+		"token_endpoint":         m.server.URL + "/token",
+		"jwks_uri":               m.server.URL + "/jwks",
+	})
+}
+
+func (m *mockProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := m.key.PublicKey
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": testKeyID,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (m *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad form", http.StatusBadRequest)
+		return
+	}
+	if id, secret, ok := r.BasicAuth(); !ok || id != testClientID || secret != testClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+	if r.Form.Get("grant_type") != "authorization_code" || r.Form.Get("redirect_uri") != testRedirectURL {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	grant, ok := m.codes[r.Form.Get("code")]
+	delete(m.codes, r.Form.Get("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, grant.claims)
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(m.key)
+	if err != nil {
+		m.t.Errorf("signing id token: %v", err)
+		http.Error(w, "signing failed", http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+}
+
+// authorize runs the browser half of the flow: it builds the authorization URL and has the
+// provider issue a code for the challenge in it.
+func (m *mockProvider) authorize(t *testing.T, p *Provider, verifier, nonce string, claims jwt.MapClaims) string {
+	t.Helper()
+	raw, err := p.AuthCodeURL(context.Background(), "state-1", nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.grant("code-1", u.Query().Get("code_challenge"), claims)
+	return "code-1"
+}
+
+func TestAuthCodeURLUsesDiscoveryAndPKCE(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.provider()
+
+	raw, err := p.AuthCodeURL(context.Background(), "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	if !strings.HasPrefix(raw, m.server.URL+"/authorize?") {
+		t.Fatalf("authorization URL %q does not use the discovered endpoint", raw)
+	}
+	u, _ := url.Parse(raw)
+	q := u.Query()
+
+	sum := sha256.Sum256([]byte("verifier-1"))
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        base64.RawURLEncoding.EncodeToString(sum[:]),
+		"code_challenge_method": "S256",
+	}
+	for k, v := range want {
+		if got := q.Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	m := newMockProvider(t)
+	m.issuer = "https://evil.example"
+
+	if _, err := m.provider().AuthCodeURL(context.Background(), "s", "n", "v"); err == nil {
+		t.Fatal("expected discovery with another issuer to fail")
+	}
+}
+
+func TestExchange(t *testing.T) {
+	tests := []struct {
+		name    string
+		alter   func(c jwt.MapClaims)
+		nonce   string // sent to Exchange; the token carries "nonce-1"
+		wrongPK bool   // redeem the code with another verifier
+		wantErr bool
+	}{
+		{name: "valid token"},
+		{name: "nonce mismatch", nonce: "nonce-2", wantErr: true},
+		{name: "wrong code verifier", wrongPK: true, wantErr: true},
+		{
+			name:    "another issuer",
+			alter:   func(c jwt.MapClaims) { c["iss"] = "https://evil.example" },
+			wantErr: true,
+		},
+		{
+			name:    "another audience",
+			alter:   func(c jwt.MapClaims) { c["aud"] = "someone-else" },
+			wantErr: true,
+		},
+		{
+			name:    "expired",
+			alter:   func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+			wantErr: true,
+		},
+		{
+			name: "audience array with this client as azp",
+			alter: func(c jwt.MapClaims) {
+				c["aud"] = []string{testClientID, "api.example"}
+				c["azp"] = testClientID
+			},
+		},
+		{
+			name:  "single-entry audience array without azp",
+			alter: func(c jwt.MapClaims) { c["aud"] = []string{testClientID} },
+		},
+		{
+			name:    "audience array without azp",
+			alter:   func(c jwt.MapClaims) { c["aud"] = []string{testClientID, "api.example"} },
+			wantErr: true,
+		},
+		{
+			name: "audience array authorized for another party",
+			alter: func(c jwt.MapClaims) {
+				c["aud"] = []string{testClientID, "api.example"}
+				c["azp"] = "api.example"
+			},
+			wantErr: true,
+		},
+		{
+			name:    "azp of another client",
+			alter:   func(c jwt.MapClaims) { c["azp"] = "api.example" },
+			wantErr: true,
+		},
+		{
+			name:    "audience array without this client",
+			alter:   func(c jwt.MapClaims) { c["aud"] = []string{"api.example", "other"}; c["azp"] = testClientID },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockProvider(t)
+			p := m.provider()
+
+			claims := m.claims("nonce-1")
+			if tt.alter != nil {
+				tt.alter(claims)
+			}
+			code := m.authorize(t, p, "verifier-1", "nonce-1", claims)
+
+			verifier, nonce := "verifier-1", "nonce-1"
+			if tt.wrongPK {
+				verifier = "verifier-2"
+			}
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+
+			got, err := p.Exchange(context.Background(), code, verifier, nonce)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Exchange succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+			if got.Issuer != m.server.URL || got.Subject != "user-123" || got.Email != "ada@example.com" || got.Name != "Ada" {
+				t.Errorf("unexpected claims %+v", got)
+			}
+		})
+	}
+}
+
+func TestEmailVerifiedLinking(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    any
+		verified any // nil leaves the claim out
+		wantLink bool
+	}{
+		{name: "verified", email: "ada@example.com", verified: true, wantLink: true},
+		{name: "verified as a string", email: "ada@example.com", verified: "true", wantLink: true},
+		{name: "unverified", email: "ada@example.com", verified: false},
+		{name: "unverified as a string", email: "ada@example.com", verified: "false"},
+		{name: "no email_verified claim", email: "ada@example.com"},
+		{name: "verified without an email", verified: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockProvider(t)
+			p := m.provider()
+
+			claims := m.claims("nonce-1")
+			delete(claims, "email")
+			delete(claims, "email_verified")
+			if tt.email != nil {
+				claims["email"] = tt.email
+			}
+			if tt.verified != nil {
+				claims["email_verified"] = tt.verified
+			}
+			code := m.authorize(t, p, "verifier-1", "nonce-1", claims)
+
+			got, err := p.Exchange(context.Background(), code, "verifier-1", "nonce-1")
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+			if got.CanLinkByEmail() != tt.wantLink {
+				t.Errorf("CanLinkByEmail() = %v, want %v (claims %+v)", got.CanLinkByEmail(), tt.wantLink, got)
+			}
+		})
+	}
+}
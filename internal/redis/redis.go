@@ -43,3 +43,45 @@ func GetUnmarshalledJSON(ctx context.Context, key string, data any) {
 		return
 	}
 }
+
+// IncrWithin increments a counter and starts its expiry window on the first increment,
+// so the counter resets once window has passed since it was created.
+func IncrWithin(ctx context.Context, key string, window time.Duration) (int64, error) {
+	pipe := Rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Could not increment counter in Redis")
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// Exceeded reports whether the counter at key, counted with IncrWithin, has reached limit,
+// and how long until its window resets. Like Allow, it fails open when Redis is down.
+func Exceeded(ctx context.Context, key string, limit int, window time.Duration) (exceeded bool, retryAfter time.Duration) {
+	n, err := Rdb.Get(ctx, key).Int()
+	if err != nil || n < limit {
+		return false, 0
+	}
+	ttl, err := Rdb.TTL(ctx, key).Result()
+	if err != nil || ttl <= 0 {
+		ttl = window
+	}
+	return true, ttl
+}
+
+// Allow counts one attempt against key and reports whether it is within limit for the
+// current window; when it is not, retryAfter says how long until the window resets.
+// Redis errors allow the attempt so an outage never locks clients out.
+func Allow(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, retryAfter time.Duration) {
+	n, err := IncrWithin(ctx, key, window)
+	if err != nil || n <= int64(limit) {
+		return true, 0
+	}
+	ttl, err := Rdb.TTL(ctx, key).Result()
+	if err != nil || ttl <= 0 {
+		ttl = window
+	}
+	return false, ttl
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+var extensionPattern = regexp.MustCompile(`^\.[a-z0-9]{1,10}$`)
+
+// contentKey is where an organization's file with the given hash is stored, relative to the
+// storage root. Identical uploads map to the same key, which is what deduplicates them.
+func contentKey(organizationID int, sum, filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !extensionPattern.MatchString(ext) {
+		ext = ""
+	}
+	return fmt.Sprintf("content/%d/%s%s", organizationID, sum, ext)
+}
+
+// sniffWriter keeps the first 512 bytes written to it for http.DetectContentType.
+type sniffWriter struct {
+	head []byte
+}
+
+func (w *sniffWriter) Write(p []byte) (int, error) {
+	if room := 512 - len(w.head); room > 0 {
+		w.head = append(w.head, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// copyHashed copies src to dst, hashing and sniffing the bytes on the way through.
+func copyHashed(dst io.Writer, src io.Reader, declaredType string) (model.ContentAsset, error) {
+	hash := sha256.New()
+	sniff := &sniffWriter{}
+	n, err := io.Copy(io.MultiWriter(dst, hash, sniff), src)
+	if err != nil {
+		return model.ContentAsset{}, err
+	}
+
+	mimeType := http.DetectContentType(sniff.head)
+	if mimeType == "application/octet-stream" && declaredType != "" {
+		mimeType = declaredType
+	}
+	return model.ContentAsset{
+		SHA256:    hex.EncodeToString(hash.Sum(nil)),
+		SizeBytes: n,
+		MimeType:  mimeType,
+	}, nil
+}
+
+func (ls *LocalStorage) SaveContent(fileHeader *multipart.FileHeader, organizationID int) (string, model.ContentAsset, error) {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return "", model.ContentAsset{}, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(ls.partialDir(), 0755); err != nil {
+		return "", model.ContentAsset{}, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(ls.partialDir(), ".upload-*")
+	if err != nil {
+		return "", model.ContentAsset{}, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	asset, err := copyHashed(tmp, src, fileHeader.Header.Get("Content-Type"))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", model.ContentAsset{}, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	uploadPath, err := ls.place(tmp.Name(), organizationID, fileHeader.Filename, asset)
+	if err != nil {
+		return "", model.ContentAsset{}, err
+	}
+	return uploadPath, asset, nil
+}
+
+// place moves a fully written temp file to its content-addressed path, unless the
+// organization already has the file there.
+func (ls *LocalStorage) place(tmpPath string, organizationID int, filename string, asset model.ContentAsset) (string, error) {
+	uploadPath := filepath.Join(ls.uploadDir, contentKey(organizationID, asset.SHA256, filename))
+	if _, err := os.Stat(uploadPath); err == nil {
+		log.Debug().Str("path", uploadPath).Msg("Upload already stored")
+		_ = os.Remove(tmpPath)
+		return uploadPath, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(uploadPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+	if err := os.Rename(tmpPath, uploadPath); err != nil {
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+	return uploadPath, nil
+}
+
+func (ss *SpacesStorage) SaveContent(fileHeader *multipart.FileHeader, organizationID int) (string, model.ContentAsset, error) {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return "", model.ContentAsset{}, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer src.Close()
+
+	// the key depends on the hash, so hash the spooled upload before sending it
+	asset, err := copyHashed(io.Discard, src, fileHeader.Header.Get("Content-Type"))
+	if err != nil {
+		return "", model.ContentAsset{}, fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	key := "uploads/" + contentKey(organizationID, asset.SHA256, fileHeader.Filename)
+	cdnURL := fmt.Sprintf("%s/%s", strings.TrimSuffix(ss.cdnURL, "/"), key)
+
+	exists, err := ss.objectExists(key)
+	if err != nil {
+		return "", model.ContentAsset{}, err
+	}
+	if exists {
+		log.Debug().Str("key", key).Msg("Upload already stored")
+		return cdnURL, asset, nil
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return "", model.ContentAsset{}, fmt.Errorf("failed to rewind uploaded file: %w", err)
+	}
+	_, err = ss.client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(ss.bucket),
+		Key:         aws.String(key),
+		Body:        src,
+		ContentType: aws.String(asset.MimeType),
+		ACL:         aws.String("public-read"),
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to upload file to Spaces")
+		return "", model.ContentAsset{}, fmt.Errorf("failed to upload to Spaces: %w", err)
+	}
+	return cdnURL, asset, nil
+}
+
+// objectExists reports whether the bucket already holds the key.
+func (ss *SpacesStorage) objectExists(key string) (bool, error) {
+	_, err := ss.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(ss.bucket),
+		Key:    aws.String(key),
+	})
+	if err == nil {
+		return true, nil
+	}
+	var aerr awserr.RequestFailure
+	if errors.As(err, &aerr) && aerr.StatusCode() == http.StatusNotFound {
+		return false, nil
+	}
+	log.Error().Err(err).Str("key", key).Msg("Failed to look up file in Spaces")
+	return false, fmt.Errorf("failed to look up file in Spaces: %w", err)
+}
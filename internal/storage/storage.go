@@ -14,13 +14,42 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
 )
 
 type Storage interface {
 	SaveFile(fileHeader *multipart.FileHeader, filename string) (string, error)
+	// SavePrivateFile stores a file that, unlike SaveFile's, is never publicly served; it can
+	// only be read back through OpenFile.
+	SavePrivateFile(fileHeader *multipart.FileHeader, filename string) (string, error)
+	// SaveContent stores an organization's content upload under its SHA-256, hashing it while
+	// it is written. Uploading a file the organization already has stores nothing new and
+	// returns the existing location.
+	SaveContent(fileHeader *multipart.FileHeader, organizationID int) (string, model.ContentAsset, error)
+
+	// BeginUpload, WriteUploadPart, CompleteUpload and AbortUpload assemble a content upload
+	// from chunks sent in order over several requests. Parts are numbered from 1; rewriting a
+	// part replaces it. CompleteUpload returns the file's location like SaveContent.
+	BeginUpload(organizationID int, uploadID, filename, contentType string) (PendingUpload, error)
+	WriteUploadPart(upload PendingUpload, part int, offset int64, chunk io.ReadSeeker) error
+	CompleteUpload(upload PendingUpload, organizationID int, filename string, asset model.ContentAsset) (string, error)
+	AbortUpload(upload PendingUpload) error
+	// OpenFile reads a file by the location SaveFile or SavePrivateFile returned for it.
+	OpenFile(location string) (io.ReadCloser, error)
+	// DeleteFile removes a file by the location SaveFile or SavePrivateFile returned for
+	// it. Deleting a file that no longer exists is not an error.
+	DeleteFile(location string) error
+	// FileExists reports whether a file is stored at the location SaveFile returned for it.
+	FileExists(location string) (bool, error)
 }
 
+// privateKeyPrefix is where SpacesStorage keeps private files. Their locations are bare
+// object keys rather than CDN URLs, since the CDN cannot serve them.
+const privateKeyPrefix = "private/"
+
 type LocalStorage struct {
 	uploadDir string
 }
@@ -76,20 +105,45 @@ func normalizeFilename(originalFilename string) string {
 		baseName = "file"
 	}
 
-	// Add timestamp to make it unique and traceable
+	// Add timestamp to make it traceable, and a random suffix so uploads in the same second don't collide
 	timestamp := time.Now().Format("20060102_150405")
+	suffix := uuid.NewString()[:8]
+
+	// Construct final filename: basename_timestamp_suffix.ext
+	return fmt.Sprintf("%s_%s_%s%s", baseName, timestamp, suffix, ext)
+}
+
+// privateDir holds files that must not be served. Like partialDir, it sits beside the
+// upload directory rather than in it.
+func (ls *LocalStorage) privateDir() string {
+	return filepath.Clean(ls.uploadDir) + ".private"
+}
 
-	// Construct final filename: basename_timestamp.ext
-	return fmt.Sprintf("%s_%s%s", baseName, timestamp, ext)
+// contains reports whether path is a file in the upload or private directory.
+func (ls *LocalStorage) contains(path string) bool {
+	for _, dir := range []string{filepath.Clean(ls.uploadDir), ls.privateDir()} {
+		if strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
 }
 
 func (ls *LocalStorage) SaveFile(fileHeader *multipart.FileHeader, filename string) (string, error) {
+	return ls.saveFileIn(ls.uploadDir, fileHeader, filename)
+}
+
+func (ls *LocalStorage) SavePrivateFile(fileHeader *multipart.FileHeader, filename string) (string, error) {
+	return ls.saveFileIn(ls.privateDir(), fileHeader, filename)
+}
+
+func (ls *LocalStorage) saveFileIn(dir string, fileHeader *multipart.FileHeader, filename string) (string, error) {
 	normalizedFilename := normalizeFilename(filename)
 	log.Debug().Str("original", filename).Str("normalized", normalizedFilename).Msg("File upload normalized")
-	uploadPath := filepath.Join(ls.uploadDir, normalizedFilename)
+	uploadPath := filepath.Join(dir, normalizedFilename)
 
 	// Ensure upload directory exists
-	if err := os.MkdirAll(ls.uploadDir, 0755); err != nil {
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create upload directory: %w", err)
 	}
 
@@ -123,6 +177,22 @@ func (ls *LocalStorage) SaveFile(fileHeader *multipart.FileHeader, filename stri
 }
 
 func (ss *SpacesStorage) SaveFile(fileHeader *multipart.FileHeader, filename string) (string, error) {
+	key, err := ss.putFile(fileHeader, filename, "uploads/", "public-read")
+	if err != nil {
+		return "", err
+	}
+
+	// Return the CDN URL
+	cdnURL := fmt.Sprintf("%s/%s", strings.TrimSuffix(ss.cdnURL, "/"), key)
+	return cdnURL, nil
+}
+
+func (ss *SpacesStorage) SavePrivateFile(fileHeader *multipart.FileHeader, filename string) (string, error) {
+	return ss.putFile(fileHeader, filename, privateKeyPrefix, "private")
+}
+
+// putFile uploads a file under prefix with the given canned ACL and returns its key.
+func (ss *SpacesStorage) putFile(fileHeader *multipart.FileHeader, filename, prefix, acl string) (string, error) {
 	normalizedFilename := normalizeFilename(filename)
 	log.Debug().Str("original", filename).Str("normalized", normalizedFilename).Msg("File upload normalized")
 
@@ -137,7 +207,7 @@ func (ss *SpacesStorage) SaveFile(fileHeader *multipart.FileHeader, filename str
 		}
 	}(src)
 
-	key := fmt.Sprintf("uploads/%s", normalizedFilename)
+	key := prefix + normalizedFilename
 
 	// Determine content type based on file extension
 	contentType := getContentType(normalizedFilename)
@@ -147,17 +217,103 @@ func (ss *SpacesStorage) SaveFile(fileHeader *multipart.FileHeader, filename str
 		Key:         aws.String(key),
 		Body:        src,
 		ContentType: aws.String(contentType),
-		ACL:         aws.String("public-read"),
+		ACL:         aws.String(acl),
 	})
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to upload file to Spaces")
 		return "", fmt.Errorf("failed to upload to Spaces: %w", err)
 	}
+	return key, nil
+}
+
+// objectKey is the key of the object stored at a location SaveFile or SavePrivateFile returned.
+func (ss *SpacesStorage) objectKey(location string) (string, error) {
+	if strings.HasPrefix(location, privateKeyPrefix) {
+		return location, nil
+	}
+	prefix := strings.TrimSuffix(ss.cdnURL, "/") + "/"
+	key, ok := strings.CutPrefix(location, prefix)
+	if !ok {
+		return "", fmt.Errorf("%s is not in the Spaces bucket", location)
+	}
+	return key, nil
+}
 
-	// Return the CDN URL
-	cdnURL := fmt.Sprintf("%s/%s", strings.TrimSuffix(ss.cdnURL, "/"), key)
-	return cdnURL, nil
+func (ls *LocalStorage) OpenFile(location string) (io.ReadCloser, error) {
+	path := filepath.Clean(location)
+	if !ls.contains(path) {
+		return nil, fmt.Errorf("%s is not in the upload directory", location)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return f, nil
+}
+
+func (ss *SpacesStorage) OpenFile(location string) (io.ReadCloser, error) {
+	key, err := ss.objectKey(location)
+	if err != nil {
+		return nil, err
+	}
+	out, err := ss.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(ss.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to read file from Spaces")
+		return nil, fmt.Errorf("failed to read from Spaces: %w", err)
+	}
+	return out.Body, nil
+}
+
+func (ls *LocalStorage) DeleteFile(location string) error {
+	path := filepath.Clean(location)
+	if !ls.contains(path) {
+		return fmt.Errorf("%s is not in the upload directory", location)
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+func (ss *SpacesStorage) DeleteFile(location string) error {
+	key, err := ss.objectKey(location)
+	if err != nil {
+		return err
+	}
+
+	_, err = ss.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(ss.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to delete file from Spaces")
+		return fmt.Errorf("failed to delete from Spaces: %w", err)
+	}
+	return nil
+}
+
+func (ls *LocalStorage) FileExists(location string) (bool, error) {
+	_, err := os.Stat(location)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up file: %w", err)
+	}
+	return true, nil
+}
+
+func (ss *SpacesStorage) FileExists(location string) (bool, error) {
+	key, err := ss.objectKey(location)
+	if err != nil {
+		return false, err
+	}
+	return ss.objectExists(key)
 }
 
 func getContentType(filename string) string {
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Nixie-Tech-LLC/medusa/internal/model"
+)
+
+// MinUploadPart is the smallest chunk an upload accepts other than its last, the minimum
+// part size of an S3 multipart upload.
+const MinUploadPart = 5 << 20
+
+// PendingUpload locates a chunked upload in progress: a temp file for LocalStorage, an
+// object key and multipart upload ID for SpacesStorage.
+type PendingUpload struct {
+	Key      string
+	UploadID string
+}
+
+// partialDir holds files still being written. It sits beside the upload directory rather
+// than in it, since the upload directory is served without authentication.
+func (ls *LocalStorage) partialDir() string {
+	return filepath.Clean(ls.uploadDir) + ".partial"
+}
+
+func (ls *LocalStorage) partialPath(uploadID string) string {
+	return filepath.Join(ls.partialDir(), uploadID)
+}
+
+func (ls *LocalStorage) BeginUpload(organizationID int, uploadID, filename, contentType string) (PendingUpload, error) {
+	path := ls.partialPath(uploadID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return PendingUpload{}, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return PendingUpload{}, fmt.Errorf("failed to create partial file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return PendingUpload{}, fmt.Errorf("failed to create partial file: %w", err)
+	}
+	return PendingUpload{Key: path}, nil
+}
+
+func (ls *LocalStorage) WriteUploadPart(upload PendingUpload, part int, offset int64, chunk io.ReadSeeker) error {
+	f, err := os.OpenFile(upload.Key, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open partial file: %w", err)
+	}
+	defer f.Close()
+
+	// drop whatever a failed attempt at this chunk left behind
+	if err := f.Truncate(offset); err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+	if _, err := io.Copy(f, chunk); err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+	return f.Close()
+}
+
+func (ls *LocalStorage) CompleteUpload(upload PendingUpload, organizationID int, filename string, asset model.ContentAsset) (string, error) {
+	return ls.place(upload.Key, organizationID, filename, asset)
+}
+
+func (ls *LocalStorage) AbortUpload(upload PendingUpload) error {
+	if err := os.Remove(upload.Key); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete partial file: %w", err)
+	}
+	return nil
+}
+
+func (ss *SpacesStorage) BeginUpload(organizationID int, uploadID, filename, contentType string) (PendingUpload, error) {
+	// the hash is only known once every chunk is in, so the object is keyed by the upload
+	key := "uploads/" + contentKey(organizationID, "upload-"+uploadID, filename)
+	if contentType == "" {
+		contentType = getContentType(filename)
+	}
+
+	out, err := ss.client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(ss.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		ACL:         aws.String("public-read"),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to start multipart upload to Spaces")
+		return PendingUpload{}, fmt.Errorf("failed to start upload to Spaces: %w", err)
+	}
+	return PendingUpload{Key: key, UploadID: aws.StringValue(out.UploadId)}, nil
+}
+
+func (ss *SpacesStorage) WriteUploadPart(upload PendingUpload, part int, offset int64, chunk io.ReadSeeker) error {
+	// a retried part number replaces the part sent before
+	_, err := ss.client.UploadPart(&s3.UploadPartInput{
+		Bucket:     aws.String(ss.bucket),
+		Key:        aws.String(upload.Key),
+		UploadId:   aws.String(upload.UploadID),
+		PartNumber: aws.Int64(int64(part)),
+		Body:       chunk,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("key", upload.Key).Int("part", part).Msg("Failed to upload part to Spaces")
+		return fmt.Errorf("failed to upload part to Spaces: %w", err)
+	}
+	return nil
+}
+
+func (ss *SpacesStorage) CompleteUpload(upload PendingUpload, organizationID int, filename string, asset model.ContentAsset) (string, error) {
+	var parts []*s3.CompletedPart
+	err := ss.client.ListPartsPages(&s3.ListPartsInput{
+		Bucket:   aws.String(ss.bucket),
+		Key:      aws.String(upload.Key),
+		UploadId: aws.String(upload.UploadID),
+	}, func(page *s3.ListPartsOutput, _ bool) bool {
+		for _, p := range page.Parts {
+			parts = append(parts, &s3.CompletedPart{ETag: p.ETag, PartNumber: p.PartNumber})
+		}
+		return true
+	})
+	if err != nil {
+		log.Error().Err(err).Str("key", upload.Key).Msg("Failed to list uploaded parts in Spaces")
+		return "", fmt.Errorf("failed to list uploaded parts: %w", err)
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return aws.Int64Value(parts[i].PartNumber) < aws.Int64Value(parts[j].PartNumber)
+	})
+
+	_, err = ss.client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(ss.bucket),
+		Key:             aws.String(upload.Key),
+		UploadId:        aws.String(upload.UploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		log.Error().Err(err).Str("key", upload.Key).Msg("Failed to complete multipart upload to Spaces")
+		return "", fmt.Errorf("failed to complete upload to Spaces: %w", err)
+	}
+
+	// reuse the organization's copy if a single-request upload already stored this file;
+	// otherwise leave the object where it is rather than copying gigabytes to a new key
+	key := "uploads/" + contentKey(organizationID, asset.SHA256, filename)
+	exists, err := ss.objectExists(key)
+	if err != nil || !exists {
+		key = upload.Key
+	} else {
+		_, _ = ss.client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String(ss.bucket), Key: aws.String(upload.Key)})
+	}
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(ss.cdnURL, "/"), key), nil
+}
+
+func (ss *SpacesStorage) AbortUpload(upload PendingUpload) error {
+	_, err := ss.client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(ss.bucket),
+		Key:      aws.String(upload.Key),
+		UploadId: aws.String(upload.UploadID),
+	})
+	var aerr awserr.Error
+	if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchUpload {
+		return nil
+	}
+	if err != nil {
+		log.Error().Err(err).Str("key", upload.Key).Msg("Failed to abort multipart upload to Spaces")
+		return fmt.Errorf("failed to abort upload to Spaces: %w", err)
+	}
+	return nil
+}
+
+// PendingUploadFor locates the partial file of a recorded upload.
+func PendingUploadFor(u model.ContentUpload) PendingUpload {
+	return PendingUpload{Key: u.StorageKey, UploadID: aws.StringValue(u.StorageUploadID)}
+}
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS uq_group_name_per_org;
+ALTER TABLE screen_groups
+    ADD CONSTRAINT uq_group_name_per_user UNIQUE (created_by, name);
+
+ALTER TABLE screen_groups DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE schedules     DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE playlists     DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE content       DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE screens       DROP COLUMN IF EXISTS organization_id;
+
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
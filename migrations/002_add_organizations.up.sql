@@ -0,0 +1,74 @@
+-- @ORGANIZATIONS
+CREATE TABLE IF NOT EXISTS organizations (
+  id          BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  name        TEXT NOT NULL,
+  created_by  BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+DROP TRIGGER IF EXISTS trg_organizations_updated_at ON organizations;
+CREATE TRIGGER trg_organizations_updated_at
+BEFORE UPDATE ON organizations
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- many-to-many: users <-> organizations
+CREATE TABLE IF NOT EXISTS organization_members (
+  organization_id BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  user_id         BIGINT NOT NULL REFERENCES users(id)         ON DELETE CASCADE,
+  joined_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (organization_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_organization_members_user ON organization_members(user_id);
+
+-- backfill: every existing user without an organization gets a personal one
+INSERT INTO organizations (name, created_by)
+SELECT COALESCE(NULLIF(u.name, ''), u.email) || '''s workspace', u.id
+  FROM users u
+ WHERE NOT EXISTS (SELECT 1 FROM organization_members m WHERE m.user_id = u.id)
+   AND NOT EXISTS (SELECT 1 FROM organizations o WHERE o.created_by = u.id);
+
+INSERT INTO organization_members (organization_id, user_id)
+SELECT o.id, o.created_by
+  FROM organizations o
+ WHERE NOT EXISTS (SELECT 1 FROM organization_members m WHERE m.user_id = o.created_by)
+ON CONFLICT DO NOTHING;
+
+-- owned resources move from created_by to organization_id
+ALTER TABLE screens       ADD COLUMN IF NOT EXISTS organization_id BIGINT REFERENCES organizations(id) ON DELETE RESTRICT;
+ALTER TABLE content       ADD COLUMN IF NOT EXISTS organization_id BIGINT REFERENCES organizations(id) ON DELETE RESTRICT;
+ALTER TABLE playlists     ADD COLUMN IF NOT EXISTS organization_id BIGINT REFERENCES organizations(id) ON DELETE RESTRICT;
+ALTER TABLE schedules     ADD COLUMN IF NOT EXISTS organization_id BIGINT REFERENCES organizations(id) ON DELETE RESTRICT;
+ALTER TABLE screen_groups ADD COLUMN IF NOT EXISTS organization_id BIGINT REFERENCES organizations(id) ON DELETE RESTRICT;
+
+UPDATE screens t SET organization_id = (
+  SELECT m.organization_id FROM organization_members m WHERE m.user_id = t.created_by ORDER BY m.joined_at, m.organization_id LIMIT 1
+) WHERE t.organization_id IS NULL;
+UPDATE content t SET organization_id = (
+  SELECT m.organization_id FROM organization_members m WHERE m.user_id = t.created_by ORDER BY m.joined_at, m.organization_id LIMIT 1
+) WHERE t.organization_id IS NULL;
+UPDATE playlists t SET organization_id = (
+  SELECT m.organization_id FROM organization_members m WHERE m.user_id = t.created_by ORDER BY m.joined_at, m.organization_id LIMIT 1
+) WHERE t.organization_id IS NULL;
+UPDATE schedules t SET organization_id = (
+  SELECT m.organization_id FROM organization_members m WHERE m.user_id = t.created_by ORDER BY m.joined_at, m.organization_id LIMIT 1
+) WHERE t.organization_id IS NULL;
+UPDATE screen_groups t SET organization_id = (
+  SELECT m.organization_id FROM organization_members m WHERE m.user_id = t.created_by ORDER BY m.joined_at, m.organization_id LIMIT 1
+) WHERE t.organization_id IS NULL;
+
+ALTER TABLE screens       ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE content       ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE playlists     ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE schedules     ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE screen_groups ALTER COLUMN organization_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_screens_organization       ON screens(organization_id);
+CREATE INDEX IF NOT EXISTS idx_content_organization       ON content(organization_id);
+CREATE INDEX IF NOT EXISTS idx_playlists_organization     ON playlists(organization_id);
+CREATE INDEX IF NOT EXISTS idx_schedules_organization     ON schedules(organization_id);
+CREATE INDEX IF NOT EXISTS idx_screen_groups_organization ON screen_groups(organization_id);
+
+-- group names are unique per organization instead of per user
+ALTER TABLE screen_groups DROP CONSTRAINT IF EXISTS uq_group_name_per_user;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_group_name_per_org ON screen_groups(organization_id, name);
@@ -0,0 +1,4 @@
+ALTER TABLE organization_members DROP COLUMN IF EXISTS role;
+
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,69 @@
+-- @ROLES
+CREATE TABLE IF NOT EXISTS roles (
+  name        TEXT PRIMARY KEY,
+  description TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+  role       TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+  permission TEXT NOT NULL,
+  PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name, description) VALUES
+  ('owner',     'Full control, including organization membership'),
+  ('editor',    'Manages screens, content, playlists and schedules'),
+  ('publisher', 'Publishes existing playlists to screens and schedules'),
+  ('viewer',    'Read-only access')
+ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description;
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('viewer',    'screens:read'),
+  ('viewer',    'content:read'),
+  ('viewer',    'playlists:read'),
+  ('viewer',    'schedules:read'),
+
+  ('publisher', 'screens:read'),
+  ('publisher', 'content:read'),
+  ('publisher', 'playlists:read'),
+  ('publisher', 'schedules:read'),
+  ('publisher', 'screens:publish'),
+  ('publisher', 'schedules:write'),
+
+  ('editor',    'screens:read'),
+  ('editor',    'content:read'),
+  ('editor',    'playlists:read'),
+  ('editor',    'schedules:read'),
+  ('editor',    'screens:publish'),
+  ('editor',    'schedules:write'),
+  ('editor',    'screens:write'),
+  ('editor',    'content:write'),
+  ('editor',    'playlists:write'),
+
+  ('owner',     'screens:read'),
+  ('owner',     'content:read'),
+  ('owner',     'playlists:read'),
+  ('owner',     'schedules:read'),
+  ('owner',     'screens:publish'),
+  ('owner',     'schedules:write'),
+  ('owner',     'screens:write'),
+  ('owner',     'content:write'),
+  ('owner',     'playlists:write'),
+  ('owner',     'members:manage')
+ON CONFLICT DO NOTHING;
+
+-- members that existed before roles keep full control; new members default to read-only
+ALTER TABLE organization_members
+  ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'owner' REFERENCES roles(name) ON DELETE RESTRICT;
+ALTER TABLE organization_members ALTER COLUMN role SET DEFAULT 'viewer';
+
+-- an organization without an owner hands ownership back to its creator
+UPDATE organization_members m
+   SET role = 'owner'
+  FROM organizations o
+ WHERE o.id = m.organization_id
+   AND o.created_by = m.user_id
+   AND NOT EXISTS (
+     SELECT 1 FROM organization_members x
+      WHERE x.organization_id = m.organization_id AND x.role = 'owner'
+   );
@@ -0,0 +1,6 @@
+UPDATE organization_members SET role = 'viewer' WHERE role = 'operator';
+DELETE FROM roles WHERE name = 'operator';
+
+ALTER TABLE screen_assignments
+  DROP COLUMN IF EXISTS assigned_at,
+  DROP COLUMN IF EXISTS assigned_by;
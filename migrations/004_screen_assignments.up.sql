@@ -0,0 +1,14 @@
+-- @SCREEN ASSIGNMENTS
+ALTER TABLE screen_assignments
+  ADD COLUMN IF NOT EXISTS assigned_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- operators see nothing org-wide; screens reach them only through screen_assignments
+INSERT INTO roles (name, description) VALUES
+  ('operator', 'Operates only the screens assigned to them')
+ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description;
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('operator', 'content:read'),
+  ('operator', 'playlists:read')
+ON CONFLICT DO NOTHING;
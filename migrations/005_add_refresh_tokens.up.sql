@@ -0,0 +1,16 @@
+-- @REFRESH TOKENS
+-- every login starts a family; each refresh rotates to a new row in the same family,
+-- and presenting an already-rotated token revokes the whole family
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+  id          BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  family_id   UUID   NOT NULL,
+  token_hash  TEXT   NOT NULL UNIQUE,
+  user_agent  TEXT,
+  ip          TEXT,
+  expires_at  TIMESTAMPTZ NOT NULL,
+  revoked_at  TIMESTAMPTZ,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user   ON refresh_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family_id);
@@ -0,0 +1,21 @@
+-- @API KEYS
+-- keys act for their creator within one organization, limited to their scopes
+CREATE TABLE IF NOT EXISTS api_keys (
+  id               BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  organization_id  BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  created_by       BIGINT NOT NULL REFERENCES users(id)         ON DELETE CASCADE,
+  name             TEXT   NOT NULL,
+  prefix           TEXT   NOT NULL,
+  key_hash         TEXT   NOT NULL UNIQUE,
+  expires_at       TIMESTAMPTZ,
+  last_used_at     TIMESTAMPTZ,
+  revoked_at       TIMESTAMPTZ,
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_org ON api_keys(organization_id);
+
+CREATE TABLE IF NOT EXISTS api_key_scopes (
+  api_key_id  BIGINT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+  permission  TEXT   NOT NULL,
+  PRIMARY KEY (api_key_id, permission)
+);
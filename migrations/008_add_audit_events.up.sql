@@ -0,0 +1,19 @@
+-- @AUDIT EVENTS
+-- append-only record of who did what; organization and actor are optional so that
+-- pre-authentication events (e.g. lockouts) can be recorded too
+CREATE TABLE IF NOT EXISTS audit_events (
+  id                BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  organization_id   BIGINT REFERENCES organizations(id) ON DELETE CASCADE,
+  actor_user_id     BIGINT REFERENCES users(id)         ON DELETE SET NULL,
+  actor_api_key_id  BIGINT REFERENCES api_keys(id)      ON DELETE SET NULL,
+  action            TEXT   NOT NULL,
+  entity_type       TEXT   NOT NULL,
+  entity_id         TEXT,
+  before            JSONB,
+  after             JSONB,
+  metadata          JSONB,
+  ip                TEXT,
+  created_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_org_created ON audit_events(organization_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity      ON audit_events(entity_type, entity_id);
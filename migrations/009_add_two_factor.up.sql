@@ -0,0 +1,18 @@
+-- @TWO FACTOR
+-- totp_secret is set at enrollment and only trusted once totp_enabled_at is set
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS totp_secret      TEXT,
+  ADD COLUMN IF NOT EXISTS totp_enabled_at  TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS totp_last_step   BIGINT;
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+  id          BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  code_hash   TEXT   NOT NULL,
+  used_at     TIMESTAMPTZ,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (user_id, code_hash)
+);
+
+ALTER TABLE organizations
+  ADD COLUMN IF NOT EXISTS require_two_factor BOOLEAN NOT NULL DEFAULT false;
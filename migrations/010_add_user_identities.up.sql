@@ -0,0 +1,13 @@
+-- @USER IDENTITIES
+-- external (OIDC) accounts linked to a medusa user, keyed by the provider's issuer and subject
+CREATE TABLE IF NOT EXISTS user_identities (
+  id             BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  user_id        BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  issuer         TEXT   NOT NULL,
+  subject        TEXT   NOT NULL,
+  email          TEXT,
+  created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_login_at  TIMESTAMPTZ,
+  UNIQUE (issuer, subject)
+);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_audit_events_org_action;
+DELETE FROM role_permissions WHERE permission = 'audit:read';
@@ -0,0 +1,7 @@
+-- @AUDIT PERMISSION
+-- reading the organization's audit log is reserved to owners
+INSERT INTO role_permissions (role, permission) VALUES
+  ('owner', 'audit:read')
+ON CONFLICT DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_org_action ON audit_events(organization_id, action);
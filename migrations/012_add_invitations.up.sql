@@ -0,0 +1,17 @@
+-- @INVITATIONS
+-- an invitation lets someone join an organization with a preset role; only the hash
+-- of the emailed token is stored
+CREATE TABLE IF NOT EXISTS invitations (
+  id               BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  organization_id  BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  email            TEXT   NOT NULL,
+  role             TEXT   NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+  token_hash       TEXT   NOT NULL UNIQUE,
+  invited_by       BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  expires_at       TIMESTAMPTZ NOT NULL,
+  accepted_at      TIMESTAMPTZ,
+  accepted_by      BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  revoked_at       TIMESTAMPTZ,
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_invitations_org ON invitations(organization_id);
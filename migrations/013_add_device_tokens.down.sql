@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_screens_device_token_hash;
+ALTER TABLE screens DROP COLUMN IF EXISTS device_token_issued_at;
+ALTER TABLE screens DROP COLUMN IF EXISTS device_token_hash;
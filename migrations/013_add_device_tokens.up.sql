@@ -0,0 +1,7 @@
+-- @DEVICE TOKENS
+-- each paired screen holds the hash of a secret issued to its device; TV endpoints
+-- authenticate with the secret instead of the bare device_id. Screens paired before
+-- this have no token and must have one issued from the admin API.
+ALTER TABLE screens ADD COLUMN IF NOT EXISTS device_token_hash TEXT;
+ALTER TABLE screens ADD COLUMN IF NOT EXISTS device_token_issued_at TIMESTAMPTZ;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_screens_device_token_hash ON screens(device_token_hash);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS screen_devices;
@@ -0,0 +1,21 @@
+-- @SCREEN DEVICES
+-- history of the physical devices bound to each screen; the open row (unbound_at IS NULL)
+-- is the device currently paired
+CREATE TABLE IF NOT EXISTS screen_devices (
+  id           BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  screen_id    BIGINT NOT NULL REFERENCES screens(id) ON DELETE CASCADE,
+  device_id    TEXT   NOT NULL,
+  bound_by     BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  bound_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+  unbound_by   BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  unbound_at   TIMESTAMPTZ,
+  unbind_reason TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_screen_devices_screen ON screen_devices(screen_id, bound_at DESC);
+
+-- screens paired before history was kept start with their current device
+INSERT INTO screen_devices (screen_id, device_id, bound_at)
+SELECT s.id, s.device_id, s.updated_at
+  FROM screens s
+ WHERE s.paired
+   AND NOT EXISTS (SELECT 1 FROM screen_devices d WHERE d.screen_id = s.id);
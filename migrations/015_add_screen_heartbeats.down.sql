@@ -0,0 +1,5 @@
+ALTER TABLE screens
+  DROP COLUMN IF EXISTS app_version,
+  DROP COLUMN IF EXISTS playlist_etag,
+  DROP COLUMN IF EXISTS uptime_seconds,
+  DROP COLUMN IF EXISTS last_seen_at;
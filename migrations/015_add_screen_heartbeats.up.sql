@@ -0,0 +1,7 @@
+-- @SCREEN HEARTBEATS
+-- latest heartbeat reported by the screen's device; online status is derived from last_seen_at
+ALTER TABLE screens
+  ADD COLUMN IF NOT EXISTS last_seen_at   TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS uptime_seconds BIGINT,
+  ADD COLUMN IF NOT EXISTS playlist_etag  TEXT,
+  ADD COLUMN IF NOT EXISTS app_version    TEXT;
@@ -0,0 +1,30 @@
+-- @ALERTING
+-- an alert rule watches one screen or every screen in a group and notifies a channel when
+-- a screen has made no contact for offline_after_seconds
+CREATE TABLE IF NOT EXISTS alert_rules (
+  id                    BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  organization_id       BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  name                  TEXT   NOT NULL,
+  screen_id             BIGINT REFERENCES screens(id) ON DELETE CASCADE,
+  group_id              BIGINT REFERENCES screen_groups(id) ON DELETE CASCADE,
+  offline_after_seconds INT    NOT NULL DEFAULT 600 CHECK (offline_after_seconds > 0),
+  channel               TEXT   NOT NULL CHECK (channel IN ('webhook', 'slack', 'email')),
+  target                TEXT   NOT NULL,
+  enabled               BOOLEAN NOT NULL DEFAULT TRUE,
+  created_by            BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  created_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CHECK ((screen_id IS NULL) <> (group_id IS NULL))
+);
+CREATE INDEX IF NOT EXISTS idx_alert_rules_org ON alert_rules(organization_id);
+
+-- one row per rule and screen once the rule has fired for it; notifications are only sent
+-- when the state changes
+CREATE TABLE IF NOT EXISTS alert_states (
+  rule_id     BIGINT NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+  screen_id   BIGINT NOT NULL REFERENCES screens(id) ON DELETE CASCADE,
+  state       TEXT   NOT NULL CHECK (state IN ('firing', 'resolved')),
+  fired_at    TIMESTAMPTZ NOT NULL,
+  resolved_at TIMESTAMPTZ,
+  PRIMARY KEY (rule_id, screen_id)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS play_events;
+DROP FUNCTION IF EXISTS ensure_monthly_partitions(TEXT);
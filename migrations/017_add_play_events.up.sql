@@ -0,0 +1,45 @@
+-- @PLAY EVENTS
+-- proof-of-play: one row per content item a screen reports having played. Partitioned by
+-- month on started_at; the default partition catches anything outside the created months.
+-- No foreign keys, so plays stay on record after their screen or content is deleted.
+CREATE TABLE IF NOT EXISTS play_events (
+  id              BIGSERIAL,
+  organization_id BIGINT NOT NULL,
+  screen_id       BIGINT NOT NULL,
+  content_id      BIGINT NOT NULL,
+  playlist_id     BIGINT,
+  started_at      TIMESTAMPTZ NOT NULL,
+  ended_at        TIMESTAMPTZ NOT NULL,
+  received_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CHECK (ended_at >= started_at),
+  -- players resend batches they could not confirm; a screen cannot start the same item twice at once
+  UNIQUE (screen_id, content_id, started_at)
+) PARTITION BY RANGE (started_at);
+
+CREATE TABLE IF NOT EXISTS play_events_default PARTITION OF play_events DEFAULT;
+
+CREATE INDEX IF NOT EXISTS idx_play_events_org_started ON play_events(organization_id, started_at);
+
+-- ensure_monthly_partitions gives a table partitioned by month, named parent_YYYY_MM, a
+-- partition for last month and each of the next twelve. Migrations run on every start, so
+-- calling it from a migration keeps a year of partitions ahead.
+CREATE OR REPLACE FUNCTION ensure_monthly_partitions(parent TEXT)
+RETURNS void AS $$
+DECLARE
+  m DATE;
+BEGIN
+  FOR i IN -1..12 LOOP
+    m := (date_trunc('month', now()) + make_interval(months => i))::date;
+    BEGIN
+      EXECUTE format(
+        'CREATE TABLE IF NOT EXISTS %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)',
+        parent || '_' || to_char(m, 'YYYY_MM'), parent, m, (m + interval '1 month')::date);
+    EXCEPTION WHEN others THEN
+      -- rows for this month already sit in the default partition
+      RAISE NOTICE 'skipping % partition for %: %', parent, m, SQLERRM;
+    END;
+  END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT ensure_monthly_partitions('play_events');
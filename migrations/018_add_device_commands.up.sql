@@ -0,0 +1,19 @@
+-- @DEVICE COMMANDS
+-- remote commands sent to a screen's device; the device acknowledges each one by id
+CREATE TABLE IF NOT EXISTS device_commands (
+  id              TEXT   PRIMARY KEY,
+  organization_id BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  screen_id       BIGINT NOT NULL REFERENCES screens(id) ON DELETE CASCADE,
+  type            TEXT   NOT NULL,
+  params          JSONB,
+  status          TEXT   NOT NULL DEFAULT 'pending'
+                    CHECK (status IN ('pending', 'sent', 'succeeded', 'failed')),
+  message         TEXT,
+  result          JSONB,
+  issued_by       BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at      TIMESTAMPTZ NOT NULL,
+  sent_at         TIMESTAMPTZ,
+  acked_at        TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_device_commands_screen ON device_commands(screen_id, created_at DESC);
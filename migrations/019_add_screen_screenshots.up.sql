@@ -0,0 +1,15 @@
+-- @SCREENSHOTS
+-- screenshots uploaded by devices, usually in answer to a take_screenshot command; only the
+-- most recent few per screen are kept, the rest are pruned along with their files
+CREATE TABLE IF NOT EXISTS screen_screenshots (
+  id              BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  organization_id BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  screen_id       BIGINT NOT NULL REFERENCES screens(id) ON DELETE CASCADE,
+  command_id      TEXT   REFERENCES device_commands(id) ON DELETE SET NULL,
+  url             TEXT   NOT NULL,
+  content_type    TEXT   NOT NULL,
+  size_bytes      BIGINT NOT NULL,
+  captured_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_screen_screenshots_screen   ON screen_screenshots(screen_id, captured_at DESC);
+CREATE INDEX IF NOT EXISTS idx_screen_screenshots_captured ON screen_screenshots(captured_at);
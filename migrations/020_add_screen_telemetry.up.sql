@@ -0,0 +1,26 @@
+-- @SCREEN TELEMETRY
+-- device health samples sent with /api/tv/report, kept as a time series per screen. Every
+-- metric is optional, since players report what their platform exposes. Partitioned by
+-- month on recorded_at like play_events, so old months can be dropped wholesale.
+CREATE TABLE IF NOT EXISTS screen_telemetry (
+  id                   BIGSERIAL,
+  screen_id            BIGINT NOT NULL REFERENCES screens(id) ON DELETE CASCADE,
+  recorded_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+  cpu_percent          DOUBLE PRECISION,
+  memory_used_bytes    BIGINT,
+  memory_total_bytes   BIGINT,
+  temperature_celsius  DOUBLE PRECISION,
+  storage_used_bytes   BIGINT,
+  storage_total_bytes  BIGINT,
+  network_type         TEXT,
+  signal_dbm           INT,
+  rx_bytes_per_second  BIGINT,
+  tx_bytes_per_second  BIGINT
+) PARTITION BY RANGE (recorded_at);
+
+CREATE TABLE IF NOT EXISTS screen_telemetry_default PARTITION OF screen_telemetry DEFAULT;
+
+CREATE INDEX IF NOT EXISTS idx_screen_telemetry_screen_recorded ON screen_telemetry(screen_id, recorded_at DESC);
+
+-- ensure_monthly_partitions is defined in 017_add_play_events
+SELECT ensure_monthly_partitions('screen_telemetry');
@@ -0,0 +1,4 @@
+ALTER TABLE content
+  DROP COLUMN IF EXISTS mime_type,
+  DROP COLUMN IF EXISTS size_bytes,
+  DROP COLUMN IF EXISTS sha256;
@@ -0,0 +1,6 @@
+-- @CONTENT CHECKSUMS
+-- what players need to verify a cached copy of an uploaded file; NULL for content that is
+-- not a stored file (integrations, external URLs) and for files uploaded before this
+ALTER TABLE content ADD COLUMN IF NOT EXISTS sha256     TEXT;
+ALTER TABLE content ADD COLUMN IF NOT EXISTS size_bytes BIGINT;
+ALTER TABLE content ADD COLUMN IF NOT EXISTS mime_type  TEXT;
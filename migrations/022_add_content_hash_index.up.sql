@@ -0,0 +1,4 @@
+-- @CONTENT HASH INDEX
+-- uploads are stored once per organization under their SHA-256, so several content rows can
+-- share a file; this finds the other rows before the file is deleted
+CREATE INDEX IF NOT EXISTS idx_content_org_sha256 ON content (organization_id, sha256) WHERE sha256 IS NOT NULL;
@@ -0,0 +1,27 @@
+-- @CONTENT UPLOADS
+-- large files uploaded in chunks over several requests; the content row is only created once
+-- every byte has arrived. hash_state carries the running SHA-256 between chunks, and the
+-- storage columns locate the partial file (a temp file, or an S3 multipart upload).
+CREATE TABLE IF NOT EXISTS content_uploads (
+  id                TEXT PRIMARY KEY,
+  organization_id   BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  created_by        BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name              TEXT   NOT NULL,
+  type              TEXT   NOT NULL,
+  width             INT    NOT NULL,
+  height            INT    NOT NULL,
+  filename          TEXT   NOT NULL,
+  size_bytes        BIGINT NOT NULL CHECK (size_bytes > 0),
+  received_bytes    BIGINT NOT NULL DEFAULT 0,
+  parts             INT    NOT NULL DEFAULT 0,
+  mime_type         TEXT,
+  hash_state        BYTEA,
+  storage_key       TEXT   NOT NULL,
+  storage_upload_id TEXT,
+  status            TEXT   NOT NULL DEFAULT 'uploading',
+  created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at        TIMESTAMPTZ NOT NULL,
+  CONSTRAINT content_uploads_status_chk CHECK (status IN ('uploading','completing'))
+);
+CREATE INDEX IF NOT EXISTS idx_content_uploads_expires ON content_uploads(expires_at);
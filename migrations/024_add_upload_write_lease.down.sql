@@ -0,0 +1,5 @@
+UPDATE content_uploads SET status = 'uploading' WHERE status = 'writing';
+ALTER TABLE content_uploads DROP CONSTRAINT IF EXISTS content_uploads_status_chk;
+ALTER TABLE content_uploads ADD CONSTRAINT content_uploads_status_chk
+  CHECK (status IN ('uploading','completing'));
+ALTER TABLE content_uploads DROP COLUMN IF EXISTS write_lease;
@@ -0,0 +1,7 @@
+-- @CONTENT UPLOAD WRITE LEASE
+-- a chunk claims its offset before it is written, so two requests sending the same chunk
+-- cannot both write it; write_lease names the request holding the claim
+ALTER TABLE content_uploads ADD COLUMN IF NOT EXISTS write_lease TEXT;
+ALTER TABLE content_uploads DROP CONSTRAINT IF EXISTS content_uploads_status_chk;
+ALTER TABLE content_uploads ADD CONSTRAINT content_uploads_status_chk
+  CHECK (status IN ('uploading','writing','completing'));
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_alert_states_undelivered;
+ALTER TABLE alert_states DROP COLUMN IF EXISTS notify_attempted_at;
+ALTER TABLE alert_states DROP COLUMN IF EXISTS notify_attempts;
+ALTER TABLE alert_states DROP COLUMN IF EXISTS notified;
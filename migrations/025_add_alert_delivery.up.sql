@@ -0,0 +1,7 @@
+-- @ALERT DELIVERY
+-- a state change is only notified once; notified stays false until a notification is
+-- delivered, so failed deliveries are retried. rows from before this migration were sent.
+ALTER TABLE alert_states ADD COLUMN IF NOT EXISTS notified            BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE alert_states ADD COLUMN IF NOT EXISTS notify_attempts     INT     NOT NULL DEFAULT 0;
+ALTER TABLE alert_states ADD COLUMN IF NOT EXISTS notify_attempted_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_alert_states_undelivered ON alert_states (rule_id) WHERE NOT notified;
@@ -0,0 +1 @@
+ALTER TABLE screen_telemetry DROP COLUMN IF EXISTS ip_address;
@@ -0,0 +1,4 @@
+-- @TELEMETRY IP
+-- the address each telemetry report came from, so a device's network changes show up
+-- alongside its other metrics
+ALTER TABLE screen_telemetry ADD COLUMN IF NOT EXISTS ip_address TEXT;